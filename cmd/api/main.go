@@ -13,6 +13,10 @@ import (
 // @in							header
 // @name						Authorization
 // @description				Type "Bearer" followed by a space and JWT token.
+// @securityDefinitions.apikey	ApiKey
+// @in							header
+// @name						X-API-Key
+// @description				API key of a service, created through /api-key.
 func main() {
 
 	server := server.NewServer()
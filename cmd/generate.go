@@ -6,6 +6,7 @@ import (
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gen"
+	"gorm.io/gen/field"
 	"gorm.io/gorm"
 
 	_ "github.com/joho/godotenv/autoload"
@@ -36,8 +37,44 @@ func main() {
 	// apply basic crud api on structs or table models which is specified by table name with function
 	// GenerateModel/GenerateModelAs. And generator will generate table models' code when calling Excute.
 	//g.ApplyBasic(model.User{}, g.GenerateModel("company"), g.GenerateModelAs("people", "Person", gen.FieldIgnore("address")))
-	g.ApplyBasic(g.GenerateAllTable()...)
+	g.ApplyBasic(g.GenerateAllTable(append(jsonColumns(), swaggerColumns()...)...)...)
 
 	// execute the action of code generation
 	g.Execute()
 }
+
+// jsonColumns maps the JSON columns to Go types that GORM serializes as JSON. Column
+// names are unique across the tables, so the options can apply to every table.
+func jsonColumns() []gen.ModelOpt {
+	columns := []struct{ name, goType string }{
+		// Orders keep a copy of their addresses, see model.AddressSnapshot.
+		{"shippingAddress", "*AddressSnapshot"},
+		{"billingAddress", "*AddressSnapshot"},
+		// Merges keep the address IDs of the orders they move, see model.MergedOrderAddresses.
+		{"order_addresses", "[]MergedOrderAddresses"},
+		// Custom attributes of customers and the allowed values of enum attributes.
+		{"attributes", "map[string]any"},
+		{"options", "[]string"},
+	}
+
+	var opts []gen.ModelOpt
+	for _, column := range columns {
+		opts = append(opts,
+			gen.FieldType(column.name, column.goType),
+			gen.FieldGORMTag(column.name, func(tag field.GormTag) field.GormTag {
+				return tag.Set("serializer", "json")
+			}),
+		)
+	}
+	return opts
+}
+
+// swaggerColumns documents the columns whose Go types swag cannot describe on its own.
+func swaggerColumns() []gen.ModelOpt {
+	return []gen.ModelOpt{
+		// gorm.DeletedAt is a struct that is a time or null in JSON.
+		gen.FieldTag("deleted_at", func(tag field.Tag) field.Tag {
+			return tag.Set("swaggertype", "string").Set("format", "date-time")
+		}),
+	}
+}
@@ -0,0 +1,85 @@
+// Package apikey issues and checks the API keys that services use instead of logging in.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/model"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	keyPrefix = "dbo_"
+
+	// lastUsedInterval limits how often the last use of a key is written, so a busy
+	// integration does not update its row on every request.
+	lastUsedInterval = time.Minute
+)
+
+// ErrInvalidKey is returned for unknown, revoked and expired keys.
+var ErrInvalidKey = errors.New("invalid API key")
+
+// Generate returns a new random key together with the prefix that identifies it in listings.
+// Only the hash of the key is stored; the key itself is shown once.
+func Generate() (key, prefix string, err error) {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	prefix = keyPrefix + hex.EncodeToString(id)
+	return prefix + "_" + base64.RawURLEncoding.EncodeToString(secret), prefix, nil
+}
+
+// Hash returns the value stored for a key.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticate returns the active API key matching key and records its use.
+func Authenticate(key string) (*model.APIKey, error) {
+	if !strings.HasPrefix(key, keyPrefix) {
+		return nil, ErrInvalidKey
+	}
+
+	stored, err := dal.APIKey.Where(dal.APIKey.KeyHash.Eq(Hash(key))).First()
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidKey
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if stored.RevokedAt != nil || (stored.ExpiresAt != nil && !now.Before(*stored.ExpiresAt)) {
+		return nil, ErrInvalidKey
+	}
+
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) >= lastUsedInterval {
+		if _, err := dal.APIKey.Where(dal.APIKey.ID.Eq(stored.ID)).Update(dal.APIKey.LastUsedAt, now); err != nil {
+			return nil, err
+		}
+		stored.LastUsedAt = &now
+	}
+	return stored, nil
+}
+
+// Scopes splits the stored scopes of a key into role names.
+func Scopes(key *model.APIKey) []string {
+	if key.Scopes == "" {
+		return nil
+	}
+	return strings.Split(key.Scopes, ",")
+}
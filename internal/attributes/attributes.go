@@ -0,0 +1,175 @@
+// Package attributes validates the custom attributes of customers against the
+// schema admins define for each attribute key.
+package attributes
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Types an attribute can have.
+const (
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeEnum    = "enum"
+)
+
+// maxStringLength is the longest string value an attribute can hold.
+const maxStringLength = 1024
+
+var keyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// Definition is the schema of one attribute key.
+type Definition struct {
+	Key     string
+	Type    string
+	Options []string
+}
+
+// ValidKey reports whether key can name an attribute: lowercase letters, digits
+// and underscores, starting with a letter.
+func ValidKey(key string) bool {
+	return keyPattern.MatchString(key)
+}
+
+// Check returns an error when the definition itself is invalid.
+func (d Definition) Check() error {
+	if !ValidKey(d.Key) {
+		return errors.New("key must start with a letter and contain only lowercase letters, digits and underscores")
+	}
+	switch d.Type {
+	case TypeString, TypeNumber, TypeBoolean:
+		if len(d.Options) > 0 {
+			return fmt.Errorf("options are only allowed for %s attributes", TypeEnum)
+		}
+	case TypeEnum:
+		if len(d.Options) == 0 {
+			return errors.New("enum attributes need at least one option")
+		}
+		seen := map[string]bool{}
+		for _, option := range d.Options {
+			if option == "" || seen[option] {
+				return errors.New("enum options must be unique and not empty")
+			}
+			seen[option] = true
+		}
+	default:
+		return fmt.Errorf("type must be %s, %s, %s or %s", TypeString, TypeNumber, TypeBoolean, TypeEnum)
+	}
+	return nil
+}
+
+// Validate returns an error when value, as decoded from JSON, does not fit the definition.
+func (d Definition) Validate(value any) error {
+	switch d.Type {
+	case TypeString:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s must be a string", d.Key)
+		}
+		if len(s) > maxStringLength {
+			return fmt.Errorf("%s must be at most %d characters", d.Key, maxStringLength)
+		}
+	case TypeNumber:
+		n, ok := value.(float64)
+		if !ok || math.IsNaN(n) || math.IsInf(n, 0) {
+			return fmt.Errorf("%s must be a number", d.Key)
+		}
+	case TypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s must be true or false", d.Key)
+		}
+	case TypeEnum:
+		s, ok := value.(string)
+		if !ok || !d.hasOption(s) {
+			return fmt.Errorf("%s must be one of %s", d.Key, strings.Join(d.Options, ", "))
+		}
+	default:
+		return fmt.Errorf("%s has unknown type %q", d.Key, d.Type)
+	}
+	return nil
+}
+
+// ParseFilter parses a filter value given as query text into the value it is compared with:
+// a float64 for numbers, a bool for booleans and a string otherwise.
+func (d Definition) ParseFilter(s string) (any, error) {
+	var value any = s
+	switch d.Type {
+	case TypeNumber:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", d.Key)
+		}
+		value = n
+	case TypeBoolean:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be true or false", d.Key)
+		}
+		value = b
+	}
+	if err := d.Validate(value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// ErrUndefined is reported by Validate for a key without a definition.
+var ErrUndefined = errors.New("is not a defined attribute")
+
+// Errors maps the attribute keys whose values are invalid to the reason.
+type Errors map[string]error
+
+func (e Errors) Error() string {
+	problems := make([]string, 0, len(e))
+	for _, err := range e {
+		problems = append(problems, err.Error())
+	}
+	sort.Strings(problems)
+	return strings.Join(problems, "; ")
+}
+
+// Validate checks a set of attribute values against the definitions and returns Errors
+// when some do not fit. Keys without a definition are rejected. A nil value removes the
+// attribute and is dropped from values.
+func Validate(defs map[string]Definition, values map[string]any) error {
+	errs := Errors{}
+	for key, value := range values {
+		def, ok := defs[key]
+		if !ok {
+			errs[key] = fmt.Errorf("%s %w", key, ErrUndefined)
+			continue
+		}
+		if value == nil {
+			delete(values, key)
+			continue
+		}
+		if err := def.Validate(value); err != nil {
+			errs[key] = err
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Path returns the JSON path of an attribute key inside the attributes object.
+func Path(key string) string {
+	return `$."` + key + `"`
+}
+
+func (d Definition) hasOption(s string) bool {
+	for _, option := range d.Options {
+		if option == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,433 @@
+package controllers
+
+import (
+	"dbo-test/internal/dal"
+	"dbo-test/internal/model"
+	"dbo-test/internal/problem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	errAddressNotFound     = errors.New("address not found")
+	errInvalidOrderAddress = errors.New("invalid order address")
+)
+
+type addressReq struct {
+	Type       string `json:"type" binding:"required,oneof=billing shipping"`
+	Recipient  string `json:"recipient" binding:"max=255"`
+	Line1      string `json:"line1" binding:"required,max=255"`
+	Line2      string `json:"line2" binding:"max=255"`
+	City       string `json:"city" binding:"required,max=128"`
+	Region     string `json:"region" binding:"max=128"`
+	PostalCode string `json:"postal_code" binding:"max=32"`
+	Country    string `json:"country" binding:"required,iso3166_1_alpha2" example:"ID"`
+	IsDefault  bool   `json:"is_default"`
+}
+
+func (r *addressReq) normalize() {
+	for _, s := range []*string{&r.Type, &r.Recipient, &r.Line1, &r.Line2, &r.City, &r.Region, &r.PostalCode, &r.Country} {
+		*s = strings.TrimSpace(*s)
+	}
+	r.Type = strings.ToLower(r.Type)
+	r.Country = strings.ToUpper(r.Country)
+}
+
+// GetCustomerAddresses godoc
+//
+//	@Summary		Get customer addresses
+//	@Description	Get the billing and shipping addresses of a customer, defaults first
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path	int		true	"Customer ID"
+//	@Param			type	query	string	false	"Only addresses of this type"	Enums(billing, shipping)
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse{data=[]model.Address}
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/{id}/addresses [get]
+func GetCustomerAddresses(c *gin.Context) {
+	customerID, ok := bindExistingCustomer(c)
+	if !ok {
+		return
+	}
+
+	resultOrm := dal.Address.WithContext(c.Request.Context()).Where(dal.Address.CustomerID.Eq(customerID))
+	if addressType := c.Query("type"); addressType != "" {
+		resultOrm = resultOrm.Where(dal.Address.Type.Eq(addressType))
+	}
+	addresses, err := resultOrm.Order(dal.Address.IsDefault.Desc(), dal.Address.ID).Find()
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   addresses,
+	})
+}
+
+// GetCustomerAddress godoc
+//
+//	@Summary		Get a customer address
+//	@Description	Get a single address of a customer
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path	int	true	"Customer ID"
+//	@Param			addressId	path	int	true	"Address ID"
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse{data=model.Address}
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/{id}/addresses/{addressId} [get]
+func GetCustomerAddress(c *gin.Context) {
+	customerID, ok := bindExistingCustomer(c)
+	if !ok {
+		return
+	}
+	addressID, err := strconv.Atoi(c.Param("addressId"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid address id")
+		return
+	}
+
+	address, err := findCustomerAddress(dal.Q, customerID, int32(addressID))
+	if err != nil {
+		respondAddressError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   address,
+	})
+}
+
+// CreateCustomerAddress godoc
+//
+//	@Summary		Add a customer address
+//	@Description	Add a billing or shipping address to a customer. The first address of a type
+//	@Description	becomes the default; making another address the default clears the old one.
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path	int			true	"Customer ID"
+//	@Param			address	body	addressReq	true	"Address details"
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		201	{object}	successResponse{data=model.Address}
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/{id}/addresses [post]
+func CreateCustomerAddress(c *gin.Context) {
+	customerID, ok := bindExistingCustomer(c)
+	if !ok {
+		return
+	}
+	var input addressReq
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	actorID := currentActorID(c)
+	address := &model.Address{
+		CustomerID: customerID,
+		Type:       input.Type,
+		Recipient:  input.Recipient,
+		Line1:      input.Line1,
+		Line2:      input.Line2,
+		City:       input.City,
+		Region:     input.Region,
+		PostalCode: input.PostalCode,
+		Country:    input.Country,
+		IsDefault:  input.IsDefault,
+		CreatedBy:  actorID,
+		UpdatedBy:  actorID,
+	}
+	err := dal.Q.Transaction(func(tx *dal.Query) error {
+		if !address.IsDefault {
+			count, err := tx.Address.Where(
+				tx.Address.CustomerID.Eq(customerID),
+				tx.Address.Type.Eq(address.Type),
+			).Count()
+			if err != nil {
+				return err
+			}
+			address.IsDefault = count == 0
+		}
+		if address.IsDefault {
+			if err := clearDefaultAddress(tx, customerID, address.Type, 0); err != nil {
+				return err
+			}
+		}
+		return tx.Address.Create(address)
+	})
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, successResponse{
+		Status: successStatus,
+		Data:   address,
+	})
+}
+
+// UpdateCustomerAddress godoc
+//
+//	@Summary		Update a customer address
+//	@Description	Replace the details of a customer address. Orders keep the copy of the address
+//	@Description	they were placed with. Every type with addresses keeps one default: when the default
+//	@Description	address is no longer the default or changes its type, the oldest other address of
+//	@Description	its type becomes the default, and the only address of a type is always its default.
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path	int			true	"Customer ID"
+//	@Param			addressId	path	int			true	"Address ID"
+//	@Param			address		body	addressReq	true	"Updated address details"
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse{data=model.Address}
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/{id}/addresses/{addressId} [put]
+func UpdateCustomerAddress(c *gin.Context) {
+	customerID, ok := bindExistingCustomer(c)
+	if !ok {
+		return
+	}
+	addressID, err := strconv.Atoi(c.Param("addressId"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid address id")
+		return
+	}
+	var input addressReq
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	var address *model.Address
+	err = dal.Q.Transaction(func(tx *dal.Query) error {
+		var err error
+		address, err = findCustomerAddress(tx, customerID, int32(addressID))
+		if err != nil {
+			return err
+		}
+		isDefault := input.IsDefault
+		if isDefault {
+			if err := clearDefaultAddress(tx, customerID, input.Type, address.ID); err != nil {
+				return err
+			}
+		} else if address.IsDefault && address.Type == input.Type {
+			// Another address takes over as the default; the only address of its type stays the default.
+			promoted, err := promoteDefaultAddress(tx, customerID, input.Type, address.ID)
+			if err != nil {
+				return err
+			}
+			isDefault = !promoted
+		} else {
+			// The first address of a type is its default, also when it arrives by a change of type.
+			count, err := tx.Address.Where(
+				tx.Address.CustomerID.Eq(customerID),
+				tx.Address.Type.Eq(input.Type),
+				tx.Address.ID.Neq(address.ID),
+			).Count()
+			if err != nil {
+				return err
+			}
+			isDefault = count == 0
+		}
+		if address.IsDefault && address.Type != input.Type {
+			// The address leaves its type, which needs another default.
+			if _, err := promoteDefaultAddress(tx, customerID, address.Type, address.ID); err != nil {
+				return err
+			}
+		}
+
+		address.Type = input.Type
+		address.Recipient = input.Recipient
+		address.Line1 = input.Line1
+		address.Line2 = input.Line2
+		address.City = input.City
+		address.Region = input.Region
+		address.PostalCode = input.PostalCode
+		address.Country = input.Country
+		address.IsDefault = isDefault
+		address.UpdatedBy = currentActorID(c)
+		_, err = tx.Address.Where(tx.Address.ID.Eq(address.ID)).Select(
+			tx.Address.Type, tx.Address.Recipient, tx.Address.Line1, tx.Address.Line2,
+			tx.Address.City, tx.Address.Region, tx.Address.PostalCode, tx.Address.Country,
+			tx.Address.IsDefault, tx.Address.UpdatedBy,
+		).Updates(address)
+		return err
+	})
+	if err != nil {
+		respondAddressError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   address,
+	})
+}
+
+// DeleteCustomerAddress godoc
+//
+//	@Summary		Delete a customer address
+//	@Description	Delete a customer address. Orders keep the copy of the address they were placed with.
+//	@Description	When the default address is deleted, the oldest remaining address of its type becomes the default.
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path	int	true	"Customer ID"
+//	@Param			addressId	path	int	true	"Address ID"
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/{id}/addresses/{addressId} [delete]
+func DeleteCustomerAddress(c *gin.Context) {
+	customerID, ok := bindExistingCustomer(c)
+	if !ok {
+		return
+	}
+	addressID, err := strconv.Atoi(c.Param("addressId"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid address id")
+		return
+	}
+
+	err = dal.Q.Transaction(func(tx *dal.Query) error {
+		address, err := findCustomerAddress(tx, customerID, int32(addressID))
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Address.Where(tx.Address.ID.Eq(address.ID)).Delete(); err != nil {
+			return err
+		}
+
+		// Orders keep their snapshot but no longer point at the deleted address.
+		if _, err := tx.Order.Where(tx.Order.ShippingAddressID.Eq(address.ID)).UpdateSimple(tx.Order.ShippingAddressID.Null()); err != nil {
+			return err
+		}
+		if _, err := tx.Order.Where(tx.Order.BillingAddressID.Eq(address.ID)).UpdateSimple(tx.Order.BillingAddressID.Null()); err != nil {
+			return err
+		}
+
+		if !address.IsDefault {
+			return nil
+		}
+		_, err = promoteDefaultAddress(tx, customerID, address.Type, address.ID)
+		return err
+	})
+	if err != nil {
+		respondAddressError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   nil,
+	})
+}
+
+func findCustomerAddress(q *dal.Query, customerID, addressID int32) (*model.Address, error) {
+	address, err := q.Address.Where(
+		q.Address.ID.Eq(addressID),
+		q.Address.CustomerID.Eq(customerID),
+	).First()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errAddressNotFound
+	}
+	return address, err
+}
+
+// clearDefaultAddress unsets the default address of a type, except for the address keepID.
+func clearDefaultAddress(q *dal.Query, customerID int32, addressType string, keepID int32) error {
+	_, err := q.Address.Where(
+		q.Address.CustomerID.Eq(customerID),
+		q.Address.Type.Eq(addressType),
+		q.Address.IsDefault.Is(true),
+		q.Address.ID.Neq(keepID),
+	).UpdateSimple(q.Address.IsDefault.Value(false))
+	return err
+}
+
+// promoteDefaultAddress makes the oldest address of a type, other than the address skipID,
+// the default. It reports false when there is no such address.
+func promoteDefaultAddress(q *dal.Query, customerID int32, addressType string, skipID int32) (bool, error) {
+	next, err := q.Address.Where(
+		q.Address.CustomerID.Eq(customerID),
+		q.Address.Type.Eq(addressType),
+		q.Address.ID.Neq(skipID),
+	).Order(q.Address.ID).First()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	_, err = q.Address.Where(q.Address.ID.Eq(next.ID)).UpdateSimple(q.Address.IsDefault.Value(true))
+	return err == nil, err
+}
+
+func respondAddressError(c *gin.Context, err error) {
+	if errors.Is(err, errAddressNotFound) {
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, err.Error())
+		return
+	}
+	problem.Error(c, err)
+}
+
+// orderAddress returns the address an order of the customer is sent or billed to, with a
+// snapshot of it. Without an address ID the customer's default address of the type is used,
+// if there is one.
+func orderAddress(q *dal.Query, customerID int32, addressID *int32, addressType string) (*int32, *model.AddressSnapshot, error) {
+	var (
+		address *model.Address
+		err     error
+	)
+	if addressID != nil {
+		address, err = findCustomerAddress(q, customerID, *addressID)
+		if errors.Is(err, errAddressNotFound) {
+			return nil, nil, fmt.Errorf("%w: %s address %d does not belong to customer %d", errInvalidOrderAddress, addressType, *addressID, customerID)
+		}
+	} else {
+		address, err = q.Address.Where(
+			q.Address.CustomerID.Eq(customerID),
+			q.Address.Type.Eq(addressType),
+			q.Address.IsDefault.Is(true),
+		).First()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, nil
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return &address.ID, address.Snapshot(), nil
+}
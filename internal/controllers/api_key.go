@@ -0,0 +1,245 @@
+package controllers
+
+import (
+	"context"
+	"dbo-test/internal/apikey"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/middlewares"
+	"dbo-test/internal/model"
+	"dbo-test/internal/problem"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type createAPIKeyReq struct {
+	Name      string     `json:"name" binding:"required,max=255"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,unique" example:"sales"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type apiKeyResp struct {
+	ID         int32      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedBy  *int32     `json:"created_by"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type createAPIKeyResp struct {
+	apiKeyResp
+	// Key is only returned when the key is created.
+	Key string `json:"key"`
+}
+
+func newAPIKeyResp(key *model.APIKey) apiKeyResp {
+	return apiKeyResp{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     apikey.Scopes(key),
+		CreatedBy:  key.CreatedBy,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+// @Summary		Create an API key
+// @Description	Create an API key for a service. Its scopes are the roles it acts with, sales or
+// @Description	readonly; keys cannot act as admins. The key is returned only once and must be sent
+// @Description	in the X-API-Key header.
+// @Tags			API Key
+// @Accept			json
+// @Produce		json
+// @Param			input	body	createAPIKeyReq	true	"create api key req"
+// @Security		Bearer
+// @Success		200	{object}	successResponse{data=createAPIKeyResp}
+// @Failure		400	{object}	problem.Details
+// @Failure		403	{object}	problem.Details
+// @Failure		500	{object}	problem.Details
+// @Router			/api-key [post]
+func CreateAPIKey(c *gin.Context) {
+	var input createAPIKeyReq
+	if !bindJSON(c, &input) {
+		return
+	}
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(time.Now()) {
+		problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, "expires_at must be in the future")
+		return
+	}
+
+	for _, scope := range input.Scopes {
+		if !slices.Contains(middlewares.APIKeyScopes, scope) {
+			problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest,
+				fmt.Sprintf("scope %q is not allowed for API keys, use one of %s", scope, strings.Join(middlewares.APIKeyScopes, ", ")))
+			return
+		}
+	}
+
+	roles, err := findRoles(input.Scopes)
+	if err != nil {
+		if errors.Is(err, errUnknownRole) {
+			problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, err.Error())
+			return
+		}
+		problem.Error(c, fmt.Errorf("cannot find roles: %w", err))
+		return
+	}
+	scopes := make([]string, 0, len(roles))
+	for _, role := range roles {
+		scopes = append(scopes, role.Name)
+	}
+
+	key, prefix, err := apikey.Generate()
+	if err != nil {
+		problem.Error(c, fmt.Errorf("cannot generate api key: %w", err))
+		return
+	}
+
+	stored := &model.APIKey{
+		Name:      input.Name,
+		Prefix:    prefix,
+		KeyHash:   apikey.Hash(key),
+		Scopes:    strings.Join(scopes, ","),
+		ExpiresAt: input.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+	if userID, err := currentUserID(c); err == nil {
+		stored.CreatedBy = &userID
+	}
+	if err := dal.APIKey.Create(stored); err != nil {
+		problem.Error(c, fmt.Errorf("cannot create api key: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data: createAPIKeyResp{
+			apiKeyResp: newAPIKeyResp(stored),
+			Key:        key,
+		},
+	})
+}
+
+// @Summary		Get API keys
+// @Description	Get the API keys, newest first, without the keys themselves
+// @Tags			API Key
+// @Accept			json
+// @Produce		json
+// @Param			page		query	int		false	"Page number"				default(1)
+// @Param			pagesize	query	int		false	"Number of items per page"	default(10)
+// @Param			revoked		query	bool	false	"Include revoked keys"		default(false)
+// @Security		Bearer
+// @Success		200	{object}	successResponse{data=PagedResults{data=[]apiKeyResp}}
+// @Failure		400	{object}	problem.Details
+// @Failure		403	{object}	problem.Details
+// @Failure		500	{object}	problem.Details
+// @Router			/api-key [get]
+func GetMultipleAPIKey(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid page")
+		return
+	}
+	pagesize, err := strconv.Atoi(c.DefaultQuery("pagesize", "10"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid pagesize")
+		return
+	}
+	includeRevoked, err := strconv.ParseBool(c.DefaultQuery("revoked", "false"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid revoked")
+		return
+	}
+
+	apiKeyQuery := dal.APIKey
+	resultOrm := apiKeyQuery.WithContext(context.Background())
+	if !includeRevoked {
+		resultOrm = resultOrm.Where(apiKeyQuery.RevokedAt.IsNull())
+	}
+
+	totalRecords, err := resultOrm.Count()
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	if page > 0 {
+		resultOrm = resultOrm.Offset((page - 1) * pagesize)
+	}
+	keys, err := resultOrm.Order(apiKeyQuery.ID.Desc()).Limit(pagesize).Find()
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	resp := make([]apiKeyResp, 0, len(keys))
+	for _, key := range keys {
+		resp = append(resp, newAPIKeyResp(key))
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data: PagedResults{
+			Page:         int64(page),
+			PageSize:     int64(pagesize),
+			Data:         resp,
+			TotalRecords: int(totalRecords),
+		},
+	})
+}
+
+// @Summary		Revoke an API key
+// @Description	Revoke an API key. Requests using it are rejected from then on.
+// @Tags			API Key
+// @Accept			json
+// @Produce		json
+// @Param			id	path	int	true	"API key ID"
+// @Security		Bearer
+// @Success		200	{object}	successResponse
+// @Failure		400	{object}	problem.Details
+// @Failure		403	{object}	problem.Details
+// @Failure		404	{object}	problem.Details
+// @Failure		500	{object}	problem.Details
+// @Router			/api-key/{id} [delete]
+func RevokeAPIKey(c *gin.Context) {
+	keyID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid id")
+		return
+	}
+
+	key, err := dal.APIKey.Where(dal.APIKey.ID.Eq(int32(keyID))).First()
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			problem.Error(c, err)
+			return
+		}
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "api key not found")
+		return
+	}
+
+	if key.RevokedAt == nil {
+		if _, err := dal.APIKey.Where(dal.APIKey.ID.Eq(key.ID)).Update(dal.APIKey.RevokedAt, time.Now()); err != nil {
+			problem.Error(c, fmt.Errorf("cannot revoke api key: %w", err))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+	})
+}
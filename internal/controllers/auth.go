@@ -1,108 +1,565 @@
 package controllers
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
 	"dbo-test/internal/dal"
+	"dbo-test/internal/jwtkeys"
+	"dbo-test/internal/loginguard"
+	"dbo-test/internal/middlewares"
 	"dbo-test/internal/model"
+	"dbo-test/internal/password"
+	"dbo-test/internal/problem"
+	"dbo-test/internal/revocation"
+	"dbo-test/internal/session"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
+var errRefreshTokenReused = errors.New("refresh token has already been used")
+
 type loginReq struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type refreshReq struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type tokenResp struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
 }
 
 // @Summary		Logs in a user
-// @Description	Logs in a user with email and password
+// @Description	Logs in a user with email and password. When the user has two-factor authentication enabled
+// @Description	the response holds a challenge token instead, to be exchanged at /auth/login/2fa.
+// @Description	Every failed attempt makes the client wait longer before the next one, and the account
+// @Description	is locked for a while after too many failures.
 // @Tags			Auth
 // @Accept			json
 // @Produce		json
 // @Param			input	body		loginReq	true	"login req"
-// @Success		200		{object}	successResponse
-// @Failure		400		{object}	errorResponse
+// @Success		200		{object}	successResponse{data=tokenResp}
+// @Failure		400		{object}	problem.Details
+// @Failure		403		{object}	problem.Details
+// @Failure		423		{object}	problem.Details
+// @Failure		429		{object}	problem.Details
+// @Failure		500		{object}	problem.Details
 // @Router			/auth/login [post]
-func LoginHandler(c *gin.Context) {
-	var input loginReq
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: err.Error(),
-		})
-		return
+func LoginHandler(guard *loginguard.Guard, keys *jwtkeys.KeySet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input loginReq
+		if !bindJSON(c, &input) {
+			return
+		}
+
+		if rejectThrottledLogin(c, guard, input.Email) {
+			return
+		}
+
+		user, err := dal.User.Where(dal.User.Email.Eq(input.Email)).First()
+		if err != nil {
+			guard.Failure(input.Email, c.ClientIP())
+			recordLoginAttempt(c, nil, input.Email, loginStatusFailed, failureUnknownEmail)
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidCredentials, "invalid user")
+			return
+		}
+
+		if rejectLockedAccount(c, user) {
+			return
+		}
+
+		if !password.Verify(user.Password, input.Password) {
+			registerLoginFailure(c, guard, user, failureWrongPassword)
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidCredentials, "invalid user")
+			return
+		}
+		upgradePasswordHash(user, input.Password)
+
+		if user.DisabledAt != nil {
+			recordLoginAttempt(c, &user.ID, user.Email, loginStatusFailed, failureUserDisabled)
+			problem.Write(c, http.StatusForbidden, problem.CodeUserDisabled, "user is disabled")
+			return
+		}
+
+		if user.EmailVerifiedAt == nil {
+			recordLoginAttempt(c, &user.ID, user.Email, loginStatusFailed, failureEmailUnverified)
+			problem.Write(c, http.StatusForbidden, problem.CodeEmailUnverified, "email is not verified")
+			return
+		}
+
+		if user.TotpEnabledAt != nil {
+			respondTwoFactorChallenge(c, keys, user)
+			return
+		}
+
+		completeLogin(c, guard, keys, user)
 	}
+}
 
-	user, err := dal.User.Where(dal.User.Email.Eq(input.Email)).First()
+// respondTwoFactorChallenge answers a login of a user with two-factor authentication
+// with a challenge token to be exchanged at /auth/login/2fa, instead of tokens.
+func respondTwoFactorChallenge(c *gin.Context, keys *jwtkeys.KeySet, user *model.User) {
+	challengeToken, err := generateTwoFactorChallenge(keys, user)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: "invalid user",
-		})
+		problem.Error(c, fmt.Errorf("cannot generate jwt: %w", err))
 		return
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: "invalid user",
-		})
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data: twoFactorChallengeResp{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+		},
+	})
+}
+
+// rejectThrottledLogin answers with 429 when the client has to wait before its next login attempt.
+func rejectThrottledLogin(c *gin.Context, guard *loginguard.Guard, email string) bool {
+	wait := guard.Wait(email, c.ClientIP())
+	if wait <= 0 {
+		return false
+	}
+
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	problem.Write(c, http.StatusTooManyRequests, problem.CodeTooManyRequests, fmt.Sprintf("too many failed login attempts, retry in %d seconds", seconds))
+	return true
+}
+
+// rejectLockedAccount answers with 423 and records the attempt when the account is locked.
+func rejectLockedAccount(c *gin.Context, user *model.User) bool {
+	if user.LockedUntil == nil || !time.Now().Before(*user.LockedUntil) {
+		return false
+	}
+
+	recordLoginAttempt(c, &user.ID, user.Email, loginStatusLocked, failureAccountLocked)
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(*user.LockedUntil).Seconds()))))
+	problem.Write(c, http.StatusLocked, problem.CodeAccountLocked, "account is temporarily locked after too many failed login attempts")
+	return true
+}
+
+// registerLoginFailure records a wrong password or code and locks the account
+// once LOGIN_MAX_FAILED_ATTEMPTS consecutive failures are reached.
+func registerLoginFailure(c *gin.Context, guard *loginguard.Guard, user *model.User, reason string) {
+	guard.Failure(user.Email, c.ClientIP())
+	recordLoginAttempt(c, &user.ID, user.Email, loginStatusFailed, reason)
+
+	maxAttempts := envInt("LOGIN_MAX_FAILED_ATTEMPTS", 5)
+	lockout := time.Minute * time.Duration(envInt("LOGIN_LOCKOUT_DURATION", 15))
+
+	// The count is incremented and read back in one transaction, so concurrent failures
+	// each see their own attempt and none of them is lost.
+	err := dal.Q.Transaction(func(tx *dal.Query) error {
+		if _, err := tx.User.Where(tx.User.ID.Eq(user.ID)).UpdateSimple(tx.User.FailedLoginAttempts.Add(1)); err != nil {
+			return err
+		}
+		current, err := tx.User.Where(tx.User.ID.Eq(user.ID)).Select(tx.User.FailedLoginAttempts).First()
+		if err != nil {
+			return err
+		}
+		if int(current.FailedLoginAttempts) < maxAttempts {
+			return nil
+		}
+		_, err = tx.User.Where(tx.User.ID.Eq(user.ID)).UpdateSimple(
+			tx.User.FailedLoginAttempts.Value(0),
+			tx.User.LockedUntil.Value(time.Now().Add(lockout)),
+		)
+		return err
+	})
+	if err != nil {
+		log.Printf("cannot count failed login of user %d: %v", user.ID, err)
+	}
+}
+
+// upgradePasswordHash rehashes a verified password when its hash uses an older algorithm
+// or a lower cost than configured. Logging in must not fail because of it.
+func upgradePasswordHash(user *model.User, plain string) {
+	hasher := password.HasherFromEnv()
+	if !hasher.NeedsRehash(user.Password) {
 		return
 	}
 
-	accessToken, err := generateJWT(user.Email)
+	hashed, err := hasher.Hash(plain)
+	if err == nil {
+		_, err = dal.User.Where(dal.User.ID.Eq(user.ID)).Update(dal.User.Password, hashed)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse{
-			Status:  errorStatus,
-			Message: fmt.Sprintf("cannot generate jwt: %v", err),
-		})
+		log.Printf("cannot upgrade password hash of user %d: %v", user.ID, err)
 		return
 	}
+	user.Password = hashed
+}
+
+// recordLoginAttempt writes an unsuccessful attempt to the login log.
+// Errors are only logged so that they cannot change the answer given to the client.
+func recordLoginAttempt(c *gin.Context, userID *int32, email, status, reason string) {
+	entry := newLoginLog(c, userID, email, status)
+	entry.FailureReason = &reason
+	if err := dal.LoginLog.Create(entry); err != nil {
+		log.Printf("cannot create login log: %v", err)
+	}
+}
 
-	if err := dal.LoginLog.Create(&model.LoginLog{
-		UserID:    user.ID,
+func newLoginLog(c *gin.Context, userID *int32, email, status string) *model.LoginLog {
+	ip, userAgent := clientInfo(c)
+
+	return &model.LoginLog{
+		UserID:    userID,
+		Email:     &email,
+		Status:    status,
+		IP:        &ip,
+		UserAgent: &userAgent,
 		LoginTime: time.Now(),
-	}); err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse{
-			Status:  errorStatus,
-			Message: fmt.Sprintf("cannot create login log: %v", err),
-		})
+	}
+}
+
+// clientInfo returns the IP and the user agent of the client, shortened to fit the database.
+func clientInfo(c *gin.Context) (string, string) {
+	userAgent := c.Request.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	return c.ClientIP(), userAgent
+}
+
+// completeLogin issues the access and refresh tokens of an authenticated user and records the login.
+func completeLogin(c *gin.Context, guard *loginguard.Guard, keys *jwtkeys.KeySet, user *model.User) {
+	guard.Reset(user.Email)
+	if user.FailedLoginAttempts > 0 || user.LockedUntil != nil {
+		if _, err := dal.User.Where(dal.User.ID.Eq(user.ID)).UpdateSimple(
+			dal.User.FailedLoginAttempts.Value(0),
+			dal.User.LockedUntil.Null(),
+		); err != nil {
+			problem.Error(c, fmt.Errorf("cannot reset failed logins: %w", err))
+			return
+		}
+	}
+
+	familyID, err := randomHex(16)
+	if err != nil {
+		problem.Error(c, fmt.Errorf("cannot generate refresh token: %w", err))
+		return
+	}
+
+	// Every login starts a new session, identified by the family of its refresh tokens.
+	var refreshToken string
+	err = dal.Q.Transaction(func(tx *dal.Query) error {
+		token, stored, err := createRefreshToken(tx, user.ID, familyID)
+		if err != nil {
+			return err
+		}
+		ip, userAgent := clientInfo(c)
+		if _, err := session.Create(tx, user.ID, familyID, ip, userAgent, stored.ExpiresAt); err != nil {
+			return err
+		}
+		refreshToken = token
+		return nil
+	})
+	if err != nil {
+		problem.Error(c, fmt.Errorf("cannot create refresh token: %w", err))
+		return
+	}
+
+	accessToken, tokenID, err := generateJWT(keys, user, familyID)
+	if err != nil {
+		problem.Error(c, fmt.Errorf("cannot generate jwt: %w", err))
+		return
+	}
+
+	entry := newLoginLog(c, &user.ID, user.Email, loginStatusSuccess)
+	entry.TokenID = &tokenID
+	if err := dal.LoginLog.Create(entry); err != nil {
+		problem.Error(c, fmt.Errorf("cannot create login log: %w", err))
 		return
 	}
 
 	c.JSON(http.StatusOK, successResponse{
 		Status: successStatus,
-		Data:   gin.H{"access_token": accessToken},
+		Data: tokenResp{
+			AccessToken:  accessToken,
+			RefreshToken: refreshToken,
+		},
+	})
+}
+
+// @Summary		Refresh an access token
+// @Description	Exchanges a refresh token for a new access token and a new refresh token.
+// @Description	Every refresh token can be used only once; presenting a used token revokes its whole family.
+// @Tags			Auth
+// @Accept			json
+// @Produce		json
+// @Param			input	body		refreshReq	true	"refresh req"
+// @Success		200		{object}	successResponse{data=tokenResp}
+// @Failure		400		{object}	problem.Details
+// @Failure		401		{object}	problem.Details
+// @Failure		403		{object}	problem.Details
+// @Failure		500		{object}	problem.Details
+// @Router			/auth/refresh [post]
+func RefreshTokenHandler(keys *jwtkeys.KeySet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input refreshReq
+		if !bindJSON(c, &input) {
+			return
+		}
+
+		stored, err := dal.RefreshToken.Where(dal.RefreshToken.TokenHash.Eq(hashToken(input.RefreshToken))).First()
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				problem.Error(c, err)
+				return
+			}
+			problem.Write(c, http.StatusUnauthorized, problem.CodeInvalidToken, "invalid refresh token")
+			return
+		}
+
+		current, err := dal.Session.Where(dal.Session.FamilyID.Eq(stored.FamilyID)).First()
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			problem.Error(c, err)
+			return
+		}
+		if current != nil && current.RevokedAt != nil {
+			problem.Write(c, http.StatusUnauthorized, problem.CodeInvalidToken, "session has been revoked, please log in again")
+			return
+		}
+
+		if stored.RevokedAt != nil {
+			revokeReusedRefreshToken(c, stored.FamilyID)
+			return
+		}
+		if time.Now().After(stored.ExpiresAt) {
+			problem.Write(c, http.StatusUnauthorized, problem.CodeInvalidToken, "refresh token expired")
+			return
+		}
+
+		user, err := dal.User.Where(dal.User.ID.Eq(stored.UserID)).First()
+		if err != nil {
+			problem.Write(c, http.StatusUnauthorized, problem.CodeInvalidToken, "invalid refresh token")
+			return
+		}
+		if user.DisabledAt != nil {
+			problem.Write(c, http.StatusForbidden, problem.CodeUserDisabled, "user is disabled")
+			return
+		}
+
+		var refreshToken string
+		err = dal.Q.Transaction(func(tx *dal.Query) error {
+			// The conditional update makes rotation atomic: when two requests race
+			// with the same token only one of them can mark it as used.
+			info, err := tx.RefreshToken.Where(
+				tx.RefreshToken.ID.Eq(stored.ID),
+				tx.RefreshToken.RevokedAt.IsNull(),
+			).Update(tx.RefreshToken.RevokedAt, time.Now())
+			if err != nil {
+				return err
+			}
+			if info.RowsAffected == 0 {
+				return errRefreshTokenReused
+			}
+
+			token, next, err := createRefreshToken(tx, stored.UserID, stored.FamilyID)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.RefreshToken.Where(tx.RefreshToken.ID.Eq(stored.ID)).Update(tx.RefreshToken.ReplacedBy, next.ID); err != nil {
+				return err
+			}
+
+			// Families from before sessions were tracked get a session on their next refresh.
+			ip, userAgent := clientInfo(c)
+			if current == nil {
+				_, err = session.Create(tx, stored.UserID, stored.FamilyID, ip, userAgent, next.ExpiresAt)
+			} else {
+				err = session.Extend(tx, stored.FamilyID, ip, userAgent, next.ExpiresAt)
+			}
+			if err != nil {
+				return err
+			}
+
+			refreshToken = token
+			return nil
+		})
+		if err != nil {
+			if errors.Is(err, errRefreshTokenReused) {
+				revokeReusedRefreshToken(c, stored.FamilyID)
+				return
+			}
+			problem.Error(c, fmt.Errorf("cannot rotate refresh token: %w", err))
+			return
+		}
+
+		accessToken, _, err := generateJWT(keys, user, stored.FamilyID)
+		if err != nil {
+			problem.Error(c, fmt.Errorf("cannot generate jwt: %w", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, successResponse{
+			Status: successStatus,
+			Data: tokenResp{
+				AccessToken:  accessToken,
+				RefreshToken: refreshToken,
+			},
+		})
+	}
+}
+
+// revokeReusedRefreshToken revokes every token of the family and rejects the request.
+// A refresh token that comes back after it was rotated means it has leaked,
+// so neither the attacker nor the legitimate client may keep using the family.
+func revokeReusedRefreshToken(c *gin.Context, familyID string) {
+	if err := revokeRefreshTokenFamily(familyID); err != nil {
+		problem.Error(c, fmt.Errorf("cannot revoke refresh tokens: %w", err))
+		return
+	}
+
+	problem.Write(c, http.StatusUnauthorized, problem.CodeInvalidToken, "refresh token reuse detected, please log in again")
+}
+
+// revokeRefreshTokenFamily revokes the refresh tokens of a family and ends its session,
+// which also rejects the access tokens issued for it.
+func revokeRefreshTokenFamily(familyID string) error {
+	return dal.Q.Transaction(func(tx *dal.Query) error {
+		if _, err := tx.RefreshToken.Where(
+			tx.RefreshToken.FamilyID.Eq(familyID),
+			tx.RefreshToken.RevokedAt.IsNull(),
+		).Update(tx.RefreshToken.RevokedAt, time.Now()); err != nil {
+			return err
+		}
+		return session.RevokeFamily(tx, familyID)
 	})
 }
 
-type createUserReq struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+// revokeUserRefreshTokens revokes every refresh token the user still holds and ends all
+// their sessions, logging out all their devices.
+func revokeUserRefreshTokens(q *dal.Query, userID int32) error {
+	if _, err := q.RefreshToken.Where(
+		q.RefreshToken.UserID.Eq(userID),
+		q.RefreshToken.RevokedAt.IsNull(),
+	).Update(q.RefreshToken.RevokedAt, time.Now()); err != nil {
+		return err
+	}
+	return session.RevokeUser(q, userID)
 }
 
-// @Summary		Get login data
-// @Description	Get login data
+// createRefreshToken stores a new refresh token of the given family and returns its plain value.
+// Only the SHA-256 digest of the token is persisted.
+func createRefreshToken(q *dal.Query, userID int32, familyID string) (string, *model.RefreshToken, error) {
+	expire, err := strconv.Atoi(os.Getenv("JWT_REFRESH_EXPIRE"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+
+	refreshToken := &model.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(time.Minute * time.Duration(expire)),
+		CreatedAt: time.Now(),
+	}
+	if err := q.RefreshToken.Create(refreshToken); err != nil {
+		return "", nil, err
+	}
+
+	return token, refreshToken, nil
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type logoutReq struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// @Summary		Logs out a user
+// @Description	Revokes the access token used for this request and ends its session. When a refresh token
+// @Description	is given, its whole family is revoked as well so it cannot be used to obtain new access tokens.
 // @Tags			Auth
 // @Accept			json
 // @Produce		json
 // @Security		Bearer
-// @Success		200	{array}		successResponse
-// @Failure		500	{object}	errorResponse
-// @Router			/login-data [get]
-func GetLoginData(c *gin.Context) {
-	resp, err := dal.LoginLog.Find()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse{
-			Status:  errorStatus,
-			Message: fmt.Sprintf("cannot get login data: %v", err),
+// @Param			input	body		logoutReq	false	"logout req"
+// @Success		200		{object}	successResponse
+// @Failure		400		{object}	problem.Details
+// @Failure		401		{object}	problem.Details
+// @Failure		500		{object}	problem.Details
+// @Router			/auth/logout [post]
+func LogoutHandler(revoked revocation.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input logoutReq
+		if c.Request.ContentLength != 0 {
+			if !bindJSON(c, &input) {
+				return
+			}
+		}
+
+		principal, _ := middlewares.GetPrincipal(c)
+		if err := revoked.Revoke(principal.TokenID, principal.ExpiresAt); err != nil {
+			problem.Error(c, fmt.Errorf("cannot revoke token: %w", err))
+			return
+		}
+
+		if principal.SessionID != "" {
+			if err := revokeRefreshTokenFamily(principal.SessionID); err != nil {
+				problem.Error(c, fmt.Errorf("cannot end session: %w", err))
+				return
+			}
+		}
+
+		if input.RefreshToken != "" {
+			stored, err := dal.RefreshToken.Where(dal.RefreshToken.TokenHash.Eq(hashToken(input.RefreshToken))).First()
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				problem.Error(c, err)
+				return
+			}
+			if stored != nil {
+				if err := revokeRefreshTokenFamily(stored.FamilyID); err != nil {
+					problem.Error(c, fmt.Errorf("cannot revoke refresh tokens: %w", err))
+					return
+				}
+			}
+		}
+
+		c.JSON(http.StatusOK, successResponse{
+			Status: successStatus,
 		})
-		return
 	}
-	c.JSON(http.StatusOK, successResponse{
-		Status: successStatus,
-		Data:   resp,
-	})
 }
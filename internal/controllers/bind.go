@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"dbo-test/internal/problem"
+	"dbo-test/internal/validation"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+)
+
+// normalizer is implemented by request bodies that clean up their fields, such as trimming
+// spaces, before they are validated.
+type normalizer interface {
+	normalize()
+}
+
+// bindJSON decodes the JSON request body into obj and checks it against its binding tags,
+// answering with 400 and the problems per field when it is invalid.
+func bindJSON(c *gin.Context, obj any) bool {
+	if err := json.NewDecoder(c.Request.Body).Decode(obj); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.Is(err, io.EOF):
+			problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, "request body is required")
+		case errors.As(err, &typeErr) && typeErr.Field != "":
+			problem.Invalid(c, validation.Errors{{
+				Field:   typeErr.Field,
+				Code:    validation.CodeType,
+				Message: fmt.Sprintf("%s must be %s", typeErr.Field, jsonTypeName(typeErr.Type)),
+			}})
+		default:
+			problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, err.Error())
+		}
+		return false
+	}
+	if n, ok := obj.(normalizer); ok {
+		n.normalize()
+	}
+	return validateRequest(c, obj)
+}
+
+// validateRequest checks a request body against its binding tags, answering with 400 and
+// the problems per field when it is invalid.
+func validateRequest(c *gin.Context, obj any) bool {
+	return respondValidation(c, validation.Struct(obj))
+}
+
+// validatePatch is validateRequest for the fields a patch changes. Values the patch keeps
+// are not checked again, so records saved under older or more lenient rules can be patched.
+func validatePatch(c *gin.Context, obj any, changes map[string]json.RawMessage) bool {
+	return respondValidation(c, validation.StructPartial(obj, patchKeys(changes)...))
+}
+
+func respondValidation(c *gin.Context, err error) bool {
+	if err == nil {
+		return true
+	}
+	var errs validation.Errors
+	if errors.As(err, &errs) {
+		problem.Invalid(c, errs)
+		return false
+	}
+	problem.Error(c, err)
+	return false
+}
+
+// jsonTypeName names the kind of JSON value a Go type is decoded from.
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "a list"
+	case reflect.Pointer:
+		return jsonTypeName(t.Elem())
+	default:
+		return "an object"
+	}
+}
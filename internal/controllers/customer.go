@@ -2,15 +2,23 @@ package controllers
 
 import (
 	"context"
+	"dbo-test/internal/attributes"
 	"dbo-test/internal/dal"
 	"dbo-test/internal/model"
+	"dbo-test/internal/problem"
+	"dbo-test/internal/validation"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gen"
+	"gorm.io/gen/field"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // GetSingleCustomer godoc
@@ -22,31 +30,28 @@ import (
 //	@Produce		json
 //	@Param			id	path	int	true	"Customer ID"
 //	@Security		Bearer
+//	@Security		ApiKey
 //	@Success		200	{object}	model.Customer
-//	@Failure		400	{object}	errorResponse
-//	@Failure		401	{object}	errorResponse
-//	@Failure		404	{object}	errorResponse
-//	@Failure		500	{object}	errorResponse
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
 //	@Router			/customer/{id} [get]
 func GetSingleCustomer(c *gin.Context) {
 	customerID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: "invalid id",
-		})
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid id")
 		return
 	}
 
 	customer, err := dal.Customer.Where(dal.Customer.ID.Eq(int32(customerID))).First()
 	if err != nil {
 		if !errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusInternalServerError, errorResponse{
-				Status:  errorStatus,
-				Message: err.Error(),
-			})
+			problem.Error(c, err)
 			return
 		}
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "customer not found")
+		return
 	}
 
 	c.JSON(http.StatusOK, customer)
@@ -59,39 +64,38 @@ func GetSingleCustomer(c *gin.Context) {
 //	@Tags			customers
 //	@Accept			json
 //	@Produce		json
-//	@Param			page		query	int		false	"Page number"					default(1)
-//	@Param			pagesize	query	int		false	"Number of items per page"		default(10)
-//	@Param			order		query	string	false	"Order by field (asc or desc)"	default("asc")
-//	@Param			name		query	string	false	"Filter by name"
-//	@Param			email		query	string	false	"Filter by email"
-//	@Param			phone		query	string	false	"Filter by phone"
+//	@Param			page		query	int			false	"Page number"					default(1)
+//	@Param			pagesize	query	int			false	"Number of items per page"		default(10)
+//	@Param			order		query	string		false	"Order by field (asc or desc)"	default("asc")
+//	@Param			name		query	string		false	"Filter by name"
+//	@Param			email		query	string		false	"Filter by email"
+//	@Param			phone		query	string		false	"Filter by phone"
+//	@Param			tag			query	[]string	false	"Filter by tag, repeated or comma separated"	collectionFormat(multi)
+//	@Param			tag_match	query	string		false	"Whether customers need any or all of the tags"	Enums(any, all)	default(any)
+//	@Param			attr.key	query	string		false	"Filter by the value of the custom attribute key, e.g. attr.region=west"
 //	@Security		Bearer
+//	@Security		ApiKey
 //	@Success		200	{object}	successResponse{data=PagedResults{data=[]model.Customer}}
-//	@Failure		400	{object}	errorResponse
-//	@Failure		401	{object}	errorResponse
-//	@Failure		500	{object}	errorResponse
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
 //	@Router			/customer [get]
 func GetMultipleCustomer(c *gin.Context) {
 	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: "invalid page",
-		})
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid page")
 		return
 	}
 	pagesize, err := strconv.Atoi(c.DefaultQuery("pagesize", "10"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: "invalid pagesize",
-		})
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid pagesize")
 		return
 	}
 	order := c.DefaultQuery("order", "asc")
-	name := c.DefaultQuery("name", "")
-	email := c.DefaultQuery("email", "")
-	phone := c.DefaultQuery("phone", "")
+	filters, ok := bindCustomerFilters(c)
+	if !ok {
+		return
+	}
 
 	orderParts := strings.Split(order, " ")
 	descbBool := false
@@ -102,12 +106,9 @@ func GetMultipleCustomer(c *gin.Context) {
 	resp, totalRecords, err := queryMultipleCustomer(page, pagesize, orderBy{
 		Field: orderParts[0],
 		Desc:  descbBool,
-	}, name, email, phone)
+	}, filters)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse{
-			Status:  errorStatus,
-			Message: err.Error(),
-		})
+		problem.Error(c, err)
 		return
 	}
 
@@ -124,21 +125,11 @@ func GetMultipleCustomer(c *gin.Context) {
 func queryMultipleCustomer(
 	page, pagesize int,
 	order orderBy,
-	name, email, phone string,
+	filters customerFilters,
 ) ([]*model.Customer, int64, error) {
 
 	customerQuery := dal.Customer
-	resultOrm := customerQuery.WithContext(context.Background())
-
-	if name != "" {
-		resultOrm = resultOrm.Where(dal.Customer.Name.Like("%" + name + "%"))
-	}
-	if email != "" {
-		resultOrm = resultOrm.Where(dal.Customer.Email.Like("%" + email + "%"))
-	}
-	if phone != "" {
-		resultOrm = resultOrm.Where(dal.Customer.Phone.Like("%" + phone + "%"))
-	}
+	resultOrm := filterCustomers(customerQuery.WithContext(context.Background()), filters)
 
 	totalRecords, err := resultOrm.Count()
 	if err != nil {
@@ -169,45 +160,197 @@ func queryMultipleCustomer(
 	return resp, totalRecords, nil
 }
 
+// customerFilters are the customer filters shared by the customer listing and export.
+type customerFilters struct {
+	Name, Email, Phone string
+	Tags               []string
+	// MatchAllTags requires every tag instead of any of them.
+	MatchAllTags bool
+	Attributes   []attributeFilter
+}
+
+type attributeFilter struct {
+	Definition attributes.Definition
+	Value      any
+}
+
+// bindCustomerFilters reads the customer filters from the query, answering with 400 when one is invalid.
+// Attribute filters are given as attr.<key>=<value>.
+func bindCustomerFilters(c *gin.Context) (customerFilters, bool) {
+	filters := customerFilters{
+		Name:  c.Query("name"),
+		Email: c.Query("email"),
+		Phone: c.Query("phone"),
+	}
+
+	var tags []string
+	for _, value := range c.QueryArray("tag") {
+		tags = append(tags, strings.Split(value, ",")...)
+	}
+	// Tags are counted when all must match, so each may appear only once.
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+		return filters, false
+	}
+	filters.Tags = tags
+	switch c.DefaultQuery("tag_match", "any") {
+	case "any":
+	case "all":
+		filters.MatchAllTags = true
+	default:
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "tag_match must be any or all")
+		return filters, false
+	}
+
+	var defs map[string]attributes.Definition
+	for param, values := range c.Request.URL.Query() {
+		key, ok := strings.CutPrefix(param, "attr.")
+		if !ok {
+			continue
+		}
+		if defs == nil {
+			var err error
+			if defs, err = loadAttributeDefinitions(dal.Q); err != nil {
+				problem.Error(c, err)
+				return filters, false
+			}
+		}
+		def, ok := defs[key]
+		if !ok {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, fmt.Sprintf("%s is not a defined attribute", key))
+			return filters, false
+		}
+		value, err := def.ParseFilter(values[0])
+		if err != nil {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, err.Error())
+			return filters, false
+		}
+		filters.Attributes = append(filters.Attributes, attributeFilter{Definition: def, Value: value})
+	}
+	return filters, true
+}
+
+// filterCustomers narrows a customer query to the filters.
+func filterCustomers(resultOrm dal.ICustomerDo, filters customerFilters) dal.ICustomerDo {
+	if filters.Name != "" {
+		resultOrm = resultOrm.Where(dal.Customer.Name.Like("%" + filters.Name + "%"))
+	}
+	if filters.Email != "" {
+		resultOrm = resultOrm.Where(dal.Customer.Email.Like("%" + filters.Email + "%"))
+	}
+	if filters.Phone != "" {
+		resultOrm = resultOrm.Where(dal.Customer.Phone.Like("%" + filters.Phone + "%"))
+	}
+
+	if len(filters.Tags) > 0 {
+		tagIDs := dal.Tag.Select(dal.Tag.ID).Where(dal.Tag.Name.In(filters.Tags...))
+		tagged := dal.CustomerTag.Select(dal.CustomerTag.CustomerID).
+			Where(dal.CustomerTag.Columns(dal.CustomerTag.TagID).In(tagIDs))
+		if filters.MatchAllTags {
+			tagged = tagged.Group(dal.CustomerTag.CustomerID).
+				Having(dal.CustomerTag.TagID.Count().Eq(len(filters.Tags)))
+		}
+		resultOrm = resultOrm.Where(dal.Customer.Columns(dal.Customer.ID).In(tagged))
+	}
+
+	for _, filter := range filters.Attributes {
+		path := attributes.Path(filter.Definition.Key)
+		var expr clause.Expr
+		switch filter.Definition.Type {
+		case attributes.TypeNumber:
+			expr = clause.Expr{SQL: "JSON_EXTRACT(`customers`.`attributes`, ?) = ?", Vars: []any{path, filter.Value}}
+		case attributes.TypeBoolean:
+			expr = clause.Expr{SQL: "JSON_EXTRACT(`customers`.`attributes`, ?) = CAST(? AS JSON)", Vars: []any{path, strconv.FormatBool(filter.Value.(bool))}}
+		default:
+			expr = clause.Expr{SQL: "JSON_UNQUOTE(JSON_EXTRACT(`customers`.`attributes`, ?)) = ?", Vars: []any{path, filter.Value}}
+		}
+		resultOrm = resultOrm.Where(gen.Cond(expr)...)
+	}
+	return resultOrm
+}
+
+// bindExistingCustomer reads the customer ID of a route and checks that the customer exists.
+func bindExistingCustomer(c *gin.Context) (int32, bool) {
+	customerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid id")
+		return 0, false
+	}
+
+	count, err := dal.Customer.Where(dal.Customer.ID.Eq(int32(customerID))).Count()
+	if err != nil {
+		problem.Error(c, err)
+		return 0, false
+	}
+	if count == 0 {
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "customer not found")
+		return 0, false
+	}
+	return int32(customerID), true
+}
+
 type createCustomerReq struct {
-	Name  string `json:"name"`
-	Email string `json:"email"`
-	Phone string `json:"phone"`
+	Name  string `json:"name" binding:"required,max=255"`
+	Email string `json:"email" binding:"required,email,max=255"`
+	// Phone is an E.164 number; spaces, dots, dashes and brackets are removed first.
+	Phone string `json:"phone" binding:"omitempty,e164" example:"+6281234567890"`
+	// Attributes are the custom attributes, checked against their definitions.
+	Attributes map[string]any `json:"attributes"`
+}
+
+func (r *createCustomerReq) normalize() {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Email = strings.TrimSpace(r.Email)
+	r.Phone = normalizePhone(r.Phone)
+}
+
+// normalizePhone removes the separators people write phone numbers with.
+func normalizePhone(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(" .-()", r) {
+			return -1
+		}
+		return r
+	}, phone)
 }
 
 // CreateCustomer godoc
 //
 //	@Summary		Create a new customer
-//	@Description	Create a new customer with the provided details
+//	@Description	Create a new customer with the provided details. Custom attributes must be defined
+//	@Description	at /customer/attributes and match the type of their definition.
 //	@Tags			customers
 //	@Accept			json
 //	@Produce		json
 //	@Param			customer	body	createCustomerReq	true	"Customer details"
 //	@Security		Bearer
+//	@Security		ApiKey
 //	@Success		200	{object}	successResponse
-//	@Failure		400	{object}	errorResponse
-//	@Failure		401	{object}	errorResponse
-//	@Failure		500	{object}	errorResponse
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
 //	@Router			/customer [post]
 func CreateCustomer(c *gin.Context) {
 	var input createCustomerReq
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: err.Error(),
-		})
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	if !validateCustomerAttributes(c, input.Attributes) {
 		return
 	}
 
+	actorID := currentActorID(c)
 	if err := dal.Customer.Create(&model.Customer{
-		Name:  input.Name,
-		Email: input.Email,
-		Phone: input.Phone,
+		Name:       input.Name,
+		Email:      input.Email,
+		Phone:      input.Phone,
+		CreatedBy:  actorID,
+		UpdatedBy:  actorID,
+		Attributes: input.Attributes,
 	}); err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse{
-			Status:  errorStatus,
-			Message: err.Error(),
-		})
+		problem.Error(c, err)
 		return
 	}
 
@@ -217,55 +360,62 @@ func CreateCustomer(c *gin.Context) {
 }
 
 type updateCustomerReq struct {
-	Name  string `json:"name"`
-	Email string `json:"email"`
-	Phone string `json:"phone"`
+	Name  string `json:"name" binding:"required,max=255"`
+	Email string `json:"email" binding:"required,email,max=255"`
+	// Phone is an E.164 number; spaces, dots, dashes and brackets are removed first.
+	Phone string `json:"phone" binding:"omitempty,e164" example:"+6281234567890"`
+	// Attributes are the custom attributes, checked against their definitions.
+	Attributes map[string]any `json:"attributes"`
+}
+
+func (r *updateCustomerReq) normalize() {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Email = strings.TrimSpace(r.Email)
+	r.Phone = normalizePhone(r.Phone)
 }
 
 // UpdateCustomer godoc
 //
 //	@Summary		Update an existing customer
-//	@Description	Update the details of an existing customer
+//	@Description	Update the details of an existing customer. When attributes are given they replace
+//	@Description	all custom attributes of the customer.
 //	@Tags			customers
 //	@Accept			json
 //	@Produce		json
 //	@Param			id			path	int					true	"Customer ID"
 //	@Param			customer	body	updateCustomerReq	true	"Updated customer details"
 //	@Security		Bearer
+//	@Security		ApiKey
 //	@Success		200	{object}	successResponse
-//	@Failure		400	{object}	errorResponse
-//	@Failure		401	{object}	errorResponse
-//	@Failure		500	{object}	errorResponse
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
 //	@Router			/customer/{id} [put]
 func UpdateCustomer(c *gin.Context) {
 	customerID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: "invalid id",
-		})
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid id")
 		return
 	}
 
 	var input updateCustomerReq
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: err.Error(),
-		})
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	if !validateCustomerAttributes(c, input.Attributes) {
 		return
 	}
 
 	_, err = dal.Customer.Where(dal.Customer.ID.Eq(int32(customerID))).Updates(&model.Customer{
-		Name:  input.Name,
-		Email: input.Email,
-		Phone: input.Phone,
+		Name:       input.Name,
+		Email:      input.Email,
+		Phone:      input.Phone,
+		UpdatedBy:  currentActorID(c),
+		Attributes: input.Attributes,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse{
-			Status:  errorStatus,
-			Message: err.Error(),
-		})
+		problem.Error(c, err)
 		return
 	}
 
@@ -274,45 +424,258 @@ func UpdateCustomer(c *gin.Context) {
 	})
 }
 
+// PatchCustomer godoc
+//
+//	@Summary		Partially update a customer
+//	@Description	Apply a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) to the name, email,
+//	@Description	phone and attributes of a customer. Only the fields the patch changes are written,
+//	@Description	so a phone can be cleared with null, and the changed fields must pass the same checks
+//	@Description	as in a new customer. Plain application/json is taken as a merge patch.
+//	@Tags			customers
+//	@Accept			application/merge-patch+json
+//	@Accept			application/json-patch+json
+//	@Produce		json
+//	@Param			id		path	int		true	"Customer ID"
+//	@Param			patch	body	object	true	"Merge patch object or JSON Patch operations"
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse{data=model.Customer}
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		415	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/{id} [patch]
+func PatchCustomer(c *gin.Context) {
+	customerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid id")
+		return
+	}
+
+	customer, err := dal.Customer.Where(dal.Customer.ID.Eq(int32(customerID))).First()
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			problem.Error(c, err)
+			return
+		}
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "customer not found")
+		return
+	}
+
+	changes, ok := bindPatch(c, map[string]any{
+		"name":       customer.Name,
+		"email":      customer.Email,
+		"phone":      customer.Phone,
+		"attributes": customer.Attributes,
+	})
+	if !ok {
+		return
+	}
+
+	var columns []field.Expr
+	var errs validation.Errors
+	for _, key := range patchKeys(changes) {
+		raw := changes[key]
+		switch key {
+		case "name":
+			decodePatchMember(&errs, key, raw, &customer.Name, false)
+			columns = append(columns, dal.Customer.Name)
+		case "email":
+			decodePatchMember(&errs, key, raw, &customer.Email, false)
+			columns = append(columns, dal.Customer.Email)
+		case "phone":
+			// The phone column is not nullable, so null clears it.
+			var phone *string
+			decodePatchMember(&errs, key, raw, &phone, true)
+			customer.Phone = ""
+			if phone != nil {
+				customer.Phone = *phone
+			}
+			columns = append(columns, dal.Customer.Phone)
+		case "attributes":
+			customer.Attributes = nil
+			decodePatchMember(&errs, key, raw, &customer.Attributes, true)
+			columns = append(columns, dal.Customer.Attributes)
+		}
+	}
+	if len(errs) > 0 {
+		problem.Invalid(c, errs)
+		return
+	}
+
+	// Changed members must be as valid as in a new customer. The others are left as they are,
+	// so customers saved under older rules can be patched.
+	patched := createCustomerReq{Name: customer.Name, Email: customer.Email, Phone: customer.Phone}
+	patched.normalize()
+	if !validatePatch(c, &patched, changes) {
+		return
+	}
+	for _, key := range patchKeys(changes) {
+		switch key {
+		case "name":
+			customer.Name = patched.Name
+		case "email":
+			customer.Email = patched.Email
+		case "phone":
+			customer.Phone = patched.Phone
+		}
+	}
+	if _, ok := changes["attributes"]; ok && !validateCustomerAttributes(c, customer.Attributes) {
+		return
+	}
+
+	if len(columns) > 0 {
+		customer.UpdatedBy = currentActorID(c)
+		columns = append(columns, dal.Customer.UpdatedBy)
+		if _, err := dal.Customer.Where(dal.Customer.ID.Eq(customer.ID)).Select(columns...).Updates(customer); err != nil {
+			problem.Error(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   customer,
+	})
+}
+
 // DeleteCustomer godoc
 //
 //	@Summary		Delete a customer
-//	@Description	Delete a customer by ID
+//	@Description	Move a customer to the trash. It can be restored until it is purged
+//	@Description	after the retention period; its orders are kept.
 //	@Tags			customers
 //	@Accept			json
 //	@Produce		json
 //	@Param			id	path	int	true	"Customer ID"
 //	@Security		Bearer
+//	@Security		ApiKey
 //	@Success		200	{object}	successResponse
-//	@Failure		400	{object}	errorResponse
-//	@Failure		401	{object}	errorResponse
-//	@Failure		500	{object}	errorResponse
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
 //	@Router			/customer/{id} [delete]
 func DeleteCustomer(c *gin.Context) {
 	customerID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: "invalid id",
-		})
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid id")
+		return
+	}
+
+	// Only deleted_at and updated_by are written; rows already in the trash are not matched.
+	info, err := dal.Customer.Where(dal.Customer.ID.Eq(int32(customerID))).Updates(&model.Customer{
+		UpdatedBy: currentActorID(c),
+		DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if info.RowsAffected == 0 {
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "customer not found")
+		return
+	}
+	c.JSON(http.StatusOK, successResponse{
+		Status: "success",
+	})
+}
+
+// RestoreCustomer godoc
+//
+//	@Summary		Restore a customer
+//	@Description	Take a deleted customer out of the trash
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path	int	true	"Customer ID"
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/{id}/restore [post]
+func RestoreCustomer(c *gin.Context) {
+	customerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid id")
 		return
 	}
 
-	info, err := dal.Customer.Where(dal.Customer.ID.Eq(int32(customerID))).Delete()
+	info, err := dal.Customer.Unscoped().Where(
+		dal.Customer.ID.Eq(int32(customerID)),
+		dal.Customer.DeletedAt.IsNotNull(),
+	).UpdateSimple(
+		dal.Customer.DeletedAt.Null(),
+		updatedByExpr(dal.Customer.UpdatedBy, currentActorID(c)),
+	)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
 	if info.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, errorResponse{
-			Status:  errorStatus,
-			Message: "order not found",
-		})
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "customer not found in trash")
+		return
+	}
+	c.JSON(http.StatusOK, successResponse{
+		Status: "success",
+	})
+}
+
+// GetDeletedCustomers godoc
+//
+//	@Summary		Get deleted customers
+//	@Description	Get the customers in the trash, most recently deleted first
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			page		query	int	false	"Page number"				default(1)
+//	@Param			pagesize	query	int	false	"Number of items per page"	default(10)
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse{data=PagedResults{data=[]model.Customer}}
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/trash [get]
+func GetDeletedCustomers(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid page")
+		return
+	}
+	pagesize, err := strconv.Atoi(c.DefaultQuery("pagesize", "10"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid pagesize")
+		return
+	}
+
+	resultOrm := dal.Customer.Unscoped().Where(dal.Customer.DeletedAt.IsNotNull())
+	totalRecords, err := resultOrm.Count()
+	if err != nil {
+		problem.Error(c, err)
+		return
 	}
+
+	if page > 0 {
+		resultOrm = resultOrm.Offset((page - 1) * pagesize)
+	}
+	resp, err := resultOrm.Order(dal.Customer.DeletedAt.Desc()).Limit(pagesize).Find()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse{
-			Status:  errorStatus,
-			Message: err.Error(),
-		})
+		problem.Error(c, err)
 		return
 	}
+
 	c.JSON(http.StatusOK, successResponse{
 		Status: "success",
+		Data: PagedResults{
+			Page:         int64(page),
+			PageSize:     int64(pagesize),
+			Data:         resp,
+			TotalRecords: int(totalRecords),
+		},
 	})
 }
@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"dbo-test/internal/attributes"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/model"
+	"dbo-test/internal/problem"
+	"dbo-test/internal/validation"
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type customerAttributeReq struct {
+	Type        string   `json:"type" binding:"required,oneof=string number boolean enum"`
+	Options     []string `json:"options" binding:"unique"`
+	Description string   `json:"description" binding:"max=255"`
+}
+
+// GetCustomerAttributes godoc
+//
+//	@Summary		Get custom attribute definitions
+//	@Description	Get the definitions of the custom attributes customers can have
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse{data=[]model.CustomerAttribute}
+//	@Failure		401	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/attributes [get]
+func GetCustomerAttributes(c *gin.Context) {
+	defs, err := dal.CustomerAttribute.WithContext(c.Request.Context()).Order(dal.CustomerAttribute.Key).Find()
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   defs,
+	})
+}
+
+// PutCustomerAttribute godoc
+//
+//	@Summary		Define a custom attribute
+//	@Description	Create or replace the definition of a custom attribute. Values customers already have
+//	@Description	are not checked again, but every later write is checked against the new definition.
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			key			path	string					true	"Attribute key"
+//	@Param			attribute	body	customerAttributeReq	true	"Attribute definition"
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse{data=model.CustomerAttribute}
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/attributes/{key} [put]
+func PutCustomerAttribute(c *gin.Context) {
+	var input customerAttributeReq
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	def := attributes.Definition{Key: c.Param("key"), Type: input.Type, Options: input.Options}
+	if err := def.Check(); err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, err.Error())
+		return
+	}
+
+	actorID := currentActorID(c)
+	attribute := &model.CustomerAttribute{
+		Key:         def.Key,
+		Type:        def.Type,
+		Options:     def.Options,
+		Description: input.Description,
+		CreatedBy:   actorID,
+		UpdatedBy:   actorID,
+	}
+	err := dal.CustomerAttribute.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"type", "options", "description", "updated_by"}),
+	}).Create(attribute)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   attribute,
+	})
+}
+
+// DeleteCustomerAttribute godoc
+//
+//	@Summary		Delete a custom attribute
+//	@Description	Delete the definition of a custom attribute and remove its value from every customer
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			key	path	string	true	"Attribute key"
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/attributes/{key} [delete]
+func DeleteCustomerAttribute(c *gin.Context) {
+	key := c.Param("key")
+
+	var deleted int64
+	err := dal.Q.Transaction(func(tx *dal.Query) error {
+		info, err := tx.CustomerAttribute.Where(tx.CustomerAttribute.Key.Eq(key)).Delete()
+		if err != nil {
+			return err
+		}
+		deleted = info.RowsAffected
+		if deleted == 0 {
+			return nil
+		}
+
+		path := attributes.Path(key)
+		_, err = tx.Customer.Unscoped().
+			Where(tx.Customer.Attributes.IsNotNull()).
+			Update(tx.Customer.Attributes, gorm.Expr("JSON_REMOVE(`attributes`, ?)", path))
+		return err
+	})
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if deleted == 0 {
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "attribute not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   nil,
+	})
+}
+
+// loadAttributeDefinitions returns the custom attribute definitions by key.
+func loadAttributeDefinitions(q *dal.Query) (map[string]attributes.Definition, error) {
+	rows, err := q.CustomerAttribute.Find()
+	if err != nil {
+		return nil, err
+	}
+	defs := make(map[string]attributes.Definition, len(rows))
+	for _, row := range rows {
+		defs[row.Key] = attributes.Definition{Key: row.Key, Type: row.Type, Options: row.Options}
+	}
+	return defs, nil
+}
+
+// validateCustomerAttributes checks custom attribute values against their definitions,
+// answering with 400 and a problem per attribute when some do not fit.
+func validateCustomerAttributes(c *gin.Context, values map[string]any) bool {
+	if values == nil {
+		return true
+	}
+	defs, err := loadAttributeDefinitions(dal.Q)
+	if err != nil {
+		problem.Error(c, err)
+		return false
+	}
+	err = attributes.Validate(defs, values)
+	var invalid attributes.Errors
+	if !errors.As(err, &invalid) {
+		return true
+	}
+
+	keys := make([]string, 0, len(invalid))
+	for key := range invalid {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	errs := make(validation.Errors, len(keys))
+	for i, key := range keys {
+		code := validation.CodeInvalid
+		if errors.Is(invalid[key], attributes.ErrUndefined) {
+			code = validation.CodeUnknown
+		}
+		errs[i] = validation.FieldError{
+			Field:   "attributes." + key,
+			Code:    code,
+			Message: invalid[key].Error(),
+		}
+	}
+	problem.Invalid(c, errs)
+	return false
+}
@@ -0,0 +1,264 @@
+package controllers
+
+import (
+	"dbo-test/internal/dal"
+	"dbo-test/internal/importer"
+	"dbo-test/internal/model"
+	"dbo-test/internal/problem"
+	"dbo-test/internal/validation"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxImportSize is the largest upload accepted by ImportCustomers.
+	maxImportSize = 10 << 20
+
+	defaultImportBatchSize = 500
+	maxImportBatchSize     = 5000
+)
+
+type importReport struct {
+	DryRun bool `json:"dry_run"`
+	Atomic bool `json:"atomic"`
+	Total  int  `json:"total"`
+	Valid  int  `json:"valid"`
+	// Inserted is the number of customers created; in a dry run it is always 0.
+	Inserted int                 `json:"inserted"`
+	Errors   []importer.RowError `json:"errors"`
+}
+
+// ImportCustomers godoc
+//
+//	@Summary		Import customers
+//	@Description	Create customers from a CSV file with a name, email and phone header, or from NDJSON
+//	@Description	with one customer object per line. Send the file as the "file" field of a multipart form,
+//	@Description	or as the request body with a text/csv or application/x-ndjson content type.
+//	@Description	Every row is normalized and validated like the body of POST /customer, and errors are
+//	@Description	reported per line. With atomic=true (the default)
+//	@Description	nothing is inserted unless every row is valid and all batches succeed; with atomic=false
+//	@Description	valid rows are inserted and every batch is committed on its own.
+//	@Tags			customers
+//	@Accept			mpfd
+//	@Accept			text/csv
+//	@Accept			application/x-ndjson
+//	@Produce		json
+//	@Param			file		formData	file	false	"CSV or NDJSON file"
+//	@Param			format		query		string	false	"csv or ndjson, detected from the file name or content type when empty"
+//	@Param			dry_run		query		bool	false	"Only validate the rows"						default(false)
+//	@Param			atomic		query		bool	false	"Import all rows in one transaction"			default(true)
+//	@Param			batch_size	query		int		false	"Number of rows inserted per statement"		default(500)
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse{data=importReport}
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		413	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/import [post]
+func ImportCustomers(c *gin.Context) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid dry_run")
+		return
+	}
+	atomic, err := strconv.ParseBool(c.DefaultQuery("atomic", "true"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid atomic")
+		return
+	}
+	batchSize, err := strconv.Atoi(c.DefaultQuery("batch_size", strconv.Itoa(defaultImportBatchSize)))
+	if err != nil || batchSize <= 0 || batchSize > maxImportBatchSize {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, fmt.Sprintf("batch_size must be between 1 and %d", maxImportBatchSize))
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	upload, format, err := openImportUpload(c)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			problem.Write(c, http.StatusRequestEntityTooLarge, problem.CodePayloadTooLarge, fmt.Sprintf("upload is larger than %d bytes", maxImportSize))
+			return
+		}
+		problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, err.Error())
+		return
+	}
+	defer upload.Close()
+
+	rows, rowErrors, err := importer.Read(upload, format)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			problem.Write(c, http.StatusRequestEntityTooLarge, problem.CodePayloadTooLarge, fmt.Sprintf("upload is larger than %d bytes", maxImportSize))
+			return
+		}
+		problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, fmt.Sprintf("cannot read upload: %v", err))
+		return
+	}
+
+	actorID := currentActorID(c)
+	report := importReport{
+		DryRun: dryRun,
+		Atomic: atomic,
+		Total:  len(rows) + len(rowErrors),
+		Errors: rowErrors,
+	}
+	var valid []importer.Row
+	for _, row := range rows {
+		row, errs, err := validateImportRow(row)
+		if err != nil {
+			problem.Error(c, err)
+			return
+		}
+		if len(errs) > 0 {
+			report.Errors = append(report.Errors, importer.RowError{Line: row.Line, Errors: errs})
+			continue
+		}
+		valid = append(valid, row)
+	}
+	report.Valid = len(valid)
+
+	if !dryRun && len(valid) > 0 && (!atomic || len(report.Errors) == 0) {
+		inserted, batchErrors, err := insertImportedCustomers(c, valid, actorID, atomic, batchSize)
+		if err != nil {
+			problem.Error(c, fmt.Errorf("cannot import customers: %w", err))
+			return
+		}
+		report.Inserted = inserted
+		report.Errors = append(report.Errors, batchErrors...)
+	}
+
+	sort.SliceStable(report.Errors, func(i, j int) bool { return report.Errors[i].Line < report.Errors[j].Line })
+	if report.Errors == nil {
+		report.Errors = []importer.RowError{}
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   report,
+	})
+}
+
+// validateImportRow normalizes the row and checks it against the rules of CreateCustomer.
+// It returns the normalized row and the messages of its problems.
+func validateImportRow(row importer.Row) (importer.Row, []string, error) {
+	input := createCustomerReq{Name: row.Name, Email: row.Email, Phone: row.Phone}
+	input.normalize()
+	row.Name, row.Email, row.Phone = input.Name, input.Email, input.Phone
+
+	err := validation.Struct(&input)
+	if err == nil {
+		return row, nil, nil
+	}
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		return row, nil, err
+	}
+	messages := make([]string, len(errs))
+	for i, fe := range errs {
+		messages[i] = fe.Message
+	}
+	return row, messages, nil
+}
+
+// insertImportedCustomers creates the customers with CreateInBatches. Atomic imports run in one
+// transaction and fail as a whole. Otherwise every batch is committed on its own and the rows
+// of a failed batch are reported instead of failing the import; the cause is only logged.
+func insertImportedCustomers(c *gin.Context, rows []importer.Row, actorID *int32, atomic bool, batchSize int) (int, []importer.RowError, error) {
+	customers := make([]*model.Customer, 0, len(rows))
+	for _, row := range rows {
+		customers = append(customers, &model.Customer{
+			Name:      row.Name,
+			Email:     row.Email,
+			Phone:     row.Phone,
+			CreatedBy: actorID,
+			UpdatedBy: actorID,
+		})
+	}
+
+	if atomic {
+		err := dal.Q.Transaction(func(tx *dal.Query) error {
+			return tx.Customer.CreateInBatches(customers, batchSize)
+		})
+		if err != nil {
+			return 0, nil, err
+		}
+		return len(customers), nil, nil
+	}
+
+	inserted := 0
+	var batchErrors []importer.RowError
+	for start := 0; start < len(customers); start += batchSize {
+		end := min(start+batchSize, len(customers))
+		if err := dal.Customer.CreateInBatches(customers[start:end], batchSize); err != nil {
+			problem.Log(c, fmt.Errorf("cannot import rows %d to %d: %w", rows[start].Line, rows[end-1].Line, err))
+			for _, row := range rows[start:end] {
+				batchErrors = append(batchErrors, importer.RowError{
+					Line:   row.Line,
+					Errors: []string{"could not be saved"},
+				})
+			}
+			continue
+		}
+		inserted += end - start
+	}
+	return inserted, batchErrors, nil
+}
+
+// openImportUpload returns the uploaded file and its format. The file is either the "file" field
+// of a multipart form or the request body itself.
+func openImportUpload(c *gin.Context) (io.ReadCloser, string, error) {
+	format := strings.ToLower(c.Query("format"))
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+
+	if mediaType == "multipart/form-data" {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, "", fmt.Errorf("file is required: %w", err)
+		}
+		if format == "" {
+			format = importFormat(strings.TrimPrefix(filepath.Ext(header.Filename), "."))
+		}
+		if format == "" {
+			fileType, _, _ := mime.ParseMediaType(header.Header.Get("Content-Type"))
+			format = importFormat(fileType)
+		}
+		if format == "" {
+			return nil, "", errors.New("cannot tell the format of the file, set format to csv or ndjson")
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		return file, format, nil
+	}
+
+	if format == "" {
+		format = importFormat(mediaType)
+	}
+	if format == "" {
+		return nil, "", errors.New("send a multipart file, or a text/csv or application/x-ndjson body")
+	}
+	return c.Request.Body, format, nil
+}
+
+// importFormat maps a file extension or media type to an import format.
+func importFormat(kind string) string {
+	switch strings.ToLower(kind) {
+	case "csv", "text/csv", "application/csv":
+		return importer.FormatCSV
+	case "ndjson", "jsonl", "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return importer.FormatNDJSON
+	}
+	return ""
+}
@@ -0,0 +1,449 @@
+package controllers
+
+import (
+	"dbo-test/internal/dal"
+	"dbo-test/internal/dedupe"
+	"dbo-test/internal/model"
+	"dbo-test/internal/problem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gen"
+	"gorm.io/gorm"
+)
+
+const defaultNameThreshold = 0.85
+
+var (
+	errMergeSameCustomer  = errors.New("a customer cannot be merged into itself")
+	errMergeNotFound      = errors.New("customer not found")
+	errMergeAlreadyUndone = errors.New("merge has already been undone")
+	errMergeNotLatest     = errors.New("a later merge of these customers has to be undone first")
+	errMergePurged        = errors.New("the merged customer has been purged and cannot be restored")
+)
+
+type mergeCustomerReq struct {
+	DuplicateID int32 `json:"duplicate_id" binding:"required"`
+}
+
+type customerMergeResp struct {
+	ID          int32      `json:"id"`
+	SurvivorID  int32      `json:"survivor_id"`
+	DuplicateID int32      `json:"duplicate_id"`
+	OrderIDs    []int32    `json:"order_ids"`
+	MergedBy    *int32     `json:"merged_by"`
+	MergedAt    time.Time  `json:"merged_at"`
+	UndoneBy    *int32     `json:"undone_by"`
+	UndoneAt    *time.Time `json:"undone_at"`
+}
+
+func newCustomerMergeResp(merge *model.CustomerMerge) customerMergeResp {
+	return customerMergeResp{
+		ID:          merge.ID,
+		SurvivorID:  merge.SurvivorID,
+		DuplicateID: merge.DuplicateID,
+		OrderIDs:    parseMergedOrderIDs(merge.OrderIds),
+		MergedBy:    merge.MergedBy,
+		MergedAt:    merge.MergedAt,
+		UndoneBy:    merge.UndoneBy,
+		UndoneAt:    merge.UndoneAt,
+	}
+}
+
+// GetDuplicateCustomers godoc
+//
+//	@Summary		Find duplicate customers
+//	@Description	Find pairs of customers that are likely the same person: the same email ignoring case
+//	@Description	and "+tags", the same phone number ignoring formatting and prefixes, or similar names.
+//	@Description	The older customer of each pair is the suggested survivor of a merge.
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			name_threshold	query	number	false	"Minimum name similarity from 0 to 1"	default(0.85)
+//	@Param			page			query	int		false	"Page number"							default(1)
+//	@Param			pagesize		query	int		false	"Number of items per page"				default(10)
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse{data=PagedResults{data=[]dedupe.Match}}
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/duplicates [get]
+func GetDuplicateCustomers(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid page")
+		return
+	}
+	pagesize, err := strconv.Atoi(c.DefaultQuery("pagesize", "10"))
+	if err != nil || pagesize < 1 {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid pagesize")
+		return
+	}
+	threshold, err := strconv.ParseFloat(c.DefaultQuery("name_threshold", strconv.FormatFloat(defaultNameThreshold, 'f', -1, 64)), 64)
+	if err != nil || threshold <= 0 || threshold > 1 {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "name_threshold must be greater than 0 and at most 1")
+		return
+	}
+
+	var candidates []dedupe.Candidate
+	var batch []*model.Customer
+	err = dal.Customer.Select(dal.Customer.ID, dal.Customer.Name, dal.Customer.Email, dal.Customer.Phone).
+		FindInBatches(&batch, exportBatchSize, func(tx gen.Dao, _ int) error {
+			for _, customer := range batch {
+				candidates = append(candidates, dedupe.Candidate{
+					ID:    customer.ID,
+					Name:  customer.Name,
+					Email: customer.Email,
+					Phone: customer.Phone,
+				})
+			}
+			return nil
+		})
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	matches := dedupe.Find(candidates, threshold)
+	start := min((page-1)*pagesize, len(matches))
+	end := min(start+pagesize, len(matches))
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data: PagedResults{
+			Page:         int64(page),
+			PageSize:     int64(pagesize),
+			Data:         matches[start:end],
+			TotalRecords: len(matches),
+		},
+	})
+}
+
+// MergeCustomer godoc
+//
+//	@Summary		Merge a duplicate into a customer
+//	@Description	Move all orders of the duplicate to the customer and move the duplicate to the trash,
+//	@Description	in one transaction. The moved orders keep their copies of the duplicate's addresses but
+//	@Description	no longer refer to them. The merge is recorded and can be undone.
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path	int					true	"ID of the surviving customer"
+//	@Param			input	body	mergeCustomerReq	true	"The duplicate to merge"
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse{data=customerMergeResp}
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/{id}/merge [post]
+func MergeCustomer(c *gin.Context) {
+	survivorID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid id")
+		return
+	}
+
+	var input mergeCustomerReq
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	actorID := currentActorID(c)
+	merge := &model.CustomerMerge{
+		SurvivorID:  int32(survivorID),
+		DuplicateID: input.DuplicateID,
+		MergedBy:    actorID,
+		MergedAt:    time.Now(),
+	}
+	err = dal.Q.Transaction(func(tx *dal.Query) error {
+		if merge.SurvivorID == merge.DuplicateID {
+			return errMergeSameCustomer
+		}
+		count, err := tx.Customer.Where(tx.Customer.ID.In(merge.SurvivorID, merge.DuplicateID)).Count()
+		if err != nil {
+			return err
+		}
+		if count != 2 {
+			return errMergeNotFound
+		}
+
+		orders, err := tx.Order.
+			Select(tx.Order.ID, tx.Order.ShippingAddressID, tx.Order.BillingAddressID).
+			Where(tx.Order.CustomerID.Eq(merge.DuplicateID)).
+			Find()
+		if err != nil {
+			return err
+		}
+		orderIDs := make([]int32, 0, len(orders))
+		for _, order := range orders {
+			orderIDs = append(orderIDs, order.ID)
+			if order.ShippingAddressID != nil || order.BillingAddressID != nil {
+				merge.OrderAddresses = append(merge.OrderAddresses, model.MergedOrderAddresses{
+					OrderID:           order.ID,
+					ShippingAddressID: order.ShippingAddressID,
+					BillingAddressID:  order.BillingAddressID,
+				})
+			}
+		}
+		// The addresses stay with the duplicate; the orders keep only their copies of them.
+		if len(orderIDs) > 0 {
+			if _, err := tx.Order.Where(tx.Order.ID.In(orderIDs...)).UpdateSimple(
+				tx.Order.CustomerID.Value(merge.SurvivorID),
+				tx.Order.ShippingAddressID.Null(),
+				tx.Order.BillingAddressID.Null(),
+				updatedByExpr(tx.Order.UpdatedBy, actorID),
+			); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Customer.Where(tx.Customer.ID.Eq(merge.DuplicateID)).Updates(&model.Customer{
+			UpdatedBy: actorID,
+			DeletedAt: gorm.DeletedAt{Time: merge.MergedAt, Valid: true},
+		}); err != nil {
+			return err
+		}
+
+		merge.OrderIds = formatMergedOrderIDs(orderIDs)
+		return tx.CustomerMerge.Create(merge)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errMergeSameCustomer):
+			problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, err.Error())
+		case errors.Is(err, errMergeNotFound):
+			problem.Write(c, http.StatusNotFound, problem.CodeNotFound, err.Error())
+		default:
+			problem.Error(c, fmt.Errorf("cannot merge customers: %w", err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   newCustomerMergeResp(merge),
+	})
+}
+
+// GetCustomerMerges godoc
+//
+//	@Summary		Get customer merges
+//	@Description	Get the recorded customer merges, newest first
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			customer_id	query	int	false	"Only merges into or from this customer"
+//	@Param			page		query	int	false	"Page number"				default(1)
+//	@Param			pagesize	query	int	false	"Number of items per page"	default(10)
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse{data=PagedResults{data=[]customerMergeResp}}
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/merges [get]
+func GetCustomerMerges(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid page")
+		return
+	}
+	pagesize, err := strconv.Atoi(c.DefaultQuery("pagesize", "10"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid pagesize")
+		return
+	}
+
+	resultOrm := dal.CustomerMerge.WithContext(c.Request.Context())
+	if customerIDStr := c.Query("customer_id"); customerIDStr != "" {
+		customerID, err := strconv.Atoi(customerIDStr)
+		if err != nil {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid customer_id")
+			return
+		}
+		resultOrm = resultOrm.Where(dal.CustomerMerge.SurvivorID.Eq(int32(customerID))).
+			Or(dal.CustomerMerge.DuplicateID.Eq(int32(customerID)))
+	}
+
+	totalRecords, err := resultOrm.Count()
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	if page > 0 {
+		resultOrm = resultOrm.Offset((page - 1) * pagesize)
+	}
+	merges, err := resultOrm.Order(dal.CustomerMerge.ID.Desc()).Limit(pagesize).Find()
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	resp := make([]customerMergeResp, 0, len(merges))
+	for _, merge := range merges {
+		resp = append(resp, newCustomerMergeResp(merge))
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data: PagedResults{
+			Page:         int64(page),
+			PageSize:     int64(pagesize),
+			Data:         resp,
+			TotalRecords: int(totalRecords),
+		},
+	})
+}
+
+// UndoCustomerMerge godoc
+//
+//	@Summary		Undo a customer merge
+//	@Description	Restore the merged duplicate from the trash and move its orders back to it, with the
+//	@Description	address IDs they had before the merge. Orders that were moved to another customer since
+//	@Description	the merge are left alone, and orders given new addresses since keep them.
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path	int	true	"Merge ID"
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse{data=customerMergeResp}
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		409	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/merges/{id}/undo [post]
+func UndoCustomerMerge(c *gin.Context) {
+	mergeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid id")
+		return
+	}
+
+	merge, err := dal.CustomerMerge.Where(dal.CustomerMerge.ID.Eq(int32(mergeID))).First()
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			problem.Error(c, err)
+			return
+		}
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "merge not found")
+		return
+	}
+
+	actorID := currentActorID(c)
+	err = dal.Q.Transaction(func(tx *dal.Query) error {
+		now := time.Now()
+		info, err := tx.CustomerMerge.Where(
+			tx.CustomerMerge.ID.Eq(merge.ID),
+			tx.CustomerMerge.UndoneAt.IsNull(),
+		).Updates(&model.CustomerMerge{UndoneAt: &now, UndoneBy: actorID})
+		if err != nil {
+			return err
+		}
+		if info.RowsAffected == 0 {
+			return errMergeAlreadyUndone
+		}
+		merge.UndoneAt, merge.UndoneBy = &now, actorID
+
+		// The duplicate may have been merged again, into the same or another customer.
+		later, err := tx.CustomerMerge.Where(
+			tx.CustomerMerge.DuplicateID.Eq(merge.DuplicateID),
+			tx.CustomerMerge.ID.Gt(merge.ID),
+			tx.CustomerMerge.UndoneAt.IsNull(),
+		).Count()
+		if err != nil {
+			return err
+		}
+		if later > 0 {
+			return errMergeNotLatest
+		}
+
+		info, err = tx.Customer.Unscoped().Where(tx.Customer.ID.Eq(merge.DuplicateID)).UpdateSimple(
+			tx.Customer.DeletedAt.Null(),
+			updatedByExpr(tx.Customer.UpdatedBy, actorID),
+		)
+		if err != nil {
+			return err
+		}
+		if info.RowsAffected == 0 {
+			return errMergePurged
+		}
+
+		// Orders that were given new addresses since the merge keep them.
+		for _, addresses := range merge.OrderAddresses {
+			if addresses.ShippingAddressID != nil {
+				if _, err := tx.Order.Where(
+					tx.Order.ID.Eq(addresses.OrderID),
+					tx.Order.CustomerID.Eq(merge.SurvivorID),
+					tx.Order.ShippingAddressID.IsNull(),
+				).Update(tx.Order.ShippingAddressID, *addresses.ShippingAddressID); err != nil {
+					return err
+				}
+			}
+			if addresses.BillingAddressID != nil {
+				if _, err := tx.Order.Where(
+					tx.Order.ID.Eq(addresses.OrderID),
+					tx.Order.CustomerID.Eq(merge.SurvivorID),
+					tx.Order.BillingAddressID.IsNull(),
+				).Update(tx.Order.BillingAddressID, *addresses.BillingAddressID); err != nil {
+					return err
+				}
+			}
+		}
+
+		if orderIDs := parseMergedOrderIDs(merge.OrderIds); len(orderIDs) > 0 {
+			if _, err := tx.Order.Where(
+				tx.Order.ID.In(orderIDs...),
+				tx.Order.CustomerID.Eq(merge.SurvivorID),
+			).UpdateSimple(
+				tx.Order.CustomerID.Value(merge.DuplicateID),
+				updatedByExpr(tx.Order.UpdatedBy, actorID),
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errMergeAlreadyUndone), errors.Is(err, errMergeNotLatest), errors.Is(err, errMergePurged):
+			problem.Write(c, http.StatusConflict, problem.CodeConflict, err.Error())
+		default:
+			problem.Error(c, fmt.Errorf("cannot undo merge: %w", err))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   newCustomerMergeResp(merge),
+	})
+}
+
+func formatMergedOrderIDs(ids []int32) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.Itoa(int(id))
+	}
+	return strings.Join(parts, ",")
+}
+
+func parseMergedOrderIDs(s string) []int32 {
+	ids := []int32{}
+	for _, part := range strings.Split(s, ",") {
+		if id, err := strconv.Atoi(part); err == nil {
+			ids = append(ids, int32(id))
+		}
+	}
+	return ids
+}
@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"dbo-test/internal/dal"
+	"dbo-test/internal/problem"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type customerSummary struct {
+	CustomerID int32 `json:"customer_id"`
+	// OrderCount, TotalAmount, AverageAmount and the order dates cover the orders
+	// between dateFrom and dateTo, or all orders without them.
+	OrderCount     int64      `json:"order_count"`
+	TotalAmount    float64    `json:"total_amount"`
+	AverageAmount  float64    `json:"average_amount"`
+	FirstOrderDate *time.Time `json:"first_order_date"`
+	LastOrderDate  *time.Time `json:"last_order_date"`
+	// LifetimeValue is the total amount of all orders the customer ever placed.
+	LifetimeValue float64 `json:"lifetime_value"`
+}
+
+// GetCustomerOrders godoc
+//
+//	@Summary		Get customer orders
+//	@Description	Get the orders of a customer with pagination and filtering options
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path	int		true	"Customer ID"
+//	@Param			page		query	int		false	"Page number"					default(1)
+//	@Param			pagesize	query	int		false	"Number of items per page"		default(10)
+//	@Param			order		query	string	false	"Order by field (asc or desc)"	default("asc")
+//	@Param			dateFrom	query	string	false	"Filter by order date from"		Format(date)
+//	@Param			dateTo		query	string	false	"Filter by order date to"		Format(date)
+//	@Param			amountFrom	query	number	false	"Filter by order amount from"	default(0)
+//	@Param			amountTo	query	number	false	"Filter by order amount to"		default(0)
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse{data=PagedResults{data=[]model.Order}}
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/{id}/orders [get]
+func GetCustomerOrders(c *gin.Context) {
+	customerID, ok := bindExistingCustomer(c)
+	if !ok {
+		return
+	}
+	page, pagesize, sort, ok := bindOrderPage(c)
+	if !ok {
+		return
+	}
+	filters, ok := bindOrderFilters(c)
+	if !ok {
+		return
+	}
+	filters.CustomerID = customerID
+
+	resp, totalRecords, err := queryMultipleOrder(page, pagesize, sort, filters)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data: PagedResults{
+			Page:         int64(page),
+			PageSize:     int64(pagesize),
+			Data:         resp,
+			TotalRecords: int(totalRecords),
+		},
+	})
+}
+
+// GetCustomerSummary godoc
+//
+//	@Summary		Get customer summary
+//	@Description	Get the order count, total and average amount and first and last order dates of a
+//	@Description	customer, optionally between two dates, and the lifetime value of all their orders
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path	int		true	"Customer ID"
+//	@Param			dateFrom	query	string	false	"Only orders from this date"	Format(date)
+//	@Param			dateTo		query	string	false	"Only orders until this date"	Format(date)
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse{data=customerSummary}
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/{id}/summary [get]
+func GetCustomerSummary(c *gin.Context) {
+	customerID, ok := bindExistingCustomer(c)
+	if !ok {
+		return
+	}
+	dateFrom, err := parseTimeParam(c, "dateFrom")
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, fmt.Sprintf("invalid dateFrom: %s", err.Error()))
+		return
+	}
+	dateTo, err := parseTimeParam(c, "dateTo")
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, fmt.Sprintf("invalid dateTo: %s", err.Error()))
+		return
+	}
+
+	var stats struct {
+		OrderCount     int64
+		TotalAmount    *float64
+		AverageAmount  *float64
+		FirstOrderDate *time.Time
+		LastOrderDate  *time.Time
+	}
+	err = filterOrders(dal.Order.WithContext(c.Request.Context()), orderFilters{
+		CustomerID: customerID,
+		DateFrom:   dateFrom,
+		DateTo:     dateTo,
+	}).Select(
+		dal.Order.ID.Count().As("order_count"),
+		dal.Order.Amount.Sum().As("total_amount"),
+		dal.Order.Amount.Avg().As("average_amount"),
+		dal.Order.OrderDate.Min().As("first_order_date"),
+		dal.Order.OrderDate.Max().As("last_order_date"),
+	).Scan(&stats)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	var lifetime struct {
+		LifetimeValue *float64
+	}
+	err = dal.Order.WithContext(c.Request.Context()).
+		Where(dal.Order.CustomerID.Eq(customerID)).
+		Select(dal.Order.Amount.Sum().As("lifetime_value")).
+		Scan(&lifetime)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	summary := customerSummary{
+		CustomerID:     customerID,
+		OrderCount:     stats.OrderCount,
+		FirstOrderDate: stats.FirstOrderDate,
+		LastOrderDate:  stats.LastOrderDate,
+	}
+	// SUM and AVG are NULL when there are no orders.
+	if stats.TotalAmount != nil {
+		summary.TotalAmount = *stats.TotalAmount
+	}
+	if stats.AverageAmount != nil {
+		summary.AverageAmount = *stats.AverageAmount
+	}
+	if lifetime.LifetimeValue != nil {
+		summary.LifetimeValue = *lifetime.LifetimeValue
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   summary,
+	})
+}
@@ -0,0 +1,431 @@
+package controllers
+
+import (
+	"dbo-test/internal/dal"
+	"dbo-test/internal/model"
+	"dbo-test/internal/problem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm/clause"
+)
+
+// maxTagLength is the longest tag name.
+const maxTagLength = 64
+
+type customerTagsReq struct {
+	Tags []string `json:"tags" binding:"required,min=1" example:"vip,wholesale"`
+}
+
+type bulkTagReq struct {
+	// CustomerIDs are at most 1000 customers changed by one request.
+	CustomerIDs []int32  `json:"customer_ids" binding:"required,min=1,max=1000"`
+	Add         []string `json:"add"`
+	Remove      []string `json:"remove"`
+}
+
+type bulkTagResp struct {
+	// Added and Removed count the customer tags that changed.
+	Added   int64 `json:"added"`
+	Removed int64 `json:"removed"`
+	// MissingIDs are customer IDs that do not exist and were skipped.
+	MissingIDs []int32 `json:"missing_ids"`
+}
+
+type tagResp struct {
+	ID        int32  `json:"id"`
+	Name      string `json:"name"`
+	Customers int64  `json:"customers"`
+}
+
+// GetTags godoc
+//
+//	@Summary		Get tags
+//	@Description	Get all customer tags with how many customers have each
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse{data=[]tagResp}
+//	@Failure		401	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/tags [get]
+func GetTags(c *gin.Context) {
+	tags, err := dal.Tag.WithContext(c.Request.Context()).Order(dal.Tag.Name).Find()
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	var counts []struct {
+		TagID     int32
+		Customers int64
+	}
+	err = dal.CustomerTag.WithContext(c.Request.Context()).
+		Select(dal.CustomerTag.TagID, dal.CustomerTag.CustomerID.Count().As("customers")).
+		Group(dal.CustomerTag.TagID).
+		Scan(&counts)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	customers := make(map[int32]int64, len(counts))
+	for _, count := range counts {
+		customers[count.TagID] = count.Customers
+	}
+
+	resp := make([]tagResp, 0, len(tags))
+	for _, tag := range tags {
+		resp = append(resp, tagResp{ID: tag.ID, Name: tag.Name, Customers: customers[tag.ID]})
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   resp,
+	})
+}
+
+// DeleteTag godoc
+//
+//	@Summary		Delete a tag
+//	@Description	Delete a tag and remove it from every customer
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			tagId	path	int	true	"Tag ID"
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/tags/{tagId} [delete]
+func DeleteTag(c *gin.Context) {
+	tagID, err := strconv.Atoi(c.Param("tagId"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid tag id")
+		return
+	}
+
+	var deleted int64
+	err = dal.Q.Transaction(func(tx *dal.Query) error {
+		info, err := tx.Tag.Where(tx.Tag.ID.Eq(int32(tagID))).Delete()
+		if err != nil {
+			return err
+		}
+		deleted = info.RowsAffected
+		_, err = tx.CustomerTag.Where(tx.CustomerTag.TagID.Eq(int32(tagID))).Delete()
+		return err
+	})
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if deleted == 0 {
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "tag not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   nil,
+	})
+}
+
+// GetCustomerTags godoc
+//
+//	@Summary		Get customer tags
+//	@Description	Get the tags of a customer
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path	int	true	"Customer ID"
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse{data=[]model.Tag}
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/{id}/tags [get]
+func GetCustomerTags(c *gin.Context) {
+	customerID, ok := bindExistingCustomer(c)
+	if !ok {
+		return
+	}
+
+	tags, err := customerTags(dal.Q, customerID)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   tags,
+	})
+}
+
+// AddCustomerTags godoc
+//
+//	@Summary		Tag a customer
+//	@Description	Add tags to a customer. Tags that do not exist yet are created.
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path	int				true	"Customer ID"
+//	@Param			tags	body	customerTagsReq	true	"Tags to add"
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse{data=[]model.Tag}
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/{id}/tags [post]
+func AddCustomerTags(c *gin.Context) {
+	customerID, ok := bindExistingCustomer(c)
+	if !ok {
+		return
+	}
+
+	var input customerTagsReq
+	if !bindJSON(c, &input) {
+		return
+	}
+	names, err := normalizeTags(input.Tags)
+	if err == nil && len(names) == 0 {
+		err = errors.New("tags are required")
+	}
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, err.Error())
+		return
+	}
+
+	var tags []*model.Tag
+	err = dal.Q.Transaction(func(tx *dal.Query) error {
+		if _, err := tagCustomers(tx, []int32{customerID}, names, currentActorID(c)); err != nil {
+			return err
+		}
+		var err error
+		tags, err = customerTags(tx, customerID)
+		return err
+	})
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   tags,
+	})
+}
+
+// RemoveCustomerTag godoc
+//
+//	@Summary		Untag a customer
+//	@Description	Remove a tag from a customer
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path	int		true	"Customer ID"
+//	@Param			tag	path	string	true	"Tag name"
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/{id}/tags/{tag} [delete]
+func RemoveCustomerTag(c *gin.Context) {
+	customerID, ok := bindExistingCustomer(c)
+	if !ok {
+		return
+	}
+
+	removed, err := untagCustomers(dal.Q, []int32{customerID}, []string{normalizeTag(c.Param("tag"))})
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if removed == 0 {
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "customer does not have this tag")
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   nil,
+	})
+}
+
+// BulkTagCustomers godoc
+//
+//	@Summary		Tag many customers
+//	@Description	Add and remove tags on many customers at once, in one transaction.
+//	@Description	Tags that do not exist yet are created; unknown customer IDs are skipped and reported.
+//	@Tags			customers
+//	@Accept			json
+//	@Produce		json
+//	@Param			input	body	bulkTagReq	true	"Customers and the tags to add and remove"
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse{data=bulkTagResp}
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/tags/bulk [post]
+func BulkTagCustomers(c *gin.Context) {
+	var input bulkTagReq
+	if !bindJSON(c, &input) {
+		return
+	}
+	add, err := normalizeTags(input.Add)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, err.Error())
+		return
+	}
+	remove, err := normalizeTags(input.Remove)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, err.Error())
+		return
+	}
+	switch {
+	case len(add) == 0 && len(remove) == 0:
+		err = errors.New("add or remove is required")
+	}
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, err.Error())
+		return
+	}
+
+	resp := bulkTagResp{MissingIDs: []int32{}}
+	err = dal.Q.Transaction(func(tx *dal.Query) error {
+		var customerIDs []int32
+		if err := tx.Customer.Where(tx.Customer.ID.In(input.CustomerIDs...)).Pluck(tx.Customer.ID, &customerIDs); err != nil {
+			return err
+		}
+		found := make(map[int32]bool, len(customerIDs))
+		for _, id := range customerIDs {
+			found[id] = true
+		}
+		for _, id := range input.CustomerIDs {
+			if !found[id] {
+				resp.MissingIDs = append(resp.MissingIDs, id)
+				found[id] = true
+			}
+		}
+		if len(customerIDs) == 0 {
+			return nil
+		}
+
+		var err error
+		if len(add) > 0 {
+			if resp.Added, err = tagCustomers(tx, customerIDs, add, currentActorID(c)); err != nil {
+				return err
+			}
+		}
+		if len(remove) > 0 {
+			if resp.Removed, err = untagCustomers(tx, customerIDs, remove); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   resp,
+	})
+}
+
+// normalizeTag lowercases a tag name and collapses its whitespace.
+func normalizeTag(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// normalizeTags normalizes tag names and removes duplicates, rejecting names that are too long.
+func normalizeTags(names []string) ([]string, error) {
+	seen := make(map[string]bool, len(names))
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		name = normalizeTag(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if len(name) > maxTagLength {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", name, maxTagLength)
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	return result, nil
+}
+
+func customerTags(q *dal.Query, customerID int32) ([]*model.Tag, error) {
+	tagIDs := q.CustomerTag.Select(q.CustomerTag.TagID).Where(q.CustomerTag.CustomerID.Eq(customerID))
+	return q.Tag.Where(q.Tag.Columns(q.Tag.ID).In(tagIDs)).Order(q.Tag.Name).Find()
+}
+
+// tagCustomers adds the tags, creating the ones that do not exist, to the customers
+// and returns how many customer tags were added.
+func tagCustomers(q *dal.Query, customerIDs []int32, names []string, actorID *int32) (int64, error) {
+	newTags := make([]*model.Tag, 0, len(names))
+	for _, name := range names {
+		newTags = append(newTags, &model.Tag{Name: name, CreatedBy: actorID})
+	}
+	if err := q.Tag.Clauses(clause.OnConflict{DoNothing: true}).Create(newTags...); err != nil {
+		return 0, err
+	}
+	var tagIDs []int32
+	if err := q.Tag.Where(q.Tag.Name.In(names...)).Pluck(q.Tag.ID, &tagIDs); err != nil {
+		return 0, err
+	}
+
+	links := q.CustomerTag.Where(q.CustomerTag.CustomerID.In(customerIDs...), q.CustomerTag.TagID.In(tagIDs...))
+	before, err := links.Count()
+	if err != nil {
+		return 0, err
+	}
+
+	newLinks := make([]*model.CustomerTag, 0, len(customerIDs)*len(tagIDs))
+	for _, customerID := range customerIDs {
+		for _, tagID := range tagIDs {
+			newLinks = append(newLinks, &model.CustomerTag{CustomerID: customerID, TagID: tagID, CreatedBy: actorID})
+		}
+	}
+	if err := q.CustomerTag.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(newLinks, exportBatchSize); err != nil {
+		return 0, err
+	}
+
+	after, err := links.Count()
+	if err != nil {
+		return 0, err
+	}
+	return after - before, nil
+}
+
+// untagCustomers removes the tags from the customers and returns how many customer tags were removed.
+func untagCustomers(q *dal.Query, customerIDs []int32, names []string) (int64, error) {
+	tagIDs := q.Tag.Select(q.Tag.ID).Where(q.Tag.Name.In(names...))
+	info, err := q.CustomerTag.Where(
+		q.CustomerTag.CustomerID.In(customerIDs...),
+		q.CustomerTag.Columns(q.CustomerTag.TagID).In(tagIDs),
+	).Delete()
+	if err != nil {
+		return 0, err
+	}
+	return info.RowsAffected, nil
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"dbo-test/internal/dal"
+	"dbo-test/internal/export"
+	"dbo-test/internal/model"
+	"dbo-test/internal/problem"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gen"
+)
+
+const (
+	// exportBatchSize is how many rows an export reads from the database at a time.
+	exportBatchSize = 1000
+
+	// exportWriteTimeout is how long reading and sending one batch may take. Exports may outlast
+	// the WriteTimeout of the server, so the write deadline is moved forward before every batch.
+	exportWriteTimeout = 30 * time.Second
+)
+
+var (
+	customerExportColumns = []string{"id", "name", "email", "phone", "created_by", "updated_by"}
+	orderExportColumns    = []string{"id", "orderDate", "amount", "customerId", "createdBy", "updatedBy"}
+)
+
+// ExportCustomers godoc
+//
+//	@Summary		Export customers
+//	@Description	Stream all customers matching the filters of the customer listing as a file, ordered by ID
+//	@Tags			customers
+//	@Produce		text/csv
+//	@Produce		application/x-ndjson
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Param			format		query	string		false	"csv, ndjson or xlsx"	default(csv)
+//	@Param			name		query	string		false	"Filter by name"
+//	@Param			email		query	string		false	"Filter by email"
+//	@Param			phone		query	string		false	"Filter by phone"
+//	@Param			tag			query	[]string	false	"Filter by tag, repeated or comma separated"	collectionFormat(multi)
+//	@Param			tag_match	query	string		false	"Whether customers need any or all of the tags"	Enums(any, all)	default(any)
+//	@Param			attr.key	query	string		false	"Filter by the value of the custom attribute key, e.g. attr.region=west"
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{file}		file
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/customer/export [get]
+func ExportCustomers(c *gin.Context) {
+	format, ok := bindExportFormat(c)
+	if !ok {
+		return
+	}
+
+	filters, ok := bindCustomerFilters(c)
+	if !ok {
+		return
+	}
+
+	resultOrm := filterCustomers(dal.Customer.WithContext(c.Request.Context()), filters)
+
+	streamExport(c, "customers", format, customerExportColumns, func(w export.Writer) error {
+		var batch []*model.Customer
+		return resultOrm.FindInBatches(&batch, exportBatchSize, func(tx gen.Dao, _ int) error {
+			for _, customer := range batch {
+				if err := w.Write([]any{
+					customer.ID, customer.Name, customer.Email, customer.Phone,
+					customer.CreatedBy, customer.UpdatedBy,
+				}); err != nil {
+					return err
+				}
+			}
+			return flushExport(c, w)
+		})
+	})
+}
+
+// ExportOrders godoc
+//
+//	@Summary		Export orders
+//	@Description	Stream all orders matching the filters of the order listing as a file, ordered by ID
+//	@Tags			Order
+//	@Produce		text/csv
+//	@Produce		application/x-ndjson
+//	@Produce		application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+//	@Param			format		query	string	false	"csv, ndjson or xlsx"			default(csv)
+//	@Param			dateFrom	query	string	false	"Filter by order date from"		Format(date)
+//	@Param			dateTo		query	string	false	"Filter by order date to"		Format(date)
+//	@Param			amountFrom	query	number	false	"Filter by order amount from"	default(0)
+//	@Param			amountTo	query	number	false	"Filter by order amount to"		default(0)
+//	@Param			customerId	query	int		false	"Filter by customer ID"
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{file}		file
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/order/export [get]
+func ExportOrders(c *gin.Context) {
+	format, ok := bindExportFormat(c)
+	if !ok {
+		return
+	}
+	filters, ok := bindOrderFilters(c)
+	if !ok {
+		return
+	}
+
+	resultOrm := filterOrders(dal.Order.WithContext(c.Request.Context()), filters)
+
+	streamExport(c, "orders", format, orderExportColumns, func(w export.Writer) error {
+		var batch []*model.Order
+		return resultOrm.FindInBatches(&batch, exportBatchSize, func(tx gen.Dao, _ int) error {
+			for _, order := range batch {
+				if err := w.Write([]any{
+					order.ID, order.OrderDate, order.Amount, order.CustomerID,
+					order.CreatedBy, order.UpdatedBy,
+				}); err != nil {
+					return err
+				}
+			}
+			return flushExport(c, w)
+		})
+	})
+}
+
+func bindExportFormat(c *gin.Context) (string, bool) {
+	format := strings.ToLower(c.DefaultQuery("format", export.FormatCSV))
+	switch format {
+	case export.FormatCSV, export.FormatNDJSON, export.FormatXLSX:
+		return format, true
+	}
+	problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "format must be csv, ndjson or xlsx")
+	return "", false
+}
+
+// streamExport sends the rows written by write as a file download. Once the first batch
+// has been sent the status cannot change anymore, so later errors end the response early.
+func streamExport(c *gin.Context, name, format string, columns []string, write func(w export.Writer) error) {
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102-150405"), format)
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+
+	err := extendExportDeadline(c)
+	var w export.Writer
+	if err == nil {
+		w, err = export.NewWriter(c.Writer, format, columns)
+	}
+	if err == nil {
+		err = write(w)
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err == nil {
+		return
+	}
+
+	if !c.Writer.Written() {
+		c.Header("Content-Disposition", "")
+		problem.Error(c, fmt.Errorf("cannot export %s: %w", name, err))
+		return
+	}
+	log.Printf("export of %s ended early: %v", name, err)
+	c.Abort()
+}
+
+// flushExport sends a finished batch to the client, so memory use does not grow with the export.
+func flushExport(c *gin.Context, w export.Writer) error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return extendExportDeadline(c)
+}
+
+// extendExportDeadline gives the next batch exportWriteTimeout to reach the client.
+func extendExportDeadline(c *gin.Context) error {
+	err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+	if errors.Is(err, http.ErrNotSupported) {
+		return nil
+	}
+	return err
+}
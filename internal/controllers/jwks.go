@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"dbo-test/internal/jwtkeys"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary		Get JSON Web Key Set
+// @Description	Get the public keys that access tokens are signed with, so other services can verify them.
+// @Description	The response is a plain RFC 7517 key set, not wrapped in the usual response envelope.
+// @Tags			Auth
+// @Produce		json
+// @Success		200	{object}	jwtkeys.JWKS
+// @Router			/.well-known/jwks.json [get]
+func JWKSHandler(keys *jwtkeys.KeySet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, keys.JWKS())
+	}
+}
@@ -0,0 +1,300 @@
+package controllers
+
+import (
+	"context"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/model"
+	"dbo-test/internal/problem"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type loginLogFilter struct {
+	DateFrom time.Time
+	DateTo   time.Time
+	UserID   int32
+	Status   string
+	IP       string
+}
+
+type loginSummary struct {
+	UserID          int32      `json:"user_id"`
+	Email           string     `json:"email"`
+	LastLogin       *time.Time `json:"last_login"`
+	LastFailure     *time.Time `json:"last_failure"`
+	FailuresLast24h int64      `json:"failures_last_24h"`
+	LockedUntil     *time.Time `json:"locked_until"`
+}
+
+// @Summary		Get login data
+// @Description	Get the login audit log, successful and failed attempts, with pagination and filtering options
+// @Tags			Auth
+// @Accept			json
+// @Produce		json
+// @Param			page		query	int		false	"Page number"					default(1)
+// @Param			pagesize	query	int		false	"Number of items per page"		default(10)
+// @Param			order		query	string	false	"Order by field (asc or desc)"	default("login_time desc")
+// @Param			dateFrom	query	string	false	"Filter by login time from"		Format(date)
+// @Param			dateTo		query	string	false	"Filter by login time to"		Format(date)
+// @Param			user_id		query	int		false	"Filter by user ID"
+// @Param			status		query	string	false	"Filter by outcome"				Enums(success, failed, locked)
+// @Param			ip			query	string	false	"Filter by client IP"
+// @Security		Bearer
+// @Success		200	{object}	successResponse{data=PagedResults{data=[]model.LoginLog}}
+// @Failure		400	{object}	problem.Details
+// @Failure		403	{object}	problem.Details
+// @Failure		500	{object}	problem.Details
+// @Router			/login-data [get]
+func GetLoginData(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid page")
+		return
+	}
+	pagesize, err := strconv.Atoi(c.DefaultQuery("pagesize", "10"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid pagesize")
+		return
+	}
+	order := c.DefaultQuery("order", "login_time desc")
+
+	filter := loginLogFilter{
+		Status: c.DefaultQuery("status", ""),
+		IP:     c.DefaultQuery("ip", ""),
+	}
+	if filter.Status != "" && filter.Status != loginStatusSuccess &&
+		filter.Status != loginStatusFailed && filter.Status != loginStatusLocked {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid status")
+		return
+	}
+
+	if userID := c.DefaultQuery("user_id", ""); userID != "" {
+		id, err := strconv.Atoi(userID)
+		if err != nil {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid user_id")
+			return
+		}
+		filter.UserID = int32(id)
+	}
+
+	filter.DateFrom, err = parseTimeParam(c, "dateFrom")
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, fmt.Sprintf("invalid dateFrom: %s", err.Error()))
+		return
+	}
+	filter.DateTo, err = parseTimeParam(c, "dateTo")
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, fmt.Sprintf("invalid dateTo: %s", err.Error()))
+		return
+	}
+
+	orderParts := strings.Split(order, " ")
+	descbBool := false
+	if len(orderParts) > 1 {
+		descbBool = strings.EqualFold(orderParts[1], "desc")
+	}
+
+	resp, totalRecords, err := queryMultipleLoginLog(page, pagesize, orderBy{
+		Field: orderParts[0],
+		Desc:  descbBool,
+	}, filter)
+	if err != nil {
+		problem.Error(c, fmt.Errorf("cannot get login data: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data: PagedResults{
+			Page:         int64(page),
+			PageSize:     int64(pagesize),
+			Data:         resp,
+			TotalRecords: int(totalRecords),
+		},
+	})
+}
+
+func queryMultipleLoginLog(
+	page, pagesize int,
+	order orderBy,
+	filter loginLogFilter,
+) ([]*model.LoginLog, int64, error) {
+
+	loginLogQuery := dal.LoginLog
+	resultOrm := loginLogQuery.WithContext(context.Background())
+
+	if !filter.DateFrom.IsZero() {
+		resultOrm = resultOrm.Where(loginLogQuery.LoginTime.Gte(filter.DateFrom))
+	}
+	if !filter.DateTo.IsZero() {
+		resultOrm = resultOrm.Where(loginLogQuery.LoginTime.Lte(filter.DateTo))
+	}
+	if filter.UserID != 0 {
+		resultOrm = resultOrm.Where(loginLogQuery.UserID.Eq(filter.UserID))
+	}
+	if filter.Status != "" {
+		resultOrm = resultOrm.Where(loginLogQuery.Status.Eq(filter.Status))
+	}
+	if filter.IP != "" {
+		resultOrm = resultOrm.Where(loginLogQuery.IP.Eq(filter.IP))
+	}
+
+	totalRecords, err := resultOrm.Count()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if page > 0 {
+		offset := (page - 1) * pagesize
+		resultOrm = resultOrm.Offset(offset).Limit(pagesize)
+	} else {
+		resultOrm = resultOrm.Limit(pagesize)
+	}
+
+	orderCol, ok := loginLogQuery.GetFieldByName(order.Field)
+	if ok {
+		if order.Desc {
+			resultOrm = resultOrm.Order(orderCol.Desc())
+		} else {
+			resultOrm = resultOrm.Order(orderCol)
+		}
+	}
+
+	resp, err := resultOrm.Find()
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp, totalRecords, nil
+}
+
+// @Summary		Get login summaries
+// @Description	Get, for every user, the last successful login, the last failure and the number of failures
+// @Description	in the last 24 hours
+// @Tags			Auth
+// @Accept			json
+// @Produce		json
+// @Param			page		query	int		false	"Page number"				default(1)
+// @Param			pagesize	query	int		false	"Number of items per page"	default(10)
+// @Param			email		query	string	false	"Filter by email"
+// @Security		Bearer
+// @Success		200	{object}	successResponse{data=PagedResults{data=[]loginSummary}}
+// @Failure		400	{object}	problem.Details
+// @Failure		403	{object}	problem.Details
+// @Failure		500	{object}	problem.Details
+// @Router			/login-data/summary [get]
+func GetLoginSummary(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid page")
+		return
+	}
+	pagesize, err := strconv.Atoi(c.DefaultQuery("pagesize", "10"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid pagesize")
+		return
+	}
+	email := c.DefaultQuery("email", "")
+
+	users, totalRecords, err := queryMultipleUser(page, pagesize, orderBy{Field: "id"}, email)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	resp, err := loginSummaries(users)
+	if err != nil {
+		problem.Error(c, fmt.Errorf("cannot get login summary: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data: PagedResults{
+			Page:         int64(page),
+			PageSize:     int64(pagesize),
+			Data:         resp,
+			TotalRecords: int(totalRecords),
+		},
+	})
+}
+
+// loginSummaries aggregates the login log of the given users with one grouped query per metric.
+func loginSummaries(users []*model.User) ([]loginSummary, error) {
+	userIDs := make([]int32, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+
+	// The log is appended in time order, so the latest attempt of a user with a status is the
+	// one with the highest ID. Its time is read from the row itself because not every driver
+	// scans MAX(login_time) into a time.Time.
+	var lastIDs []int32
+	var latest []*model.LoginLog
+	var failures []struct {
+		UserID int32
+		Total  int64
+	}
+
+	if len(userIDs) > 0 {
+		loginLogQuery := dal.LoginLog
+
+		err := loginLogQuery.
+			Where(loginLogQuery.UserID.In(userIDs...)).
+			Group(loginLogQuery.UserID, loginLogQuery.Status).
+			Pluck(loginLogQuery.ID.Max(), &lastIDs)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(lastIDs) > 0 {
+			latest, err = loginLogQuery.Where(loginLogQuery.ID.In(lastIDs...)).Order(loginLogQuery.ID).Find()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = loginLogQuery.
+			Select(loginLogQuery.UserID, loginLogQuery.ID.Count().As("total")).
+			Where(
+				loginLogQuery.UserID.In(userIDs...),
+				loginLogQuery.Status.Neq(loginStatusSuccess),
+				loginLogQuery.LoginTime.Gte(time.Now().Add(-24*time.Hour)),
+			).
+			Group(loginLogQuery.UserID).
+			Scan(&failures)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	summaries := make(map[int32]*loginSummary, len(users))
+	resp := make([]loginSummary, len(users))
+	for i, user := range users {
+		resp[i] = loginSummary{
+			UserID: user.ID,
+			Email:  user.Email,
+		}
+		if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+			resp[i].LockedUntil = user.LockedUntil
+		}
+		summaries[user.ID] = &resp[i]
+	}
+	// Entries are in ID order, so a later failure status overwrites an earlier one.
+	for _, entry := range latest {
+		at := entry.LoginTime
+		if entry.Status == loginStatusSuccess {
+			summaries[*entry.UserID].LastLogin = &at
+		} else {
+			summaries[*entry.UserID].LastFailure = &at
+		}
+	}
+	for _, row := range failures {
+		summaries[row.UserID].FailuresLast24h = row.Total
+	}
+	return resp, nil
+}
@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"dbo-test/internal/dal"
+	"dbo-test/internal/jwtkeys"
+	"dbo-test/internal/loginguard"
+	"dbo-test/internal/middlewares"
+	"dbo-test/internal/model"
+	"dbo-test/internal/oidc"
+	"dbo-test/internal/problem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"gorm.io/gorm"
+)
+
+const (
+	tokenTypeOIDCState = "oidc_state"
+	oidcStateCookie    = "oidc_state"
+	oidcStateExpire    = 10 * time.Minute
+)
+
+var (
+	errOIDCNoEmail       = errors.New("identity provider returned no email")
+	errOIDCEmailConflict = errors.New("email belongs to a user with another login")
+)
+
+// @Summary		Start identity provider login
+// @Description	Redirects to the login page of the corporate identity provider. The provider
+// @Description	redirects back to /auth/oidc/callback.
+// @Tags			Auth
+// @Success		302
+// @Failure		500	{object}	problem.Details
+// @Failure		502	{object}	problem.Details
+// @Router			/auth/oidc/login [get]
+func OIDCLoginHandler(provider *oidc.Provider, keys *jwtkeys.KeySet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		nonce, err := randomHex(16)
+		if err != nil {
+			problem.Error(c, fmt.Errorf("cannot generate nonce: %w", err))
+			return
+		}
+
+		// The state is signed so the callback can trust the nonce in it, and is also kept
+		// in a cookie so a callback started in another browser is rejected.
+		state, err := keys.Sign(jwt.MapClaims{
+			"typ":   tokenTypeOIDCState,
+			"nonce": nonce,
+			"exp":   time.Now().Add(oidcStateExpire).Unix(),
+		})
+		if err != nil {
+			problem.Error(c, fmt.Errorf("cannot generate state: %w", err))
+			return
+		}
+
+		authURL, err := provider.AuthCodeURL(c.Request.Context(), state, nonce)
+		if err != nil {
+			problem.Log(c, fmt.Errorf("cannot reach identity provider: %w", err))
+			problem.Write(c, http.StatusBadGateway, problem.CodeBadGateway, "cannot reach identity provider")
+			return
+		}
+
+		c.SetSameSite(http.SameSiteLaxMode)
+		c.SetCookie(oidcStateCookie, state, int(oidcStateExpire.Seconds()), "/auth/oidc", "", c.Request.TLS != nil, true)
+		c.Redirect(http.StatusFound, authURL)
+	}
+}
+
+// @Summary		Finish identity provider login
+// @Description	Exchanges the authorization code of the identity provider for our access and refresh tokens.
+// @Description	Users are matched by their provider identity, then by verified email, and are created
+// @Description	with the OIDC_DEFAULT_ROLE role on their first login. Users with two-factor authentication
+// @Description	get a challenge token instead, to be exchanged at /auth/login/2fa.
+// @Tags			Auth
+// @Produce		json
+// @Param			code	query		string	true	"Authorization code"
+// @Param			state	query		string	true	"State returned by the provider"
+// @Success		200		{object}	successResponse{data=tokenResp}
+// @Failure		400		{object}	problem.Details
+// @Failure		401		{object}	problem.Details
+// @Failure		403		{object}	problem.Details
+// @Failure		409		{object}	problem.Details
+// @Failure		423		{object}	problem.Details
+// @Failure		500		{object}	problem.Details
+// @Router			/auth/oidc/callback [get]
+func OIDCCallbackHandler(provider *oidc.Provider, guard *loginguard.Guard, keys *jwtkeys.KeySet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if providerErr := c.Query("error"); providerErr != "" {
+			problem.Log(c, fmt.Errorf("identity provider login failed: %s", providerErr))
+			problem.Write(c, http.StatusUnauthorized, problem.CodeUnauthorized, "cannot verify identity")
+			return
+		}
+
+		state := c.Query("state")
+		cookie, _ := c.Cookie(oidcStateCookie)
+		c.SetCookie(oidcStateCookie, "", -1, "/auth/oidc", "", c.Request.TLS != nil, true)
+		nonce, err := parseOIDCState(keys, state)
+		if err != nil || cookie != state {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid state")
+			return
+		}
+
+		code := c.Query("code")
+		if code == "" {
+			problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, "code is required")
+			return
+		}
+
+		identity, err := provider.Exchange(c.Request.Context(), code, nonce)
+		if err != nil {
+			problem.Log(c, fmt.Errorf("cannot verify identity: %w", err))
+			problem.Write(c, http.StatusUnauthorized, problem.CodeUnauthorized, "cannot verify identity")
+			return
+		}
+
+		user, err := findOrCreateOIDCUser(identity)
+		if err != nil {
+			switch {
+			case errors.Is(err, errOIDCNoEmail):
+				problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, err.Error())
+			case errors.Is(err, errOIDCEmailConflict):
+				problem.Write(c, http.StatusConflict, problem.CodeConflict, err.Error())
+			default:
+				problem.Error(c, fmt.Errorf("cannot find user: %w", err))
+			}
+			return
+		}
+
+		if rejectLockedAccount(c, user) {
+			return
+		}
+
+		if user.DisabledAt != nil {
+			recordLoginAttempt(c, &user.ID, user.Email, loginStatusFailed, failureUserDisabled)
+			problem.Write(c, http.StatusForbidden, problem.CodeUserDisabled, "user is disabled")
+			return
+		}
+
+		// The identity provider replaces the password, not the second factor.
+		if user.TotpEnabledAt != nil {
+			respondTwoFactorChallenge(c, keys, user)
+			return
+		}
+
+		completeLogin(c, guard, keys, user)
+	}
+}
+
+// parseOIDCState validates a state token and returns its nonce.
+func parseOIDCState(keys *jwtkeys.KeySet, state string) (string, error) {
+	token, err := jwt.Parse(state, keys.Keyfunc)
+	if err != nil {
+		return "", err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || claims["typ"] != tokenTypeOIDCState {
+		return "", errors.New("invalid state token")
+	}
+	nonce, _ := claims["nonce"].(string)
+	if nonce == "" {
+		return "", errors.New("invalid state token")
+	}
+	return nonce, nil
+}
+
+// findOrCreateOIDCUser returns the user linked to the identity. An existing user with the
+// same verified email is linked to it; otherwise a user without password is created.
+func findOrCreateOIDCUser(identity *oidc.Identity) (*model.User, error) {
+	user, err := dal.User.Where(
+		dal.User.OidcIssuer.Eq(identity.Issuer),
+		dal.User.OidcSubject.Eq(identity.Subject),
+	).First()
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if identity.Email == "" {
+		return nil, errOIDCNoEmail
+	}
+
+	user, err = dal.User.Where(dal.User.Email.Eq(identity.Email)).First()
+	if err == nil {
+		if !identity.EmailVerified || user.OidcSubject != nil {
+			return nil, errOIDCEmailConflict
+		}
+		if _, err := dal.User.Where(dal.User.ID.Eq(user.ID)).UpdateSimple(
+			dal.User.OidcIssuer.Value(identity.Issuer),
+			dal.User.OidcSubject.Value(identity.Subject),
+		); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	roleName := os.Getenv("OIDC_DEFAULT_ROLE")
+	if roleName == "" {
+		roleName = middlewares.RoleReadOnly
+	}
+	roles, err := findRoles([]string{roleName})
+	if err != nil {
+		return nil, err
+	}
+
+	user = &model.User{
+		Email:       identity.Email,
+		OidcIssuer:  &identity.Issuer,
+		OidcSubject: &identity.Subject,
+		CreatedAt:   time.Now(),
+	}
+	if identity.EmailVerified {
+		user.EmailVerifiedAt = &user.CreatedAt
+	}
+	err = dal.Q.Transaction(func(tx *dal.Query) error {
+		if err := tx.User.Create(user); err != nil {
+			return err
+		}
+		return assignRoles(tx, user.ID, roles)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
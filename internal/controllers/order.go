@@ -4,6 +4,8 @@ import (
 	"context"
 	"dbo-test/internal/dal"
 	"dbo-test/internal/model"
+	"dbo-test/internal/problem"
+	"dbo-test/internal/validation"
 	"errors"
 	"fmt"
 	"net/http"
@@ -12,43 +14,37 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gen/field"
 	"gorm.io/gorm"
 )
 
-//	@Summary		Get Single Order
-//	@Description	get single order by ID
-//	@Tags			Order
-//	@Accept			json
-//	@Produce		json
-//	@Param			id	path	int	true	"Order ID"
-//	@Security		Bearer
-//	@Success		200	{object}	model.Order
-//	@Failure		400	{object}	errorResponse
-//	@Failure		404	{object}	errorResponse
-//	@Failure		500	{object}	errorResponse
-//	@Router			/order/{id} [get]
+// @Summary		Get Single Order
+// @Description	get single order by ID
+// @Tags			Order
+// @Accept			json
+// @Produce		json
+// @Param			id	path	int	true	"Order ID"
+// @Security		Bearer
+// @Security		ApiKey
+// @Success		200	{object}	model.Order
+// @Failure		400	{object}	problem.Details
+// @Failure		404	{object}	problem.Details
+// @Failure		500	{object}	problem.Details
+// @Router			/order/{id} [get]
 func GetSingleOrder(c *gin.Context) {
 	orderID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: "invalid id",
-		})
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid id")
 		return
 	}
 
 	order, err := dal.Order.Where(dal.Order.ID.Eq(int32(orderID))).First()
 	if err != nil {
 		if !errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusInternalServerError, errorResponse{
-				Status:  errorStatus,
-				Message: err.Error(),
-			})
+			problem.Error(c, err)
 			return
 		}
-		c.JSON(http.StatusNotFound, gin.H{
-			"message": "order not found",
-		})
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "order not found")
 		return
 	}
 
@@ -56,6 +52,7 @@ func GetSingleOrder(c *gin.Context) {
 }
 
 // GetMultipleOrder godoc
+//
 //	@Summary		Get Multiple Order
 //	@Description	get multiple order with pagination and filtering options
 //	@Tags			Order
@@ -68,92 +65,116 @@ func GetSingleOrder(c *gin.Context) {
 //	@Param			dateTo		query	string	false	"Filter by order date to"		Format(date)
 //	@Param			amountFrom	query	number	false	"Filter by order amount from"	default(0)
 //	@Param			amountTo	query	number	false	"Filter by order amount to"		default(0)
+//	@Param			customerId	query	int		false	"Filter by customer ID"
 //	@Security		Bearer
+//	@Security		ApiKey
 //	@Success		200	{object}	PagedResults{data=[]model.Order}
-//	@Failure		400	{object}	errorResponse
-//	@Failure		404	{object}	errorResponse
-//	@Failure		500	{object}	errorResponse
+//	@Failure		400	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
 //	@Router			/order [get]
 func GetMultipleOrder(c *gin.Context) {
-	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: "invalid page",
-		})
+	page, pagesize, sort, ok := bindOrderPage(c)
+	if !ok {
 		return
 	}
-	pagesize, err := strconv.Atoi(c.DefaultQuery("pagesize", "10"))
+	filters, ok := bindOrderFilters(c)
+	if !ok {
+		return
+	}
+
+	resp, totalRecords, err := queryMultipleOrder(page, pagesize, sort, filters)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: "invalid pagesize",
-		})
+		problem.Error(c, err)
 		return
 	}
-	order := c.DefaultQuery("order", "asc")
+
+	c.JSON(http.StatusOK, PagedResults{
+		Page:         int64(page),
+		PageSize:     int64(pagesize),
+		Data:         resp,
+		TotalRecords: int(totalRecords),
+	})
+}
+
+// bindOrderPage reads the page, page size and sort order of an order listing from the
+// query, answering with 400 when one is invalid. The order parameter is a field, optionally
+// followed by asc or desc.
+func bindOrderPage(c *gin.Context) (page, pagesize int, sort orderBy, ok bool) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid page")
+		return 0, 0, sort, false
+	}
+	pagesize, err = strconv.Atoi(c.DefaultQuery("pagesize", "10"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid pagesize")
+		return 0, 0, sort, false
+	}
+
+	orderParts := strings.Split(c.DefaultQuery("order", "asc"), " ")
+	sort.Field = orderParts[0]
+	if len(orderParts) > 1 {
+		sort.Desc = strings.EqualFold(orderParts[1], "desc")
+	}
+	return page, pagesize, sort, true
+}
+
+// orderFilters are the order filters shared by the order listing and export.
+type orderFilters struct {
+	DateFrom, DateTo     time.Time
+	AmountFrom, AmountTo float64
+	// CustomerID limits the orders to one customer when it is not 0.
+	CustomerID int32
+}
+
+// bindOrderFilters reads the order filters from the query, answering with 400 when one is invalid.
+func bindOrderFilters(c *gin.Context) (orderFilters, bool) {
+	var filters orderFilters
 
 	amountFromStr := c.DefaultQuery("amountFrom", "0")
 	amountToStr := c.DefaultQuery("amountTo", "0")
 
 	amountFrom, err := strconv.ParseFloat(amountFromStr, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: "invalid amountFrom",
-		})
-		return
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid amountFrom")
+		return filters, false
 	}
 	amountTo, err := strconv.ParseFloat(amountToStr, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: "invalid amountTo",
-		})
-		return
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid amountTo")
+		return filters, false
 	}
 
 	createdFromTime, err := parseTimeParam(c, "dateFrom")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: fmt.Sprintf("invalid dateFrom: %s", err.Error()),
-		})
-		return
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, fmt.Sprintf("invalid dateFrom: %s", err.Error()))
+		return filters, false
 	}
 
 	createdToTime, err := parseTimeParam(c, "dateTo")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: fmt.Sprintf("invalid dateTo: %s", err.Error()),
-		})
-		return
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, fmt.Sprintf("invalid dateTo: %s", err.Error()))
+		return filters, false
 	}
 
-	orderParts := strings.Split(order, " ")
-	descbBool := false
-	if len(orderParts) > 1 {
-		descbBool = strings.EqualFold(orderParts[1], "desc")
-	}
-	resp, totalRecords, err := queryMultipleOrder(page, pagesize, orderBy{
-		Field: orderParts[0],
-		Desc:  descbBool,
-	}, createdFromTime, createdToTime, amountFrom, amountTo)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse{
-			Status:  errorStatus,
-			Message: err.Error(),
-		})
-		return
+	var customerID int
+	if customerIDStr := c.Query("customerId"); customerIDStr != "" {
+		customerID, err = strconv.Atoi(customerIDStr)
+		if err != nil {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid customerId")
+			return filters, false
+		}
 	}
 
-	c.JSON(http.StatusOK, PagedResults{
-		Page:         int64(page),
-		PageSize:     int64(pagesize),
-		Data:         resp,
-		TotalRecords: int(totalRecords),
-	})
+	filters = orderFilters{
+		DateFrom:   createdFromTime,
+		DateTo:     createdToTime,
+		AmountFrom: amountFrom,
+		AmountTo:   amountTo,
+		CustomerID: int32(customerID),
+	}
+	return filters, true
 }
 
 // parseTimeParam parses a time parameter, setting it to midnight UTC if only a date is provided
@@ -194,28 +215,11 @@ func parseTimeParam(c *gin.Context, paramName string) (time.Time, error) {
 func queryMultipleOrder(
 	page, pagesize int,
 	order orderBy,
-	dateFrom, dateTo time.Time,
-	amountFrom, amountTo float64,
+	filters orderFilters,
 ) ([]*model.Order, int64, error) {
 
 	orderQuery := dal.Order
-	resultOrm := orderQuery.WithContext(context.Background())
-
-	if !dateFrom.IsZero() && !dateTo.IsZero() {
-		resultOrm = resultOrm.Where(orderQuery.OrderDate.Gte(dateFrom), orderQuery.OrderDate.Lte(dateTo))
-	} else if !dateFrom.IsZero() && dateTo.IsZero() {
-		resultOrm = resultOrm.Where(orderQuery.OrderDate.Gte(dateFrom)).Debug()
-	} else if dateFrom.IsZero() && !dateTo.IsZero() {
-		resultOrm = resultOrm.Where(orderQuery.OrderDate.Lte(dateTo))
-	}
-
-	if amountFrom > 0 && amountTo > 0 {
-		resultOrm = resultOrm.Where(orderQuery.Amount.Gte(amountFrom), orderQuery.Amount.Lte(amountTo))
-	} else if amountFrom > 0 && amountTo <= 0 {
-		resultOrm = resultOrm.Where(orderQuery.Amount.Gte(amountFrom))
-	} else if amountFrom <= 0 && amountTo > 0 {
-		resultOrm = resultOrm.Where(orderQuery.Amount.Lte(amountTo))
-	}
+	resultOrm := filterOrders(orderQuery.WithContext(context.Background()), filters)
 
 	totalRecords, err := resultOrm.Count()
 	if err != nil {
@@ -245,44 +249,94 @@ func queryMultipleOrder(
 	return resp, totalRecords, nil
 }
 
+// filterOrders narrows an order query to the customer, date and amount filters.
+func filterOrders(resultOrm dal.IOrderDo, filters orderFilters) dal.IOrderDo {
+	orderQuery := dal.Order
+	dateFrom, dateTo := filters.DateFrom, filters.DateTo
+	amountFrom, amountTo := filters.AmountFrom, filters.AmountTo
+
+	if filters.CustomerID != 0 {
+		resultOrm = resultOrm.Where(orderQuery.CustomerID.Eq(filters.CustomerID))
+	}
+
+	if !dateFrom.IsZero() && !dateTo.IsZero() {
+		resultOrm = resultOrm.Where(orderQuery.OrderDate.Gte(dateFrom), orderQuery.OrderDate.Lte(dateTo))
+	} else if !dateFrom.IsZero() && dateTo.IsZero() {
+		resultOrm = resultOrm.Where(orderQuery.OrderDate.Gte(dateFrom))
+	} else if dateFrom.IsZero() && !dateTo.IsZero() {
+		resultOrm = resultOrm.Where(orderQuery.OrderDate.Lte(dateTo))
+	}
+
+	if amountFrom > 0 && amountTo > 0 {
+		resultOrm = resultOrm.Where(orderQuery.Amount.Gte(amountFrom), orderQuery.Amount.Lte(amountTo))
+	} else if amountFrom > 0 && amountTo <= 0 {
+		resultOrm = resultOrm.Where(orderQuery.Amount.Gte(amountFrom))
+	} else if amountFrom <= 0 && amountTo > 0 {
+		resultOrm = resultOrm.Where(orderQuery.Amount.Lte(amountTo))
+	}
+	return resultOrm
+}
+
 type createOrderReq struct {
-	OrderDate  time.Time `json:"order_date" format:"date-time"`
-	Amount     float64   `json:"amount"`
-	CustomerID int32     `json:"customer_id"`
+	// OrderDate can be at most a year in the future.
+	OrderDate         time.Time `json:"order_date" format:"date-time" binding:"required,notfarfuture"`
+	Amount            float64   `json:"amount" binding:"gt=0"`
+	CustomerID        int32     `json:"customer_id" binding:"required"`
+	ShippingAddressID *int32    `json:"shipping_address_id"`
+	BillingAddressID  *int32    `json:"billing_address_id"`
 }
 
 // CreateOrder godoc
+//
 //	@Summary		Create a new order
-//	@Description	Create a new order with the provided details
+//	@Description	Create a new order with the provided details. A copy of the shipping and billing
+//	@Description	addresses is kept on the order; without an address ID the customer's default is used.
 //	@Tags			Order
 //	@Accept			json
 //	@Produce		json
 //	@Param			order	body	createOrderReq	true	"Order details"
 //	@Security		Bearer
+//	@Security		ApiKey
 //	@Success		200	{object}	successResponse
-//	@Failure		400	{object}	errorResponse
-//	@Failure		401	{object}	errorResponse
-//	@Failure		500	{object}	errorResponse
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
 //	@Router			/order [post]
 func CreateOrder(c *gin.Context) {
 	var input createOrderReq
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: err.Error(),
-		})
+	if !bindJSON(c, &input) {
+		return
+	}
+	if !checkOrderCustomer(c, input.CustomerID) {
 		return
 	}
 
-	if err := dal.Order.Create(&model.Order{
+	actorID := currentActorID(c)
+	order := &model.Order{
 		OrderDate:  input.OrderDate,
 		Amount:     input.Amount,
 		CustomerID: input.CustomerID,
-	}); err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse{
-			Status:  errorStatus,
-			Message: err.Error(),
-		})
+		CreatedBy:  actorID,
+		UpdatedBy:  actorID,
+	}
+	err := dal.Q.Transaction(func(tx *dal.Query) error {
+		var err error
+		order.ShippingAddressID, order.ShippingAddress, err = orderAddress(tx, order.CustomerID, input.ShippingAddressID, model.AddressTypeShipping)
+		if err != nil {
+			return err
+		}
+		order.BillingAddressID, order.BillingAddress, err = orderAddress(tx, order.CustomerID, input.BillingAddressID, model.AddressTypeBilling)
+		if err != nil {
+			return err
+		}
+		return tx.Order.Create(order)
+	})
+	if err != nil {
+		if errors.Is(err, errInvalidOrderAddress) {
+			problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, err.Error())
+			return
+		}
+		problem.Error(c, err)
 		return
 	}
 
@@ -293,73 +347,275 @@ func CreateOrder(c *gin.Context) {
 }
 
 type updateOrderReq struct {
-	OrderDate  time.Time `json:"order_date" format:"date-time"`
-	Amount     float64   `json:"amount"`
-	CustomerID int32     `json:"customer_id"`
+	// OrderDate can be at most a year in the future.
+	OrderDate         time.Time `json:"order_date" format:"date-time" binding:"required,notfarfuture"`
+	Amount            float64   `json:"amount" binding:"gt=0"`
+	CustomerID        int32     `json:"customer_id" binding:"required"`
+	ShippingAddressID *int32    `json:"shipping_address_id"`
+	BillingAddressID  *int32    `json:"billing_address_id"`
 }
 
 // UpdateOrder godoc
 //
 //	@Summary		Update an existing order
-//	@Description	Update the details of an existing order
+//	@Description	Update the details of an existing order. The address copies on the order only
+//	@Description	change when a new shipping or billing address ID is given, or when the order moves
+//	@Description	to another customer. Then an address without a new ID falls back to the new
+//	@Description	customer's default address of its type, or is removed when there is none.
 //	@Tags			Order
 //	@Accept			json
 //	@Produce		json
 //	@Param			id		path	int				true	"Order ID"
 //	@Param			order	body	updateOrderReq	true	"Updated order details"
 //	@Security		Bearer
+//	@Security		ApiKey
 //	@Success		200	{object}	successResponse
-//	@Failure		400	{object}	errorResponse
-//	@Failure		401	{object}	errorResponse
-//	@Failure		500	{object}	errorResponse
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
 //	@Router			/order/{id} [put]
 func UpdateOrder(c *gin.Context) {
 	var input updateOrderReq
-
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: err.Error(),
-		})
+	if !bindJSON(c, &input) {
+		return
+	}
+	if !checkOrderCustomer(c, input.CustomerID) {
 		return
 	}
 
 	orderID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: "invalid id",
-		})
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid id")
 		return
 	}
 
-	info, err := dal.Order.Where(dal.Order.ID.Eq(int32(orderID))).Updates(model.Order{
+	update := model.Order{
 		OrderDate:  input.OrderDate,
 		Amount:     input.Amount,
 		CustomerID: input.CustomerID,
+		UpdatedBy:  currentActorID(c),
+	}
+	err = dal.Q.Transaction(func(tx *dal.Query) error {
+		order, err := tx.Order.Where(tx.Order.ID.Eq(int32(orderID))).First()
+		if err != nil {
+			return err
+		}
+		columns := []field.Expr{tx.Order.OrderDate, tx.Order.Amount, tx.Order.CustomerID, tx.Order.UpdatedBy}
+		// The addresses of the old customer must not stay on an order that moved to another one.
+		customerChanged := order.CustomerID != update.CustomerID
+		if input.ShippingAddressID != nil || customerChanged {
+			update.ShippingAddressID, update.ShippingAddress, err = orderAddress(tx, update.CustomerID, input.ShippingAddressID, model.AddressTypeShipping)
+			if err != nil {
+				return err
+			}
+			columns = append(columns, tx.Order.ShippingAddressID, tx.Order.ShippingAddress)
+		}
+		if input.BillingAddressID != nil || customerChanged {
+			update.BillingAddressID, update.BillingAddress, err = orderAddress(tx, update.CustomerID, input.BillingAddressID, model.AddressTypeBilling)
+			if err != nil {
+				return err
+			}
+			columns = append(columns, tx.Order.BillingAddressID, tx.Order.BillingAddress)
+		}
+		_, err = tx.Order.Where(tx.Order.ID.Eq(order.ID)).Select(columns...).Updates(&update)
+		return err
 	})
-	if info.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, errorResponse{
-			Status:  errorStatus,
-			Message: "order not found",
-		})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "order not found")
+		case errors.Is(err, errInvalidOrderAddress):
+			problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, err.Error())
+		default:
+			problem.Error(c, err)
+		}
 		return
 	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   nil,
+	})
+}
+
+// PatchOrder godoc
+//
+//	@Summary		Partially update an order
+//	@Description	Apply a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902) to an order, using the
+//	@Description	field names of the create request. Only the fields the patch changes are written, and they
+//	@Description	must pass the same checks as in a new order. A changed address ID takes a new copy
+//	@Description	of the address, and null removes the address from the order. When the order moves to
+//	@Description	another customer, an address without a new ID falls back to the new customer's default
+//	@Description	address of its type, or is removed when there is none. Plain application/json is
+//	@Description	taken as a merge patch.
+//	@Tags			Order
+//	@Accept			application/merge-patch+json
+//	@Accept			application/json-patch+json
+//	@Produce		json
+//	@Param			id		path	int		true	"Order ID"
+//	@Param			patch	body	object	true	"Merge patch object or JSON Patch operations"
+//	@Security		Bearer
+//	@Security		ApiKey
+//	@Success		200	{object}	successResponse{data=model.Order}
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		415	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
+//	@Router			/order/{id} [patch]
+func PatchOrder(c *gin.Context) {
+	orderID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse{
-			Status:  errorStatus,
-			Message: err.Error(),
-		})
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid id")
 		return
 	}
 
+	order, err := dal.Order.Where(dal.Order.ID.Eq(int32(orderID))).First()
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			problem.Error(c, err)
+			return
+		}
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "order not found")
+		return
+	}
+
+	changes, ok := bindPatch(c, map[string]any{
+		"order_date":          order.OrderDate,
+		"amount":              order.Amount,
+		"customer_id":         order.CustomerID,
+		"shipping_address_id": order.ShippingAddressID,
+		"billing_address_id":  order.BillingAddressID,
+	})
+	if !ok {
+		return
+	}
+
+	var columns []field.Expr
+	var shipping, billing patchedAddress
+	var errs validation.Errors
+	customerID := order.CustomerID
+	for _, key := range patchKeys(changes) {
+		raw := changes[key]
+		switch key {
+		case "order_date":
+			decodePatchMember(&errs, key, raw, &order.OrderDate, false)
+			columns = append(columns, dal.Order.OrderDate)
+		case "amount":
+			decodePatchMember(&errs, key, raw, &order.Amount, false)
+			columns = append(columns, dal.Order.Amount)
+		case "customer_id":
+			decodePatchMember(&errs, key, raw, &order.CustomerID, false)
+			columns = append(columns, dal.Order.CustomerID)
+		case "shipping_address_id":
+			shipping.set = true
+			decodePatchMember(&errs, key, raw, &shipping.id, true)
+		case "billing_address_id":
+			billing.set = true
+			decodePatchMember(&errs, key, raw, &billing.id, true)
+		}
+	}
+	if len(errs) > 0 {
+		problem.Invalid(c, errs)
+		return
+	}
+
+	// The addresses of the old customer must not stay on an order that moved to another one.
+	customerChanged := order.CustomerID != customerID
+	shipping.replace = shipping.set || customerChanged
+	billing.replace = billing.set || customerChanged
+	if shipping.replace {
+		columns = append(columns, dal.Order.ShippingAddressID, dal.Order.ShippingAddress)
+	}
+	if billing.replace {
+		columns = append(columns, dal.Order.BillingAddressID, dal.Order.BillingAddress)
+	}
+
+	// Changed members must be as valid as in a new order.
+	if !validatePatch(c, &createOrderReq{OrderDate: order.OrderDate, Amount: order.Amount, CustomerID: order.CustomerID}, changes) {
+		return
+	}
+	if _, ok := changes["customer_id"]; ok && !checkOrderCustomer(c, order.CustomerID) {
+		return
+	}
+
+	if len(columns) > 0 {
+		order.UpdatedBy = currentActorID(c)
+		columns = append(columns, dal.Order.UpdatedBy)
+		err = dal.Q.Transaction(func(tx *dal.Query) error {
+			var err error
+			if shipping.replace {
+				order.ShippingAddressID, order.ShippingAddress, err = shipping.apply(tx, order.CustomerID, model.AddressTypeShipping)
+				if err != nil {
+					return err
+				}
+			}
+			if billing.replace {
+				order.BillingAddressID, order.BillingAddress, err = billing.apply(tx, order.CustomerID, model.AddressTypeBilling)
+				if err != nil {
+					return err
+				}
+			}
+			_, err = tx.Order.Where(tx.Order.ID.Eq(order.ID)).Select(columns...).Updates(order)
+			return err
+		})
+		if err != nil {
+			if errors.Is(err, errInvalidOrderAddress) {
+				problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, err.Error())
+				return
+			}
+			problem.Error(c, err)
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, successResponse{
 		Status: successStatus,
-		Data:   nil,
+		Data:   order,
 	})
 }
 
+// checkOrderCustomer answers with 400 when the customer an order is for does not exist.
+func checkOrderCustomer(c *gin.Context, customerID int32) bool {
+	count, err := dal.Customer.Where(dal.Customer.ID.Eq(customerID)).Count()
+	if err != nil {
+		problem.Error(c, err)
+		return false
+	}
+	if count == 0 {
+		problem.Invalid(c, validation.Errors{{
+			Field:   "customer_id",
+			Code:    validation.CodeNotFound,
+			Message: fmt.Sprintf("customer %d does not exist", customerID),
+		}})
+		return false
+	}
+	return true
+}
+
+// patchedAddress is an address ID of an order as changed by a patch.
+type patchedAddress struct {
+	set bool
+	// id is the new address ID; nil removes the address from the order.
+	id *int32
+	// replace is set when the address changes, because the patch sets it or moves the order
+	// to another customer.
+	replace bool
+}
+
+// apply returns the address ID and copy an order has after the patch. A new ID takes a copy
+// of the address. Like UpdateOrder, an order moved to another customer without a new ID takes
+// the new customer's default address of the type, or none.
+func (p patchedAddress) apply(q *dal.Query, customerID int32, addressType string) (*int32, *model.AddressSnapshot, error) {
+	if p.set && p.id == nil {
+		return nil, nil, nil
+	}
+	return orderAddress(q, customerID, p.id, addressType)
+}
+
 // DeleteOrder godoc
+//
 //	@Summary		Delete an order
 //	@Description	Delete an order by ID
 //	@Tags			Order
@@ -367,35 +623,27 @@ func UpdateOrder(c *gin.Context) {
 //	@Produce		json
 //	@Param			id	path	int	true	"Order ID"
 //	@Security		Bearer
+//	@Security		ApiKey
 //	@Success		200	{object}	successResponse
-//	@Failure		400	{object}	errorResponse
-//	@Failure		401	{object}	errorResponse
-//	@Failure		404	{object}	errorResponse
-//	@Failure		500	{object}	errorResponse
+//	@Failure		400	{object}	problem.Details
+//	@Failure		401	{object}	problem.Details
+//	@Failure		404	{object}	problem.Details
+//	@Failure		500	{object}	problem.Details
 //	@Router			/order/{id} [delete]
 func DeleteOrder(c *gin.Context) {
 	orderID, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: "invalid id",
-		})
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid id")
 		return
 	}
 
 	info, err := dal.Order.Where(dal.Order.ID.Eq(int32(orderID))).Delete()
-	if info.RowsAffected == 0 {
-		c.JSON(http.StatusNotFound, errorResponse{
-			Status:  errorStatus,
-			Message: "order not found",
-		})
+	if err != nil {
+		problem.Error(c, err)
 		return
 	}
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse{
-			Status:  errorStatus,
-			Message: err.Error(),
-		})
+	if info.RowsAffected == 0 {
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "order not found")
 		return
 	}
 
@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"context"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/loginguard"
+	"dbo-test/internal/model"
+	"dbo-test/internal/notifier"
+	"dbo-test/internal/problem"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errResetTokenUsed = errors.New("reset token has already been used")
+
+type forgotPasswordReq struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type resetPasswordReq struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// @Summary		Request a password reset
+// @Description	Sends a single-use password reset link to the user. The response is the same
+// @Description	whether the email belongs to a user or not, and the link is sent after the response.
+// @Tags			Auth
+// @Accept			json
+// @Produce		json
+// @Param			input	body		forgotPasswordReq	true	"forgot password req"
+// @Success		200		{object}	successResponse
+// @Failure		400		{object}	problem.Details
+// @Failure		429		{object}	problem.Details
+// @Failure		500		{object}	problem.Details
+// @Router			/auth/password/forgot [post]
+func ForgotPasswordHandler(n notifier.Notifier, guard *loginguard.Guard) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input forgotPasswordReq
+		if !bindJSON(c, &input) {
+			return
+		}
+
+		if rejectThrottledRequest(c, guard, input.Email, "password reset") {
+			return
+		}
+		guard.Failure(input.Email, c.ClientIP())
+
+		user, err := dal.User.Where(dal.User.Email.Eq(input.Email)).First()
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			problem.Error(c, err)
+			return
+		}
+
+		if user != nil && user.DisabledAt == nil {
+			// Storing the token and sending the link happen after the response, so that its
+			// timing does not reveal that the account exists.
+			go sendPasswordReset(context.WithoutCancel(c.Request.Context()), n, dal.Q, user)
+		}
+
+		c.JSON(http.StatusOK, successResponse{
+			Status: successStatus,
+			Data:   gin.H{"message": "if the email belongs to an account, a reset link has been sent"},
+		})
+	}
+}
+
+// @Summary		Reset a password
+// @Description	Sets a new password using a token from the password reset link.
+// @Description	Tokens expire and can be used only once; all refresh tokens of the user are revoked.
+// @Tags			Auth
+// @Accept			json
+// @Produce		json
+// @Param			input	body		resetPasswordReq	true	"reset password req"
+// @Success		200		{object}	successResponse
+// @Failure		400		{object}	problem.Details
+// @Failure		500		{object}	problem.Details
+// @Router			/auth/password/reset [post]
+func ResetPasswordHandler(c *gin.Context) {
+	var input resetPasswordReq
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	reset, err := dal.PasswordReset.Where(dal.PasswordReset.TokenHash.Eq(hashToken(input.Token))).First()
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		problem.Error(c, err)
+		return
+	}
+	if reset == nil || reset.UsedAt != nil || time.Now().After(reset.ExpiresAt) {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidToken, "invalid or expired reset token")
+		return
+	}
+
+	user, err := dal.User.Where(dal.User.ID.Eq(reset.UserID)).First()
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+	if rejectWeakPassword(c, input.NewPassword, user.Email, user.ID) {
+		return
+	}
+
+	hashedPassword, err := hashPassword(input.NewPassword)
+	if err != nil {
+		problem.Error(c, fmt.Errorf("cannot hash password: %w", err))
+		return
+	}
+
+	err = dal.Q.Transaction(func(tx *dal.Query) error {
+		info, err := tx.PasswordReset.Where(
+			tx.PasswordReset.ID.Eq(reset.ID),
+			tx.PasswordReset.UsedAt.IsNull(),
+		).Update(tx.PasswordReset.UsedAt, time.Now())
+		if err != nil {
+			return err
+		}
+		if info.RowsAffected == 0 {
+			return errResetTokenUsed
+		}
+
+		if _, err := tx.User.Where(tx.User.ID.Eq(reset.UserID)).Update(tx.User.Password, hashedPassword); err != nil {
+			return err
+		}
+		if err := savePasswordHistory(tx, reset.UserID, user.Password); err != nil {
+			return err
+		}
+		return revokeUserRefreshTokens(tx, reset.UserID)
+	})
+	if err != nil {
+		if errors.Is(err, errResetTokenUsed) {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidToken, "invalid or expired reset token")
+			return
+		}
+		problem.Error(c, fmt.Errorf("cannot reset password: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+	})
+}
+
+// sendPasswordReset stores a new reset token for the user and mails the link. It runs after
+// the response has been written, so failures are only logged.
+func sendPasswordReset(ctx context.Context, n notifier.Notifier, q *dal.Query, user *model.User) {
+	token, err := createPasswordReset(q, user.ID)
+	if err != nil {
+		log.Printf("cannot create password reset for user %d: %v", user.ID, err)
+		return
+	}
+
+	if err := n.Notify(ctx, notifier.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body:    passwordResetBody(token),
+	}); err != nil {
+		log.Printf("cannot send password reset to user %d: %v", user.ID, err)
+	}
+}
+
+// createPasswordReset invalidates the pending reset tokens of the user and stores a new one.
+// Only the SHA-256 digest of the returned token is persisted.
+func createPasswordReset(q *dal.Query, userID int32) (string, error) {
+	expire, err := strconv.Atoi(os.Getenv("PASSWORD_RESET_EXPIRE"))
+	if err != nil {
+		return "", err
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	err = q.Transaction(func(tx *dal.Query) error {
+		if _, err := tx.PasswordReset.Where(
+			tx.PasswordReset.UserID.Eq(userID),
+			tx.PasswordReset.UsedAt.IsNull(),
+		).Update(tx.PasswordReset.UsedAt, time.Now()); err != nil {
+			return err
+		}
+
+		return tx.PasswordReset.Create(&model.PasswordReset{
+			UserID:    userID,
+			TokenHash: hashToken(token),
+			ExpiresAt: time.Now().Add(time.Minute * time.Duration(expire)),
+			CreatedAt: time.Now(),
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func passwordResetBody(token string) string {
+	link := os.Getenv("PASSWORD_RESET_URL") + "?token=" + url.QueryEscape(token)
+	return fmt.Sprintf("We received a request to reset your password.\n\n"+
+		"Open the link below to choose a new password:\n%s\n\n"+
+		"If you did not ask for a reset you can ignore this message.", link)
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"bytes"
+	"dbo-test/internal/patch"
+	"dbo-test/internal/problem"
+	"dbo-test/internal/validation"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindPatch applies the merge patch or JSON patch in the request body to doc, the
+// patchable members of a resource, and returns the members the patch changed. Removed
+// members are returned as null. It answers with 400 or 415 when the patch cannot be applied.
+func bindPatch(c *gin.Context, doc map[string]any) (map[string]json.RawMessage, bool) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, err.Error())
+		return nil, false
+	}
+	original, err := json.Marshal(doc)
+	if err != nil {
+		problem.Error(c, err)
+		return nil, false
+	}
+
+	patched, err := patch.Apply(c.ContentType(), original, body)
+	if err != nil {
+		if errors.Is(err, patch.ErrUnsupportedMediaType) {
+			problem.Write(c, http.StatusUnsupportedMediaType, problem.CodeUnsupportedMediaType, err.Error())
+		} else {
+			problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, err.Error())
+		}
+		return nil, false
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(patched, &members); err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, "the patched document must be an object")
+		return nil, false
+	}
+
+	var unknown []string
+	for key := range members {
+		if _, ok := doc[key]; !ok {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, fmt.Sprintf("unknown or read-only fields: %v", unknown))
+		return nil, false
+	}
+
+	changes := map[string]json.RawMessage{}
+	for key, before := range doc {
+		after, ok := members[key]
+		if !ok {
+			after = json.RawMessage("null")
+		}
+		if !jsonEqual(before, after) {
+			changes[key] = after
+		}
+	}
+	return changes, true
+}
+
+// jsonEqual reports whether a Go value and a JSON value encode the same JSON.
+func jsonEqual(value any, raw json.RawMessage) bool {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	if bytes.Equal(encoded, raw) {
+		return true
+	}
+	var a, b any
+	if json.Unmarshal(encoded, &a) != nil || json.Unmarshal(raw, &b) != nil {
+		return false
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// patchKeys returns the keys of the changed members in order, so that problems with them
+// are reported in a stable order.
+func patchKeys(changes map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(changes))
+	for key := range changes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// decodePatchMember decodes a changed member into dst, rejecting null for members that cannot
+// be null. Problems are added to errs.
+func decodePatchMember(errs *validation.Errors, key string, raw json.RawMessage, dst any, nullable bool) {
+	if !nullable && bytes.Equal(raw, []byte("null")) {
+		*errs = append(*errs, validation.FieldError{
+			Field:   key,
+			Code:    validation.CodeRequired,
+			Message: key + " cannot be null",
+		})
+		return
+	}
+	if err := json.Unmarshal(raw, dst); err != nil {
+		*errs = append(*errs, validation.FieldError{
+			Field:   key,
+			Code:    validation.CodeType,
+			Message: fmt.Sprintf("invalid %s: %s", key, err),
+		})
+	}
+}
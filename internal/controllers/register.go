@@ -0,0 +1,315 @@
+package controllers
+
+import (
+	"context"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/jwtkeys"
+	"dbo-test/internal/loginguard"
+	"dbo-test/internal/middlewares"
+	"dbo-test/internal/model"
+	"dbo-test/internal/notifier"
+	"dbo-test/internal/problem"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"gorm.io/gorm"
+)
+
+const tokenTypeEmailVerification = "email_verification"
+
+const registrationMessage = "if the email can be registered, a verification link has been sent"
+
+type registerReq struct {
+	Email    string `json:"email" binding:"required,email,max=255"`
+	Password string `json:"password" binding:"required"`
+}
+
+type verifyEmailReq struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type resendVerificationReq struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// @Summary		Register an account
+// @Description	Creates an unverified account and sends a verification link to the email. The account
+// @Description	cannot log in until the email is verified. The response is the same whether the email
+// @Description	is already registered or not.
+// @Tags			Auth
+// @Accept			json
+// @Produce		json
+// @Param			input	body		registerReq	true	"register req"
+// @Success		200		{object}	successResponse
+// @Failure		400		{object}	problem.Details
+// @Failure		429		{object}	problem.Details
+// @Failure		500		{object}	problem.Details
+// @Router			/auth/register [post]
+func RegisterHandler(n notifier.Notifier, guard *loginguard.Guard, keys *jwtkeys.KeySet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input registerReq
+		if !bindJSON(c, &input) {
+			return
+		}
+
+		if rejectThrottledRequest(c, guard, input.Email, "registration") {
+			return
+		}
+		guard.Failure(input.Email, c.ClientIP())
+
+		// The password is checked before the email is looked up, so that the answer to a weak
+		// password does not tell whether the email is registered.
+		if rejectWeakPassword(c, input.Password, input.Email, 0) {
+			return
+		}
+
+		existing, err := dal.User.Where(dal.User.Email.Eq(input.Email)).First()
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			problem.Error(c, err)
+			return
+		}
+		if existing != nil {
+			// Tell the owner instead of the caller, so registration cannot be used to find accounts.
+			notifyExistingRegistration(c.Request.Context(), n, keys, existing)
+			respondRegistration(c)
+			return
+		}
+
+		roles, err := findRoles([]string{middlewares.RoleReadOnly})
+		if err != nil {
+			problem.Error(c, fmt.Errorf("cannot find roles: %w", err))
+			return
+		}
+
+		hashedPassword, err := hashPassword(input.Password)
+		if err != nil {
+			problem.Error(c, fmt.Errorf("cannot hash password: %w", err))
+			return
+		}
+
+		user := &model.User{
+			Email:     input.Email,
+			Password:  hashedPassword,
+			CreatedAt: time.Now(),
+		}
+		err = dal.Q.Transaction(func(tx *dal.Query) error {
+			if err := tx.User.Create(user); err != nil {
+				return err
+			}
+			return assignRoles(tx, user.ID, roles)
+		})
+		if problem.IsDuplicate(err) {
+			// A concurrent registration of the email won the race. Answer as for an existing
+			// account, since a conflict would tell the caller that the email is taken.
+			existing, err := dal.User.Where(dal.User.Email.Eq(input.Email)).First()
+			if err != nil {
+				problem.Error(c, err)
+				return
+			}
+			notifyExistingRegistration(c.Request.Context(), n, keys, existing)
+			respondRegistration(c)
+			return
+		}
+		if err != nil {
+			problem.Error(c, fmt.Errorf("cannot create user: %w", err))
+			return
+		}
+
+		sendEmailVerification(c.Request.Context(), n, keys, user)
+		respondRegistration(c)
+	}
+}
+
+// respondRegistration gives the answer to every accepted registration, whether the email
+// was registered before or not.
+func respondRegistration(c *gin.Context) {
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   gin.H{"message": registrationMessage},
+	})
+}
+
+// @Summary		Verify an email
+// @Description	Marks the email of an account as verified using the token from the verification link.
+// @Tags			Auth
+// @Accept			json
+// @Produce		json
+// @Param			input	body		verifyEmailReq	true	"verify email req"
+// @Success		200		{object}	successResponse
+// @Failure		400		{object}	problem.Details
+// @Failure		500		{object}	problem.Details
+// @Router			/auth/register/verify [post]
+func VerifyEmailHandler(keys *jwtkeys.KeySet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input verifyEmailReq
+		if !bindJSON(c, &input) {
+			return
+		}
+
+		userID, email, err := parseEmailVerification(keys, input.Token)
+		if err != nil {
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidToken, "invalid or expired verification token")
+			return
+		}
+
+		// The token names the email it was sent to, so it is void once the email changes.
+		user, err := dal.User.Where(dal.User.ID.Eq(userID), dal.User.Email.Eq(email)).First()
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				problem.Error(c, err)
+				return
+			}
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidToken, "invalid or expired verification token")
+			return
+		}
+
+		if user.EmailVerifiedAt == nil {
+			if _, err := dal.User.Where(dal.User.ID.Eq(user.ID)).Update(dal.User.EmailVerifiedAt, time.Now()); err != nil {
+				problem.Error(c, fmt.Errorf("cannot verify email: %w", err))
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, successResponse{
+			Status: successStatus,
+		})
+	}
+}
+
+// @Summary		Resend the verification email
+// @Description	Sends a new verification link to an account that is not verified yet.
+// @Description	The response is the same whether the email belongs to such an account or not.
+// @Tags			Auth
+// @Accept			json
+// @Produce		json
+// @Param			input	body		resendVerificationReq	true	"resend verification req"
+// @Success		200		{object}	successResponse
+// @Failure		400		{object}	problem.Details
+// @Failure		429		{object}	problem.Details
+// @Failure		500		{object}	problem.Details
+// @Router			/auth/register/resend [post]
+func ResendVerificationHandler(n notifier.Notifier, guard *loginguard.Guard, keys *jwtkeys.KeySet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input resendVerificationReq
+		if !bindJSON(c, &input) {
+			return
+		}
+
+		if rejectThrottledRequest(c, guard, input.Email, "registration") {
+			return
+		}
+		guard.Failure(input.Email, c.ClientIP())
+
+		user, err := dal.User.Where(dal.User.Email.Eq(input.Email)).First()
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			problem.Error(c, err)
+			return
+		}
+		if user != nil && user.EmailVerifiedAt == nil && user.DisabledAt == nil {
+			sendEmailVerification(c.Request.Context(), n, keys, user)
+		}
+
+		c.JSON(http.StatusOK, successResponse{
+			Status: successStatus,
+			Data:   gin.H{"message": "if the email belongs to an unverified account, a verification link has been sent"},
+		})
+	}
+}
+
+// rejectThrottledRequest answers with 429 when the client sends requests that mail the email,
+// such as registrations, too often. kind names the requests in the message. Every attempt
+// counts, successful or not.
+func rejectThrottledRequest(c *gin.Context, guard *loginguard.Guard, email, kind string) bool {
+	wait := guard.Wait(email, c.ClientIP())
+	if wait <= 0 {
+		return false
+	}
+
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	problem.Write(c, http.StatusTooManyRequests, problem.CodeTooManyRequests, fmt.Sprintf("too many %s requests, retry in %d seconds", kind, seconds))
+	return true
+}
+
+// sendEmailVerification sends a verification link to the user. A delivery failure must not
+// reveal whether the account exists, so it is only logged.
+func sendEmailVerification(ctx context.Context, n notifier.Notifier, keys *jwtkeys.KeySet, user *model.User) {
+	token, err := generateEmailVerification(keys, user)
+	if err != nil {
+		log.Printf("cannot create email verification for user %d: %v", user.ID, err)
+		return
+	}
+
+	link := os.Getenv("EMAIL_VERIFICATION_URL") + "?token=" + url.QueryEscape(token)
+	if err := n.Notify(ctx, notifier.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Welcome! Open the link below to verify your email and activate your account:\n%s\n\n"+
+			"If you did not create an account you can ignore this message.", link),
+	}); err != nil {
+		log.Printf("cannot send email verification to user %d: %v", user.ID, err)
+	}
+}
+
+// notifyExistingRegistration handles a registration for an email that already has an account:
+// an unverified account gets a new verification link, a verified one a notice.
+func notifyExistingRegistration(ctx context.Context, n notifier.Notifier, keys *jwtkeys.KeySet, user *model.User) {
+	if user.DisabledAt != nil {
+		return
+	}
+	if user.EmailVerifiedAt == nil {
+		sendEmailVerification(ctx, n, keys, user)
+		return
+	}
+
+	if err := n.Notify(ctx, notifier.Message{
+		To:      user.Email,
+		Subject: "You already have an account",
+		Body: "Someone tried to register a new account with your email, but you already have one.\n\n" +
+			"If you forgot your password you can reset it. If this was not you, you can ignore this message.",
+	}); err != nil {
+		log.Printf("cannot send registration notice to user %d: %v", user.ID, err)
+	}
+}
+
+// generateEmailVerification returns a signed token proving that its holder received mail
+// sent to the user's email. It expires after EMAIL_VERIFICATION_EXPIRE minutes.
+func generateEmailVerification(keys *jwtkeys.KeySet, user *model.User) (string, error) {
+	expire := envInt("EMAIL_VERIFICATION_EXPIRE", 24*60)
+	return keys.Sign(jwt.MapClaims{
+		"typ":   tokenTypeEmailVerification,
+		"exp":   time.Now().Add(time.Minute * time.Duration(expire)).Unix(),
+		"sub":   strconv.Itoa(int(user.ID)),
+		"email": user.Email,
+	})
+}
+
+// parseEmailVerification validates a verification token and returns its user ID and email.
+func parseEmailVerification(keys *jwtkeys.KeySet, tokenString string) (int32, string, error) {
+	token, err := jwt.Parse(tokenString, keys.Keyfunc)
+	if err != nil {
+		return 0, "", err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || claims["typ"] != tokenTypeEmailVerification {
+		return 0, "", errors.New("invalid verification token")
+	}
+
+	sub, _ := claims["sub"].(string)
+	userID, err := strconv.Atoi(sub)
+	email, _ := claims["email"].(string)
+	if err != nil || email == "" {
+		return 0, "", errors.New("invalid verification token")
+	}
+	return int32(userID), email, nil
+}
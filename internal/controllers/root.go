@@ -1,23 +1,39 @@
 package controllers
 
 import (
+	"dbo-test/internal/jwtkeys"
+	"dbo-test/internal/middlewares"
+	"dbo-test/internal/model"
+	"errors"
 	"os"
 	"strconv"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
+	"gorm.io/gen/field"
 )
 
 const (
 	successStatus = "success"
-	errorStatus   = "error"
 	layoutTime    = "2006-01-02"
-)
 
-type errorResponse struct {
-	Status  string `json:"status"`
-	Message string `json:"message"`
-}
+	loginStatusSuccess = "success"
+	loginStatusFailed  = "failed"
+	loginStatusLocked  = "locked"
+
+	failureUnknownEmail    = "unknown_email"
+	failureWrongPassword   = "wrong_password"
+	failureInvalidCode     = "invalid_code"
+	failureUserDisabled    = "user_disabled"
+	failureAccountLocked   = "account_locked"
+	failureEmailUnverified = "email_unverified"
+
+	maxUserAgentLength = 512
+
+	tokenTypeTwoFactorChallenge = "2fa_challenge"
+	twoFactorChallengeExpire    = 5 * time.Minute
+)
 
 type successResponse struct {
 	Status string `json:"status"`
@@ -36,16 +52,125 @@ type PagedResults struct {
 	TotalRecords int         `json:"total_records"`
 }
 
-func generateJWT(email string) (string, error) {
+// generateJWT returns a signed access token for the user together with its token ID (jti).
+// The token belongs to the session of the refresh token family sessionID.
+func generateJWT(keys *jwtkeys.KeySet, user *model.User, sessionID string) (string, string, error) {
 	expire, err := strconv.Atoi(os.Getenv("JWT_EXPIRE"))
+	if err != nil {
+		return "", "", err
+	}
+
+	roles, err := userRoleNames(user.ID)
+	if err != nil {
+		return "", "", err
+	}
+
+	jti, err := randomHex(16)
+	if err != nil {
+		return "", "", err
+	}
+
+	claims := jwt.MapClaims{
+		"jti":   jti,
+		"typ":   middlewares.TokenTypeAccess,
+		"exp":   time.Now().Add(time.Minute * time.Duration(expire)).Unix(),
+		"sub":   strconv.Itoa(int(user.ID)),
+		"email": user.Email,
+		"roles": roles,
+		"sid":   sessionID,
+	}
+	if tenant := os.Getenv("JWT_TENANT"); tenant != "" {
+		claims["tenant"] = tenant
+	}
+
+	signed, err := keys.Sign(claims)
+	if err != nil {
+		return "", "", err
+	}
+	return signed, jti, nil
+}
+
+// generateTwoFactorChallenge returns a short-lived token proving that the user passed the password step.
+// It is only accepted by the second login step, never as an access token.
+func generateTwoFactorChallenge(keys *jwtkeys.KeySet, user *model.User) (string, error) {
+	jti, err := randomHex(16)
 	if err != nil {
 		return "", err
 	}
 
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
-	claims["exp"] = time.Now().Add(time.Minute * time.Duration(expire)).Unix()
-	claims["email"] = email
+	return keys.Sign(jwt.MapClaims{
+		"jti": jti,
+		"typ": tokenTypeTwoFactorChallenge,
+		"exp": time.Now().Add(twoFactorChallengeExpire).Unix(),
+		"sub": strconv.Itoa(int(user.ID)),
+	})
+}
+
+// twoFactorChallenge is a valid challenge token.
+type twoFactorChallenge struct {
+	userID int32
+	// jti and expiresAt let the token be used only once.
+	jti       string
+	expiresAt time.Time
+}
+
+// parseTwoFactorChallenge validates a challenge token.
+func parseTwoFactorChallenge(keys *jwtkeys.KeySet, tokenString string) (*twoFactorChallenge, error) {
+	token, err := jwt.Parse(tokenString, keys.Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || claims["typ"] != tokenTypeTwoFactorChallenge {
+		return nil, errors.New("invalid challenge token")
+	}
+
+	sub, _ := claims["sub"].(string)
+	userID, err := strconv.Atoi(sub)
+	jti, _ := claims["jti"].(string)
+	exp, _ := claims["exp"].(float64)
+	if err != nil || jti == "" || exp == 0 {
+		return nil, errors.New("invalid challenge token")
+	}
+	return &twoFactorChallenge{userID: int32(userID), jti: jti, expiresAt: time.Unix(int64(exp), 0)}, nil
+}
 
-	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+// currentUserID returns the ID of the user the request was authenticated as.
+func currentUserID(c *gin.Context) (int32, error) {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		return 0, errors.New("request is not authenticated")
+	}
+	if !principal.IsUser() {
+		return 0, errors.New("request is not authenticated as a user")
+	}
+	return principal.UserID, nil
+}
+
+// currentActorID returns the user to record as the author of a change, nil when the
+// request comes from a service.
+func currentActorID(c *gin.Context) *int32 {
+	principal, ok := middlewares.GetPrincipal(c)
+	if !ok {
+		return nil
+	}
+	return principal.ActorID()
+}
+
+// updatedByExpr sets an updated_by column to the actor, or to NULL for services.
+func updatedByExpr(column field.Int32, actorID *int32) field.AssignExpr {
+	if actorID == nil {
+		return column.Null()
+	}
+	return column.Value(*actorID)
+}
+
+// envInt reads an integer env variable, falling back when it is unset or invalid.
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
 }
@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"dbo-test/internal/dal"
+	"dbo-test/internal/middlewares"
+	"dbo-test/internal/model"
+	"dbo-test/internal/problem"
+	"dbo-test/internal/session"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type sessionResp struct {
+	ID         int32      `json:"id"`
+	UserID     int32      `json:"user_id"`
+	IP         *string    `json:"ip"`
+	UserAgent  *string    `json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	// Current marks the session of the access token used for the request.
+	Current bool `json:"current"`
+}
+
+func newSessionResp(c *gin.Context, s *model.Session) sessionResp {
+	principal, _ := middlewares.GetPrincipal(c)
+	return sessionResp{
+		ID:         s.ID,
+		UserID:     s.UserID,
+		IP:         s.IP,
+		UserAgent:  s.UserAgent,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		ExpiresAt:  s.ExpiresAt,
+		RevokedAt:  s.RevokedAt,
+		Current:    principal != nil && principal.SessionID != "" && principal.SessionID == s.FamilyID,
+	}
+}
+
+// @Summary		Get my sessions
+// @Description	Get the active sessions of the logged in user, most recently used first
+// @Tags			Auth
+// @Produce		json
+// @Security		Bearer
+// @Success		200	{object}	successResponse{data=[]sessionResp}
+// @Failure		401	{object}	problem.Details
+// @Failure		500	{object}	problem.Details
+// @Router			/auth/sessions [get]
+func GetMySessions(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		problem.Write(c, http.StatusUnauthorized, problem.CodeUnauthorized, err.Error())
+		return
+	}
+
+	respondSessions(c, userID, false)
+}
+
+// @Summary		End one of my sessions
+// @Description	Logs the logged in user out of one of their sessions. Its refresh tokens are revoked
+// @Description	and its access tokens are rejected from then on.
+// @Tags			Auth
+// @Produce		json
+// @Param			id	path	int	true	"Session ID"
+// @Security		Bearer
+// @Success		200	{object}	successResponse
+// @Failure		400	{object}	problem.Details
+// @Failure		401	{object}	problem.Details
+// @Failure		404	{object}	problem.Details
+// @Failure		500	{object}	problem.Details
+// @Router			/auth/sessions/{id} [delete]
+func RevokeMySession(c *gin.Context) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		problem.Write(c, http.StatusUnauthorized, problem.CodeUnauthorized, err.Error())
+		return
+	}
+
+	revokeSessionParam(c, userID, "id")
+}
+
+// @Summary		Get the sessions of a user
+// @Description	Get the sessions of a user, most recently used first
+// @Tags			User
+// @Produce		json
+// @Param			id		path	int		true	"User ID"
+// @Param			revoked	query	bool	false	"Include ended sessions"	default(false)
+// @Security		Bearer
+// @Success		200	{object}	successResponse{data=[]sessionResp}
+// @Failure		400	{object}	problem.Details
+// @Failure		403	{object}	problem.Details
+// @Failure		404	{object}	problem.Details
+// @Failure		500	{object}	problem.Details
+// @Router			/user/{id}/sessions [get]
+func GetUserSessions(c *gin.Context) {
+	includeRevoked, err := strconv.ParseBool(c.DefaultQuery("revoked", "false"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid revoked")
+		return
+	}
+
+	user, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+
+	respondSessions(c, user.ID, includeRevoked)
+}
+
+// @Summary		End a session of a user
+// @Description	Logs a user out of one session. Its refresh tokens are revoked
+// @Description	and its access tokens are rejected from then on.
+// @Tags			User
+// @Produce		json
+// @Param			id			path	int	true	"User ID"
+// @Param			sessionId	path	int	true	"Session ID"
+// @Security		Bearer
+// @Success		200	{object}	successResponse
+// @Failure		400	{object}	problem.Details
+// @Failure		403	{object}	problem.Details
+// @Failure		404	{object}	problem.Details
+// @Failure		500	{object}	problem.Details
+// @Router			/user/{id}/sessions/{sessionId} [delete]
+func RevokeUserSession(c *gin.Context) {
+	user, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+
+	revokeSessionParam(c, user.ID, "sessionId")
+}
+
+// @Summary		End all sessions of a user
+// @Description	Logs a user out everywhere by revoking all their refresh tokens and sessions
+// @Tags			User
+// @Produce		json
+// @Param			id	path	int	true	"User ID"
+// @Security		Bearer
+// @Success		200	{object}	successResponse
+// @Failure		400	{object}	problem.Details
+// @Failure		403	{object}	problem.Details
+// @Failure		404	{object}	problem.Details
+// @Failure		500	{object}	problem.Details
+// @Router			/user/{id}/sessions [delete]
+func RevokeUserSessions(c *gin.Context) {
+	user, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+
+	err := dal.Q.Transaction(func(tx *dal.Query) error {
+		return revokeUserRefreshTokens(tx, user.ID)
+	})
+	if err != nil {
+		problem.Error(c, fmt.Errorf("cannot end sessions: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+	})
+}
+
+func respondSessions(c *gin.Context, userID int32, includeRevoked bool) {
+	sessionQuery := dal.Session.Where(dal.Session.UserID.Eq(userID))
+	if !includeRevoked {
+		sessionQuery = sessionQuery.Where(dal.Session.RevokedAt.IsNull(), dal.Session.ExpiresAt.Gt(time.Now()))
+	}
+
+	sessions, err := sessionQuery.Order(dal.Session.LastSeenAt.Desc()).Find()
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	resp := make([]sessionResp, 0, len(sessions))
+	for _, s := range sessions {
+		resp = append(resp, newSessionResp(c, s))
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   resp,
+	})
+}
+
+// revokeSessionParam ends the session named by the path parameter param. Sessions of other
+// users are reported as not found.
+func revokeSessionParam(c *gin.Context, userID int32, param string) {
+	sessionID, err := strconv.Atoi(c.Param(param))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid session id")
+		return
+	}
+
+	s, err := dal.Session.Where(dal.Session.ID.Eq(int32(sessionID)), dal.Session.UserID.Eq(userID)).First()
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			problem.Error(c, err)
+			return
+		}
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "session not found")
+		return
+	}
+
+	if session.IsActive(s, time.Now()) {
+		if err := revokeRefreshTokenFamily(s.FamilyID); err != nil {
+			problem.Error(c, fmt.Errorf("cannot end session: %w", err))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+	})
+}
@@ -0,0 +1,428 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/jwtkeys"
+	"dbo-test/internal/loginguard"
+	"dbo-test/internal/model"
+	"dbo-test/internal/password"
+	"dbo-test/internal/problem"
+	"dbo-test/internal/revocation"
+	"dbo-test/internal/totp"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gen/field"
+)
+
+const recoveryCodeCount = 10
+
+var errChallengeUsed = errors.New("challenge token has already been used")
+
+type twoFactorChallengeResp struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+}
+
+type twoFactorEnrollResp struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+}
+
+type twoFactorConfirmReq struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type twoFactorConfirmResp struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type twoFactorDisableReq struct {
+	Password     string `json:"password" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type twoFactorLoginReq struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+// @Summary		Start two-factor enrollment
+// @Description	Generates a new TOTP secret for the logged in user and returns it with an otpauth URI
+// @Description	for authenticator apps. Two-factor authentication is enabled once a code is confirmed.
+// @Tags			Auth
+// @Accept			json
+// @Produce		json
+// @Security		Bearer
+// @Success		200	{object}	successResponse{data=twoFactorEnrollResp}
+// @Failure		401	{object}	problem.Details
+// @Failure		409	{object}	problem.Details
+// @Failure		500	{object}	problem.Details
+// @Router			/auth/2fa/enroll [post]
+func EnrollTwoFactor(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if user.TotpEnabledAt != nil {
+		problem.Write(c, http.StatusConflict, problem.CodeConflict, "two-factor authentication is already enabled")
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		problem.Error(c, fmt.Errorf("cannot generate secret: %w", err))
+		return
+	}
+
+	// A new secret starts a new sequence of time steps, so the last step of an earlier one is forgotten.
+	if _, err := dal.User.Where(dal.User.ID.Eq(user.ID)).UpdateSimple(
+		dal.User.TotpSecret.Value(secret),
+		dal.User.TotpLastStep.Null(),
+	); err != nil {
+		problem.Error(c, fmt.Errorf("cannot save secret: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data: twoFactorEnrollResp{
+			Secret:     secret,
+			OtpauthURI: totp.URI(os.Getenv("TOTP_ISSUER"), user.Email, secret),
+		},
+	})
+}
+
+// @Summary		Confirm two-factor enrollment
+// @Description	Enables two-factor authentication after checking a code from the authenticator app.
+// @Description	The response holds single-use recovery codes, they are shown only once.
+// @Tags			Auth
+// @Accept			json
+// @Produce		json
+// @Security		Bearer
+// @Param			input	body		twoFactorConfirmReq	true	"code from the authenticator app"
+// @Success		200		{object}	successResponse{data=twoFactorConfirmResp}
+// @Failure		400		{object}	problem.Details
+// @Failure		401		{object}	problem.Details
+// @Failure		409		{object}	problem.Details
+// @Failure		500		{object}	problem.Details
+// @Router			/auth/2fa/confirm [post]
+func ConfirmTwoFactor(c *gin.Context) {
+	var input twoFactorConfirmReq
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if user.TotpEnabledAt != nil {
+		problem.Write(c, http.StatusConflict, problem.CodeConflict, "two-factor authentication is already enabled")
+		return
+	}
+	if user.TotpSecret == nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, "two-factor enrollment has not been started")
+		return
+	}
+	valid, err := acceptTOTP(dal.Q, user, input.Code)
+	if err != nil {
+		problem.Error(c, fmt.Errorf("cannot check code: %w", err))
+		return
+	}
+	if !valid {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidCode, "invalid code")
+		return
+	}
+
+	codes, hashedCodes, err := generateRecoveryCodes(user.ID)
+	if err != nil {
+		problem.Error(c, fmt.Errorf("cannot generate recovery codes: %w", err))
+		return
+	}
+
+	err = dal.Q.Transaction(func(tx *dal.Query) error {
+		if _, err := tx.User.Where(tx.User.ID.Eq(user.ID)).Update(tx.User.TotpEnabledAt, time.Now()); err != nil {
+			return err
+		}
+		if _, err := tx.RecoveryCode.Where(tx.RecoveryCode.UserID.Eq(user.ID)).Delete(); err != nil {
+			return err
+		}
+		return tx.RecoveryCode.Create(hashedCodes...)
+	})
+	if err != nil {
+		problem.Error(c, fmt.Errorf("cannot enable two-factor authentication: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   twoFactorConfirmResp{RecoveryCodes: codes},
+	})
+}
+
+// @Summary		Disable two-factor authentication
+// @Description	Disables two-factor authentication of the logged in user.
+// @Description	Requires the password and either a current code or an unused recovery code.
+// @Description	Wrong passwords and codes count as failed logins and are throttled the same way.
+// @Tags			Auth
+// @Accept			json
+// @Produce		json
+// @Security		Bearer
+// @Param			input	body		twoFactorDisableReq	true	"password and code or recovery code"
+// @Success		200		{object}	successResponse
+// @Failure		400		{object}	problem.Details
+// @Failure		401		{object}	problem.Details
+// @Failure		423		{object}	problem.Details
+// @Failure		429		{object}	problem.Details
+// @Failure		500		{object}	problem.Details
+// @Router			/auth/2fa/disable [post]
+func DisableTwoFactorHandler(guard *loginguard.Guard) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input twoFactorDisableReq
+		if !bindJSON(c, &input) {
+			return
+		}
+
+		user, ok := currentUser(c)
+		if !ok {
+			return
+		}
+		if user.TotpEnabledAt == nil {
+			problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, "two-factor authentication is not enabled")
+			return
+		}
+
+		if rejectThrottledLogin(c, guard, user.Email) || rejectLockedAccount(c, user) {
+			return
+		}
+
+		if !password.Verify(user.Password, input.Password) {
+			registerLoginFailure(c, guard, user, failureWrongPassword)
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidCredentials, "password is incorrect")
+			return
+		}
+
+		valid, err := checkSecondFactor(dal.Q, user, input.Code, input.RecoveryCode)
+		if err != nil {
+			problem.Error(c, fmt.Errorf("cannot check code: %w", err))
+			return
+		}
+		if !valid {
+			registerLoginFailure(c, guard, user, failureInvalidCode)
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidCode, "invalid code")
+			return
+		}
+
+		err = dal.Q.Transaction(func(tx *dal.Query) error {
+			if _, err := tx.User.Where(tx.User.ID.Eq(user.ID)).UpdateSimple(
+				tx.User.TotpSecret.Null(),
+				tx.User.TotpEnabledAt.Null(),
+			); err != nil {
+				return err
+			}
+			_, err := tx.RecoveryCode.Where(tx.RecoveryCode.UserID.Eq(user.ID)).Delete()
+			return err
+		})
+		if err != nil {
+			problem.Error(c, fmt.Errorf("cannot disable two-factor authentication: %w", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, successResponse{
+			Status: successStatus,
+		})
+	}
+}
+
+// @Summary		Second login step
+// @Description	Exchanges the challenge token returned by /auth/login and a code from the authenticator app,
+// @Description	or an unused recovery code, for the access and refresh tokens. A challenge token completes
+// @Description	one login only.
+// @Tags			Auth
+// @Accept			json
+// @Produce		json
+// @Param			input	body		twoFactorLoginReq	true	"challenge token and code"
+// @Success		200		{object}	successResponse{data=tokenResp}
+// @Failure		400		{object}	problem.Details
+// @Failure		401		{object}	problem.Details
+// @Failure		403		{object}	problem.Details
+// @Failure		423		{object}	problem.Details
+// @Failure		429		{object}	problem.Details
+// @Failure		500		{object}	problem.Details
+// @Router			/auth/login/2fa [post]
+func LoginTwoFactorHandler(guard *loginguard.Guard, keys *jwtkeys.KeySet, revoked revocation.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input twoFactorLoginReq
+		if !bindJSON(c, &input) {
+			return
+		}
+
+		challenge, err := parseTwoFactorChallenge(keys, input.ChallengeToken)
+		if err != nil {
+			problem.Write(c, http.StatusUnauthorized, problem.CodeInvalidToken, "invalid or expired challenge token")
+			return
+		}
+		used, err := revoked.IsRevoked(challenge.jti)
+		if err != nil {
+			problem.Error(c, fmt.Errorf("cannot check challenge token: %w", err))
+			return
+		}
+		if used {
+			problem.Write(c, http.StatusUnauthorized, problem.CodeInvalidToken, "invalid or expired challenge token")
+			return
+		}
+
+		user, err := dal.User.Where(dal.User.ID.Eq(challenge.userID)).First()
+		if err != nil || user.TotpEnabledAt == nil {
+			problem.Write(c, http.StatusUnauthorized, problem.CodeInvalidToken, "invalid or expired challenge token")
+			return
+		}
+		if rejectThrottledLogin(c, guard, user.Email) || rejectLockedAccount(c, user) {
+			return
+		}
+		if user.DisabledAt != nil {
+			problem.Write(c, http.StatusForbidden, problem.CodeUserDisabled, "user is disabled")
+			return
+		}
+
+		// A challenge completes one login only, also when it is sent twice at the same time. The
+		// code is checked in the transaction that consumes the challenge, so a recovery code or
+		// time step is not spent when the challenge was already used.
+		var valid bool
+		err = dal.Q.Transaction(func(tx *dal.Query) error {
+			var err error
+			valid, err = checkSecondFactor(tx, user, input.Code, input.RecoveryCode)
+			if err != nil {
+				return fmt.Errorf("cannot check code: %w", err)
+			}
+			if !valid {
+				return nil
+			}
+
+			first, err := revoked.Consume(challenge.jti, challenge.expiresAt)
+			if err != nil {
+				return fmt.Errorf("cannot use challenge token: %w", err)
+			}
+			if !first {
+				return errChallengeUsed
+			}
+			return nil
+		})
+		if err != nil {
+			if errors.Is(err, errChallengeUsed) {
+				problem.Write(c, http.StatusUnauthorized, problem.CodeInvalidToken, "invalid or expired challenge token")
+				return
+			}
+			problem.Error(c, err)
+			return
+		}
+		if !valid {
+			registerLoginFailure(c, guard, user, failureInvalidCode)
+			problem.Write(c, http.StatusUnauthorized, problem.CodeInvalidCode, "invalid code")
+			return
+		}
+
+		completeLogin(c, guard, keys, user)
+	}
+}
+
+// checkSecondFactor accepts either a TOTP code or a recovery code.
+// A matching recovery code is marked as used.
+func checkSecondFactor(q *dal.Query, user *model.User, code, recoveryCode string) (bool, error) {
+	if code != "" {
+		valid, err := acceptTOTP(q, user, code)
+		if valid || err != nil {
+			return valid, err
+		}
+	}
+	if recoveryCode == "" {
+		return false, nil
+	}
+
+	info, err := q.RecoveryCode.Where(
+		q.RecoveryCode.UserID.Eq(user.ID),
+		q.RecoveryCode.CodeHash.Eq(hashToken(normalizeRecoveryCode(recoveryCode))),
+		q.RecoveryCode.UsedAt.IsNull(),
+	).Update(q.RecoveryCode.UsedAt, time.Now())
+	if err != nil {
+		return false, err
+	}
+	return info.RowsAffected == 1, nil
+}
+
+// acceptTOTP checks a code from the authenticator app and remembers its time step, so that
+// the code, or an older one, cannot be used again while it is still valid.
+func acceptTOTP(q *dal.Query, user *model.User, code string) (bool, error) {
+	if user.TotpSecret == nil {
+		return false, nil
+	}
+	step, ok := totp.Step(*user.TotpSecret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	// The condition makes the check and the update one step, so concurrent requests cannot both use the code.
+	info, err := q.User.Where(
+		q.User.ID.Eq(user.ID),
+		field.Or(q.User.TotpLastStep.IsNull(), q.User.TotpLastStep.Lt(step)),
+	).Update(q.User.TotpLastStep, step)
+	if err != nil {
+		return false, err
+	}
+	return info.RowsAffected == 1, nil
+}
+
+// generateRecoveryCodes returns the plain recovery codes and the rows storing their digests.
+func generateRecoveryCodes(userID int32) ([]string, []*model.RecoveryCode, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashedCodes := make([]*model.RecoveryCode, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 6)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))
+		code = code[:5] + "-" + code[5:]
+
+		codes = append(codes, code)
+		hashedCodes = append(hashedCodes, &model.RecoveryCode{
+			UserID:    userID,
+			CodeHash:  hashToken(normalizeRecoveryCode(code)),
+			CreatedAt: time.Now(),
+		})
+	}
+
+	return codes, hashedCodes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+// currentUser loads the user the request was authenticated as.
+// It writes the error response itself and returns false when the user cannot be loaded.
+func currentUser(c *gin.Context) (*model.User, bool) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		problem.Write(c, http.StatusUnauthorized, problem.CodeUnauthorized, err.Error())
+		return nil, false
+	}
+
+	user, err := dal.User.Where(dal.User.ID.Eq(userID)).First()
+	if err != nil {
+		problem.Write(c, http.StatusUnauthorized, problem.CodeInvalidCredentials, "invalid user")
+		return nil, false
+	}
+	return user, true
+}
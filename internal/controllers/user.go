@@ -1,43 +1,557 @@
 package controllers
 
 import (
+	"context"
 	"dbo-test/internal/dal"
+	"dbo-test/internal/loginguard"
+	"dbo-test/internal/middlewares"
 	"dbo-test/internal/model"
+	"dbo-test/internal/password"
+	"dbo-test/internal/problem"
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-//	@Summary		Create a new user
-//	@Description	Create a new user with the provided email and password
-//	@Tags			Auth
-//	@Accept			json
-//	@Produce		json
-//	@Security		Bearer
-//	@Param			input	body		createUserReq	true	"User details"
-//	@Success		200		{object}	successResponse
-//	@Failure		400		{object}	errorResponse
-//	@Failure		500		{object}	errorResponse
-//	@Router			/user [post]
+var errUnknownRole = errors.New("unknown role")
+
+type createUserReq struct {
+	Email    string   `json:"email" binding:"required,email,max=255"`
+	Password string   `json:"password" binding:"required"`
+	Roles    []string `json:"roles" binding:"unique" example:"sales"`
+}
+
+type updateUserReq struct {
+	Email    string   `json:"email" binding:"omitempty,email,max=255"`
+	Password string   `json:"password"`
+	Roles    []string `json:"roles" binding:"unique" example:"sales"`
+}
+
+type changePasswordReq struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type userResp struct {
+	ID              int32      `json:"id"`
+	Email           string     `json:"email"`
+	Roles           []string   `json:"roles"`
+	Disabled        bool       `json:"disabled"`
+	DisabledAt      *time.Time `json:"disabled_at"`
+	LockedUntil     *time.Time `json:"locked_until"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+func newUserResp(user *model.User, roles []string) userResp {
+	if roles == nil {
+		roles = []string{}
+	}
+	return userResp{
+		ID:              user.ID,
+		Email:           user.Email,
+		Roles:           roles,
+		Disabled:        user.DisabledAt != nil,
+		DisabledAt:      user.DisabledAt,
+		LockedUntil:     user.LockedUntil,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		CreatedAt:       user.CreatedAt,
+	}
+}
+
+// @Summary		Create a new user
+// @Description	Create a new user with the provided email, password and roles.
+// @Description	Users created without roles get the readonly role.
+// @Tags			User
+// @Accept			json
+// @Produce		json
+// @Security		Bearer
+// @Param			input	body		createUserReq	true	"User details"
+// @Success		200		{object}	successResponse{data=userResp}
+// @Failure		400		{object}	problem.Details
+// @Failure		403		{object}	problem.Details
+// @Failure		409		{object}	problem.Details
+// @Failure		500		{object}	problem.Details
+// @Router			/user [post]
 func CreateUser(c *gin.Context) {
 	var input createUserReq
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse{
-			Status:  errorStatus,
-			Message: err.Error(),
-		})
+	if !bindJSON(c, &input) {
 		return
 	}
+	if len(input.Roles) == 0 {
+		input.Roles = []string{middlewares.RoleReadOnly}
+	}
 
-	if err := dal.User.Create(&model.User{
-		Email:    input.Email,
-		Password: input.Password,
-	}); err != nil {
-		c.JSON(http.StatusInternalServerError, errorResponse{
-			Status:  errorStatus,
-			Message: fmt.Sprintf("cannot create user: %v", err),
+	roles, err := findRoles(input.Roles)
+	if err != nil {
+		if errors.Is(err, errUnknownRole) {
+			problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, err.Error())
+			return
+		}
+		problem.Error(c, fmt.Errorf("cannot find roles: %w", err))
+		return
+	}
+
+	taken, err := emailTaken(input.Email, 0)
+	if err != nil {
+		problem.Error(c, fmt.Errorf("cannot create user: %w", err))
+		return
+	}
+	if taken {
+		problem.Write(c, http.StatusConflict, problem.CodeConflict, "email is already in use")
+		return
+	}
+
+	if rejectWeakPassword(c, input.Password, input.Email, 0) {
+		return
+	}
+
+	hashedPassword, err := hashPassword(input.Password)
+	if err != nil {
+		problem.Error(c, fmt.Errorf("cannot hash password: %w", err))
+		return
+	}
+
+	// Users created by an admin do not need to verify their email.
+	now := time.Now()
+	user := &model.User{
+		Email:           input.Email,
+		Password:        hashedPassword,
+		CreatedAt:       now,
+		EmailVerifiedAt: &now,
+	}
+	err = dal.Q.Transaction(func(tx *dal.Query) error {
+		if err := tx.User.Create(user); err != nil {
+			return err
+		}
+		return assignRoles(tx, user.ID, roles)
+	})
+	if err != nil {
+		problem.Error(c, fmt.Errorf("cannot create user: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   newUserResp(user, input.Roles),
+	})
+}
+
+// @Summary		Get multiple users
+// @Description	Get a list of users with pagination and filtering options
+// @Tags			User
+// @Accept			json
+// @Produce		json
+// @Param			page		query	int		false	"Page number"					default(1)
+// @Param			pagesize	query	int		false	"Number of items per page"		default(10)
+// @Param			order		query	string	false	"Order by id, email, created_at, disabled_at, email_verified_at or locked_until (asc or desc)"	default("asc")
+// @Param			email		query	string	false	"Filter by email"
+// @Security		Bearer
+// @Success		200	{object}	successResponse{data=PagedResults{data=[]userResp}}
+// @Failure		400	{object}	problem.Details
+// @Failure		403	{object}	problem.Details
+// @Failure		500	{object}	problem.Details
+// @Router			/user [get]
+func GetMultipleUser(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid page")
+		return
+	}
+	pagesize, err := strconv.Atoi(c.DefaultQuery("pagesize", "10"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid pagesize")
+		return
+	}
+	order := c.DefaultQuery("order", "asc")
+	email := c.DefaultQuery("email", "")
+
+	orderParts := strings.Split(order, " ")
+	descbBool := false
+	if len(orderParts) > 1 {
+		descbBool = strings.EqualFold(orderParts[1], "desc")
+	}
+
+	users, totalRecords, err := queryMultipleUser(page, pagesize, orderBy{
+		Field: orderParts[0],
+		Desc:  descbBool,
+	}, email)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	userIDs := make([]int32, 0, len(users))
+	for _, user := range users {
+		userIDs = append(userIDs, user.ID)
+	}
+	roles, err := rolesByUser(userIDs...)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	resp := make([]userResp, 0, len(users))
+	for _, user := range users {
+		resp = append(resp, newUserResp(user, roles[user.ID]))
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data: PagedResults{
+			Page:         int64(page),
+			PageSize:     int64(pagesize),
+			Data:         resp,
+			TotalRecords: int(totalRecords),
+		},
+	})
+}
+
+// userOrderFields are the columns users can be ordered by. Ordering by a secret such as the
+// password hash would leak it one comparison at a time, so new columns are not sortable until
+// they are listed here.
+var userOrderFields = []string{"id", "email", "created_at", "disabled_at", "email_verified_at", "locked_until"}
+
+func queryMultipleUser(
+	page, pagesize int,
+	order orderBy,
+	email string,
+) ([]*model.User, int64, error) {
+
+	userQuery := dal.User
+	resultOrm := userQuery.WithContext(context.Background())
+
+	if email != "" {
+		resultOrm = resultOrm.Where(userQuery.Email.Like("%" + email + "%"))
+	}
+
+	totalRecords, err := resultOrm.Count()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if page > 0 {
+		offset := (page - 1) * pagesize
+		resultOrm = resultOrm.Offset(offset).Limit(pagesize)
+	} else {
+		resultOrm = resultOrm.Limit(pagesize)
+	}
+
+	if slices.Contains(userOrderFields, order.Field) {
+		orderCol, ok := userQuery.GetFieldByName(order.Field)
+		if ok {
+			if order.Desc {
+				resultOrm = resultOrm.Order(orderCol.Desc())
+			} else {
+				resultOrm = resultOrm.Order(orderCol)
+			}
+		}
+	}
+
+	resp, err := resultOrm.Find()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return resp, totalRecords, nil
+}
+
+// @Summary		Get a single user
+// @Description	Get details of a specific user by ID
+// @Tags			User
+// @Accept			json
+// @Produce		json
+// @Param			id	path	int	true	"User ID"
+// @Security		Bearer
+// @Success		200	{object}	successResponse{data=userResp}
+// @Failure		400	{object}	problem.Details
+// @Failure		403	{object}	problem.Details
+// @Failure		404	{object}	problem.Details
+// @Failure		500	{object}	problem.Details
+// @Router			/user/{id} [get]
+func GetSingleUser(c *gin.Context) {
+	user, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+
+	roles, err := userRoleNames(user.ID)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   newUserResp(user, roles),
+	})
+}
+
+// @Summary		Update an existing user
+// @Description	Update the email, password or roles of a user. Empty fields are left unchanged,
+// @Description	a non-empty roles list replaces the roles of the user.
+// @Tags			User
+// @Accept			json
+// @Produce		json
+// @Param			id		path	int				true	"User ID"
+// @Param			input	body	updateUserReq	true	"Updated user details"
+// @Security		Bearer
+// @Success		200	{object}	successResponse{data=userResp}
+// @Failure		400	{object}	problem.Details
+// @Failure		403	{object}	problem.Details
+// @Failure		404	{object}	problem.Details
+// @Failure		409	{object}	problem.Details
+// @Failure		500	{object}	problem.Details
+// @Router			/user/{id} [put]
+func UpdateUser(c *gin.Context) {
+	var input updateUserReq
+	if !bindJSON(c, &input) {
+		return
+	}
+
+	user, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+
+	var roles []*model.Role
+	if len(input.Roles) > 0 {
+		var err error
+		roles, err = findRoles(input.Roles)
+		if err != nil {
+			if errors.Is(err, errUnknownRole) {
+				problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, err.Error())
+				return
+			}
+			problem.Error(c, fmt.Errorf("cannot find roles: %w", err))
+			return
+		}
+	}
+
+	if input.Email != "" && input.Email != user.Email {
+		taken, err := emailTaken(input.Email, user.ID)
+		if err != nil {
+			problem.Error(c, fmt.Errorf("cannot update user: %w", err))
+			return
+		}
+		if taken {
+			problem.Write(c, http.StatusConflict, problem.CodeConflict, "email is already in use")
+			return
+		}
+		user.Email = input.Email
+	}
+
+	passwordChanged := input.Password != ""
+	oldHash := user.Password
+	if passwordChanged {
+		if rejectWeakPassword(c, input.Password, user.Email, user.ID) {
+			return
+		}
+		hashedPassword, err := hashPassword(input.Password)
+		if err != nil {
+			problem.Error(c, fmt.Errorf("cannot hash password: %w", err))
+			return
+		}
+		user.Password = hashedPassword
+	}
+
+	err := dal.Q.Transaction(func(tx *dal.Query) error {
+		if _, err := tx.User.Where(tx.User.ID.Eq(user.ID)).Updates(&model.User{
+			Email:    user.Email,
+			Password: user.Password,
+		}); err != nil {
+			return err
+		}
+		if passwordChanged {
+			if err := savePasswordHistory(tx, user.ID, oldHash); err != nil {
+				return err
+			}
+			if err := revokeUserRefreshTokens(tx, user.ID); err != nil {
+				return err
+			}
+		}
+		if roles == nil {
+			return nil
+		}
+		if _, err := tx.UserRole.Where(tx.UserRole.UserID.Eq(user.ID)).Delete(); err != nil {
+			return err
+		}
+		return assignRoles(tx, user.ID, roles)
+	})
+	if err != nil {
+		problem.Error(c, fmt.Errorf("cannot update user: %w", err))
+		return
+	}
+
+	roleNames, err := userRoleNames(user.ID)
+	if err != nil {
+		problem.Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+		Data:   newUserResp(user, roleNames),
+	})
+}
+
+// @Summary		Disable a user
+// @Description	Disabled users cannot log in or refresh their tokens
+// @Tags			User
+// @Accept			json
+// @Produce		json
+// @Param			id	path	int	true	"User ID"
+// @Security		Bearer
+// @Success		200	{object}	successResponse
+// @Failure		400	{object}	problem.Details
+// @Failure		403	{object}	problem.Details
+// @Failure		404	{object}	problem.Details
+// @Failure		500	{object}	problem.Details
+// @Router			/user/{id}/disable [post]
+func DisableUser(c *gin.Context) {
+	setUserDisabled(c, true)
+}
+
+// @Summary		Enable a user
+// @Description	Allows a disabled user to log in again
+// @Tags			User
+// @Accept			json
+// @Produce		json
+// @Param			id	path	int	true	"User ID"
+// @Security		Bearer
+// @Success		200	{object}	successResponse
+// @Failure		400	{object}	problem.Details
+// @Failure		403	{object}	problem.Details
+// @Failure		404	{object}	problem.Details
+// @Failure		500	{object}	problem.Details
+// @Router			/user/{id}/enable [post]
+func EnableUser(c *gin.Context) {
+	setUserDisabled(c, false)
+}
+
+func setUserDisabled(c *gin.Context, disabled bool) {
+	user, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+
+	if disabled && isCurrentUser(c, user.ID) {
+		problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, "you cannot disable yourself")
+		return
+	}
+
+	err := dal.Q.Transaction(func(tx *dal.Query) error {
+		if !disabled {
+			_, err := tx.User.Where(tx.User.ID.Eq(user.ID)).Update(tx.User.DisabledAt, nil)
+			return err
+		}
+		if _, err := tx.User.Where(tx.User.ID.Eq(user.ID)).Update(tx.User.DisabledAt, time.Now()); err != nil {
+			return err
+		}
+		return revokeUserRefreshTokens(tx, user.ID)
+	})
+	if err != nil {
+		problem.Error(c, fmt.Errorf("cannot update user: %w", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, successResponse{
+		Status: successStatus,
+	})
+}
+
+// @Summary		Unlock a user
+// @Description	Lifts the temporary lock placed on an account after too many failed logins
+// @Description	and forgets the failures recorded for its email.
+// @Tags			User
+// @Accept			json
+// @Produce		json
+// @Param			id	path	int	true	"User ID"
+// @Security		Bearer
+// @Success		200	{object}	successResponse
+// @Failure		400	{object}	problem.Details
+// @Failure		403	{object}	problem.Details
+// @Failure		404	{object}	problem.Details
+// @Failure		500	{object}	problem.Details
+// @Router			/user/{id}/unlock [post]
+func UnlockUser(guard *loginguard.Guard) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := findUserParam(c)
+		if !ok {
+			return
+		}
+
+		if _, err := dal.User.Where(dal.User.ID.Eq(user.ID)).UpdateSimple(
+			dal.User.FailedLoginAttempts.Value(0),
+			dal.User.LockedUntil.Null(),
+		); err != nil {
+			problem.Error(c, fmt.Errorf("cannot unlock user: %w", err))
+			return
+		}
+		guard.Reset(user.Email)
+
+		c.JSON(http.StatusOK, successResponse{
+			Status: successStatus,
 		})
+	}
+}
+
+// @Summary		Delete a user
+// @Description	Delete a user by ID together with their roles, refresh tokens, sessions, password
+// @Description	history, recovery codes and password reset tokens
+// @Tags			User
+// @Accept			json
+// @Produce		json
+// @Param			id	path	int	true	"User ID"
+// @Security		Bearer
+// @Success		200	{object}	successResponse
+// @Failure		400	{object}	problem.Details
+// @Failure		403	{object}	problem.Details
+// @Failure		404	{object}	problem.Details
+// @Failure		500	{object}	problem.Details
+// @Router			/user/{id} [delete]
+func DeleteUser(c *gin.Context) {
+	user, ok := findUserParam(c)
+	if !ok {
+		return
+	}
+
+	if isCurrentUser(c, user.ID) {
+		problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, "you cannot delete yourself")
+		return
+	}
+
+	err := dal.Q.Transaction(func(tx *dal.Query) error {
+		if _, err := tx.UserRole.Where(tx.UserRole.UserID.Eq(user.ID)).Delete(); err != nil {
+			return err
+		}
+		if _, err := tx.RefreshToken.Where(tx.RefreshToken.UserID.Eq(user.ID)).Delete(); err != nil {
+			return err
+		}
+		if _, err := tx.Session.Where(tx.Session.UserID.Eq(user.ID)).Delete(); err != nil {
+			return err
+		}
+		if _, err := tx.PasswordHistory.Where(tx.PasswordHistory.UserID.Eq(user.ID)).Delete(); err != nil {
+			return err
+		}
+		if _, err := tx.RecoveryCode.Where(tx.RecoveryCode.UserID.Eq(user.ID)).Delete(); err != nil {
+			return err
+		}
+		if _, err := tx.PasswordReset.Where(tx.PasswordReset.UserID.Eq(user.ID)).Delete(); err != nil {
+			return err
+		}
+		_, err := tx.User.Where(tx.User.ID.Eq(user.ID)).Delete()
+		return err
+	})
+	if err != nil {
+		problem.Error(c, fmt.Errorf("cannot delete user: %w", err))
 		return
 	}
 
@@ -45,3 +559,255 @@ func CreateUser(c *gin.Context) {
 		Status: successStatus,
 	})
 }
+
+// @Summary		Change my password
+// @Description	Changes the password of the logged in user. The current password is required
+// @Description	and all sessions of the user, including the current one, are ended. A wrong current
+// @Description	password counts as a failed login and is throttled the same way.
+// @Tags			User
+// @Accept			json
+// @Produce		json
+// @Param			input	body	changePasswordReq	true	"Current and new password"
+// @Security		Bearer
+// @Success		200	{object}	successResponse
+// @Failure		400	{object}	problem.Details
+// @Failure		401	{object}	problem.Details
+// @Failure		423	{object}	problem.Details
+// @Failure		429	{object}	problem.Details
+// @Failure		500	{object}	problem.Details
+// @Router			/user/me/password [put]
+func ChangeOwnPasswordHandler(guard *loginguard.Guard) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var input changePasswordReq
+		if !bindJSON(c, &input) {
+			return
+		}
+
+		user, ok := currentUser(c)
+		if !ok {
+			return
+		}
+
+		if rejectThrottledLogin(c, guard, user.Email) || rejectLockedAccount(c, user) {
+			return
+		}
+
+		if !password.Verify(user.Password, input.CurrentPassword) {
+			registerLoginFailure(c, guard, user, failureWrongPassword)
+			problem.Write(c, http.StatusBadRequest, problem.CodeInvalidCredentials, "current password is incorrect")
+			return
+		}
+
+		if rejectWeakPassword(c, input.NewPassword, user.Email, user.ID) {
+			return
+		}
+
+		hashedPassword, err := hashPassword(input.NewPassword)
+		if err != nil {
+			problem.Error(c, fmt.Errorf("cannot hash password: %w", err))
+			return
+		}
+
+		err = dal.Q.Transaction(func(tx *dal.Query) error {
+			if _, err := tx.User.Where(tx.User.ID.Eq(user.ID)).Update(tx.User.Password, hashedPassword); err != nil {
+				return err
+			}
+			if err := savePasswordHistory(tx, user.ID, user.Password); err != nil {
+				return err
+			}
+			return revokeUserRefreshTokens(tx, user.ID)
+		})
+		if err != nil {
+			problem.Error(c, fmt.Errorf("cannot change password: %w", err))
+			return
+		}
+
+		c.JSON(http.StatusOK, successResponse{
+			Status: successStatus,
+		})
+	}
+}
+
+// findUserParam loads the user addressed by the :id path parameter.
+// It writes the error response itself and returns false when the user cannot be loaded.
+func findUserParam(c *gin.Context) (*model.User, bool) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid id")
+		return nil, false
+	}
+
+	user, err := dal.User.Where(dal.User.ID.Eq(int32(userID))).First()
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			problem.Error(c, err)
+			return nil, false
+		}
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "user not found")
+		return nil, false
+	}
+
+	return user, true
+}
+
+func isCurrentUser(c *gin.Context, userID int32) bool {
+	currentID, err := currentUserID(c)
+	return err == nil && currentID == userID
+}
+
+// emailTaken reports whether another user than exceptID already uses the email.
+func emailTaken(email string, exceptID int32) (bool, error) {
+	count, err := dal.User.Where(dal.User.Email.Eq(email), dal.User.ID.Neq(exceptID)).Count()
+	return count > 0, err
+}
+
+// hashPassword hashes a new password with the configured algorithm and cost.
+func hashPassword(plain string) (string, error) {
+	return password.HasherFromEnv().Hash(plain)
+}
+
+// rejectWeakPassword answers with 400 when a new password breaks the password policy.
+// userID is 0 for users that do not exist yet, otherwise their recent passwords cannot be reused.
+func rejectWeakPassword(c *gin.Context, plain, email string, userID int32) bool {
+	err := checkNewPassword(plain, email, userID)
+	if err == nil {
+		return false
+	}
+
+	var policyErr *password.PolicyError
+	if errors.As(err, &policyErr) {
+		problem.Write(c, http.StatusBadRequest, problem.CodeBadRequest, policyErr.Error())
+		return true
+	}
+	problem.Error(c, fmt.Errorf("cannot check password: %w", err))
+	return true
+}
+
+func checkNewPassword(plain, email string, userID int32) error {
+	policy := password.PolicyFromEnv()
+	if err := policy.Check(plain, email); err != nil {
+		return err
+	}
+	if userID == 0 || policy.History == 0 {
+		return nil
+	}
+
+	user, err := dal.User.Where(dal.User.ID.Eq(userID)).First()
+	if err != nil {
+		return err
+	}
+	hashes := []string{user.Password}
+
+	history, err := dal.PasswordHistory.Where(dal.PasswordHistory.UserID.Eq(userID)).
+		Order(dal.PasswordHistory.ID.Desc()).
+		Limit(policy.History).
+		Find()
+	if err != nil {
+		return err
+	}
+	for _, entry := range history {
+		hashes = append(hashes, entry.PasswordHash)
+	}
+
+	for _, hash := range hashes {
+		if hash != "" && password.Verify(hash, plain) {
+			return &password.PolicyError{Violations: []string{"must not be one of your recent passwords"}}
+		}
+	}
+	return nil
+}
+
+// savePasswordHistory remembers the hash a password change replaces and forgets the
+// entries that are too old for the policy to check.
+func savePasswordHistory(tx *dal.Query, userID int32, oldHash string) error {
+	history := password.PolicyFromEnv().History
+	if oldHash == "" || history == 0 {
+		return nil
+	}
+
+	if err := tx.PasswordHistory.Create(&model.PasswordHistory{
+		UserID:       userID,
+		PasswordHash: oldHash,
+		CreatedAt:    time.Now(),
+	}); err != nil {
+		return err
+	}
+
+	expired, err := tx.PasswordHistory.Where(tx.PasswordHistory.UserID.Eq(userID)).
+		Order(tx.PasswordHistory.ID.Desc()).
+		Offset(history).
+		Find()
+	if err != nil || len(expired) == 0 {
+		return err
+	}
+	_, err = tx.PasswordHistory.Delete(expired...)
+	return err
+}
+
+// findRoles looks up roles by name and fails with errUnknownRole if any of them does not exist.
+func findRoles(names []string) ([]*model.Role, error) {
+	roles, err := dal.Role.Where(dal.Role.Name.In(names...)).Find()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		found := false
+		for _, role := range roles {
+			if role.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("%w: %s", errUnknownRole, name)
+		}
+	}
+	return roles, nil
+}
+
+func assignRoles(q *dal.Query, userID int32, roles []*model.Role) error {
+	userRoles := make([]*model.UserRole, 0, len(roles))
+	for _, role := range roles {
+		userRoles = append(userRoles, &model.UserRole{
+			UserID: userID,
+			RoleID: role.ID,
+		})
+	}
+	return q.UserRole.Create(userRoles...)
+}
+
+// userRoleNames returns the names of the roles granted to the user.
+func userRoleNames(userID int32) ([]string, error) {
+	roles, err := rolesByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return roles[userID], nil
+}
+
+// rolesByUser returns the role names of each of the given users.
+func rolesByUser(userIDs ...int32) (map[int32][]string, error) {
+	roles := make(map[int32][]string, len(userIDs))
+	if len(userIDs) == 0 {
+		return roles, nil
+	}
+
+	var rows []struct {
+		UserID int32
+		Name   string
+	}
+	err := dal.UserRole.
+		Select(dal.UserRole.UserID, dal.Role.Name).
+		Join(dal.Role, dal.Role.ID.EqCol(dal.UserRole.RoleID)).
+		Where(dal.UserRole.UserID.In(userIDs...)).
+		Scan(&rows)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		roles[row.UserID] = append(roles[row.UserID], row.Name)
+	}
+	return roles, nil
+}
@@ -31,6 +31,10 @@ func newCustomer(db *gorm.DB, opts ...gen.DOOption) customer {
 	_customer.Name = field.NewString(tableName, "name")
 	_customer.Email = field.NewString(tableName, "email")
 	_customer.Phone = field.NewString(tableName, "phone")
+	_customer.CreatedBy = field.NewInt32(tableName, "created_by")
+	_customer.UpdatedBy = field.NewInt32(tableName, "updated_by")
+	_customer.DeletedAt = field.NewField(tableName, "deleted_at")
+	_customer.Attributes = field.NewField(tableName, "attributes")
 
 	_customer.fillFieldMap()
 
@@ -40,11 +44,15 @@ func newCustomer(db *gorm.DB, opts ...gen.DOOption) customer {
 type customer struct {
 	customerDo
 
-	ALL   field.Asterisk
-	ID    field.Int32
-	Name  field.String
-	Email field.String
-	Phone field.String
+	ALL        field.Asterisk
+	ID         field.Int32
+	Name       field.String
+	Email      field.String
+	Phone      field.String
+	CreatedBy  field.Int32
+	UpdatedBy  field.Int32
+	DeletedAt  field.Field
+	Attributes field.Field
 
 	fieldMap map[string]field.Expr
 }
@@ -65,6 +73,10 @@ func (c *customer) updateTableName(table string) *customer {
 	c.Name = field.NewString(table, "name")
 	c.Email = field.NewString(table, "email")
 	c.Phone = field.NewString(table, "phone")
+	c.CreatedBy = field.NewInt32(table, "created_by")
+	c.UpdatedBy = field.NewInt32(table, "updated_by")
+	c.DeletedAt = field.NewField(table, "deleted_at")
+	c.Attributes = field.NewField(table, "attributes")
 
 	c.fillFieldMap()
 
@@ -81,11 +93,15 @@ func (c *customer) GetFieldByName(fieldName string) (field.OrderExpr, bool) {
 }
 
 func (c *customer) fillFieldMap() {
-	c.fieldMap = make(map[string]field.Expr, 4)
+	c.fieldMap = make(map[string]field.Expr, 8)
 	c.fieldMap["id"] = c.ID
 	c.fieldMap["name"] = c.Name
 	c.fieldMap["email"] = c.Email
 	c.fieldMap["phone"] = c.Phone
+	c.fieldMap["created_by"] = c.CreatedBy
+	c.fieldMap["updated_by"] = c.UpdatedBy
+	c.fieldMap["deleted_at"] = c.DeletedAt
+	c.fieldMap["attributes"] = c.Attributes
 }
 
 func (c customer) clone(db *gorm.DB) customer {
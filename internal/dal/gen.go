@@ -16,49 +16,119 @@ import (
 )
 
 var (
-	Q        = new(Query)
-	Customer *customer
-	LoginLog *loginLog
-	Order    *order
-	User     *user
+	Q                 = new(Query)
+	APIKey            *aPIKey
+	Address           *address
+	Customer          *customer
+	CustomerAttribute *customerAttribute
+	CustomerMerge     *customerMerge
+	CustomerTag       *customerTag
+	LoginLog          *loginLog
+	Order             *order
+	PasswordHistory   *passwordHistory
+	PasswordReset     *passwordReset
+	RecoveryCode      *recoveryCode
+	RefreshToken      *refreshToken
+	RevokedToken      *revokedToken
+	Role              *role
+	Session           *session
+	Tag               *tag
+	User              *user
+	UserRole          *userRole
 )
 
 func SetDefault(db *gorm.DB, opts ...gen.DOOption) {
 	*Q = *Use(db, opts...)
+	APIKey = &Q.APIKey
+	Address = &Q.Address
 	Customer = &Q.Customer
+	CustomerAttribute = &Q.CustomerAttribute
+	CustomerMerge = &Q.CustomerMerge
+	CustomerTag = &Q.CustomerTag
 	LoginLog = &Q.LoginLog
 	Order = &Q.Order
+	PasswordHistory = &Q.PasswordHistory
+	PasswordReset = &Q.PasswordReset
+	RecoveryCode = &Q.RecoveryCode
+	RefreshToken = &Q.RefreshToken
+	RevokedToken = &Q.RevokedToken
+	Role = &Q.Role
+	Session = &Q.Session
+	Tag = &Q.Tag
 	User = &Q.User
+	UserRole = &Q.UserRole
 }
 
 func Use(db *gorm.DB, opts ...gen.DOOption) *Query {
 	return &Query{
-		db:       db,
-		Customer: newCustomer(db, opts...),
-		LoginLog: newLoginLog(db, opts...),
-		Order:    newOrder(db, opts...),
-		User:     newUser(db, opts...),
+		db:                db,
+		APIKey:            newAPIKey(db, opts...),
+		Address:           newAddress(db, opts...),
+		Customer:          newCustomer(db, opts...),
+		CustomerAttribute: newCustomerAttribute(db, opts...),
+		CustomerMerge:     newCustomerMerge(db, opts...),
+		CustomerTag:       newCustomerTag(db, opts...),
+		LoginLog:          newLoginLog(db, opts...),
+		Order:             newOrder(db, opts...),
+		PasswordHistory:   newPasswordHistory(db, opts...),
+		PasswordReset:     newPasswordReset(db, opts...),
+		RecoveryCode:      newRecoveryCode(db, opts...),
+		RefreshToken:      newRefreshToken(db, opts...),
+		RevokedToken:      newRevokedToken(db, opts...),
+		Role:              newRole(db, opts...),
+		Session:           newSession(db, opts...),
+		Tag:               newTag(db, opts...),
+		User:              newUser(db, opts...),
+		UserRole:          newUserRole(db, opts...),
 	}
 }
 
 type Query struct {
 	db *gorm.DB
 
-	Customer customer
-	LoginLog loginLog
-	Order    order
-	User     user
+	APIKey            aPIKey
+	Address           address
+	Customer          customer
+	CustomerAttribute customerAttribute
+	CustomerMerge     customerMerge
+	CustomerTag       customerTag
+	LoginLog          loginLog
+	Order             order
+	PasswordHistory   passwordHistory
+	PasswordReset     passwordReset
+	RecoveryCode      recoveryCode
+	RefreshToken      refreshToken
+	RevokedToken      revokedToken
+	Role              role
+	Session           session
+	Tag               tag
+	User              user
+	UserRole          userRole
 }
 
 func (q *Query) Available() bool { return q.db != nil }
 
 func (q *Query) clone(db *gorm.DB) *Query {
 	return &Query{
-		db:       db,
-		Customer: q.Customer.clone(db),
-		LoginLog: q.LoginLog.clone(db),
-		Order:    q.Order.clone(db),
-		User:     q.User.clone(db),
+		db:                db,
+		APIKey:            q.APIKey.clone(db),
+		Address:           q.Address.clone(db),
+		Customer:          q.Customer.clone(db),
+		CustomerAttribute: q.CustomerAttribute.clone(db),
+		CustomerMerge:     q.CustomerMerge.clone(db),
+		CustomerTag:       q.CustomerTag.clone(db),
+		LoginLog:          q.LoginLog.clone(db),
+		Order:             q.Order.clone(db),
+		PasswordHistory:   q.PasswordHistory.clone(db),
+		PasswordReset:     q.PasswordReset.clone(db),
+		RecoveryCode:      q.RecoveryCode.clone(db),
+		RefreshToken:      q.RefreshToken.clone(db),
+		RevokedToken:      q.RevokedToken.clone(db),
+		Role:              q.Role.clone(db),
+		Session:           q.Session.clone(db),
+		Tag:               q.Tag.clone(db),
+		User:              q.User.clone(db),
+		UserRole:          q.UserRole.clone(db),
 	}
 }
 
@@ -72,27 +142,69 @@ func (q *Query) WriteDB() *Query {
 
 func (q *Query) ReplaceDB(db *gorm.DB) *Query {
 	return &Query{
-		db:       db,
-		Customer: q.Customer.replaceDB(db),
-		LoginLog: q.LoginLog.replaceDB(db),
-		Order:    q.Order.replaceDB(db),
-		User:     q.User.replaceDB(db),
+		db:                db,
+		APIKey:            q.APIKey.replaceDB(db),
+		Address:           q.Address.replaceDB(db),
+		Customer:          q.Customer.replaceDB(db),
+		CustomerAttribute: q.CustomerAttribute.replaceDB(db),
+		CustomerMerge:     q.CustomerMerge.replaceDB(db),
+		CustomerTag:       q.CustomerTag.replaceDB(db),
+		LoginLog:          q.LoginLog.replaceDB(db),
+		Order:             q.Order.replaceDB(db),
+		PasswordHistory:   q.PasswordHistory.replaceDB(db),
+		PasswordReset:     q.PasswordReset.replaceDB(db),
+		RecoveryCode:      q.RecoveryCode.replaceDB(db),
+		RefreshToken:      q.RefreshToken.replaceDB(db),
+		RevokedToken:      q.RevokedToken.replaceDB(db),
+		Role:              q.Role.replaceDB(db),
+		Session:           q.Session.replaceDB(db),
+		Tag:               q.Tag.replaceDB(db),
+		User:              q.User.replaceDB(db),
+		UserRole:          q.UserRole.replaceDB(db),
 	}
 }
 
 type queryCtx struct {
-	Customer ICustomerDo
-	LoginLog ILoginLogDo
-	Order    IOrderDo
-	User     IUserDo
+	APIKey            IAPIKeyDo
+	Address           IAddressDo
+	Customer          ICustomerDo
+	CustomerAttribute ICustomerAttributeDo
+	CustomerMerge     ICustomerMergeDo
+	CustomerTag       ICustomerTagDo
+	LoginLog          ILoginLogDo
+	Order             IOrderDo
+	PasswordHistory   IPasswordHistoryDo
+	PasswordReset     IPasswordResetDo
+	RecoveryCode      IRecoveryCodeDo
+	RefreshToken      IRefreshTokenDo
+	RevokedToken      IRevokedTokenDo
+	Role              IRoleDo
+	Session           ISessionDo
+	Tag               ITagDo
+	User              IUserDo
+	UserRole          IUserRoleDo
 }
 
 func (q *Query) WithContext(ctx context.Context) *queryCtx {
 	return &queryCtx{
-		Customer: q.Customer.WithContext(ctx),
-		LoginLog: q.LoginLog.WithContext(ctx),
-		Order:    q.Order.WithContext(ctx),
-		User:     q.User.WithContext(ctx),
+		APIKey:            q.APIKey.WithContext(ctx),
+		Address:           q.Address.WithContext(ctx),
+		Customer:          q.Customer.WithContext(ctx),
+		CustomerAttribute: q.CustomerAttribute.WithContext(ctx),
+		CustomerMerge:     q.CustomerMerge.WithContext(ctx),
+		CustomerTag:       q.CustomerTag.WithContext(ctx),
+		LoginLog:          q.LoginLog.WithContext(ctx),
+		Order:             q.Order.WithContext(ctx),
+		PasswordHistory:   q.PasswordHistory.WithContext(ctx),
+		PasswordReset:     q.PasswordReset.WithContext(ctx),
+		RecoveryCode:      q.RecoveryCode.WithContext(ctx),
+		RefreshToken:      q.RefreshToken.WithContext(ctx),
+		RevokedToken:      q.RevokedToken.WithContext(ctx),
+		Role:              q.Role.WithContext(ctx),
+		Session:           q.Session.WithContext(ctx),
+		Tag:               q.Tag.WithContext(ctx),
+		User:              q.User.WithContext(ctx),
+		UserRole:          q.UserRole.WithContext(ctx),
 	}
 }
 
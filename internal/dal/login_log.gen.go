@@ -30,6 +30,12 @@ func newLoginLog(db *gorm.DB, opts ...gen.DOOption) loginLog {
 	_loginLog.ID = field.NewInt32(tableName, "id")
 	_loginLog.UserID = field.NewInt32(tableName, "user_id")
 	_loginLog.LoginTime = field.NewTime(tableName, "login_time")
+	_loginLog.Email = field.NewString(tableName, "email")
+	_loginLog.Status = field.NewString(tableName, "status")
+	_loginLog.FailureReason = field.NewString(tableName, "failure_reason")
+	_loginLog.IP = field.NewString(tableName, "ip")
+	_loginLog.UserAgent = field.NewString(tableName, "user_agent")
+	_loginLog.TokenID = field.NewString(tableName, "token_id")
 
 	_loginLog.fillFieldMap()
 
@@ -39,10 +45,16 @@ func newLoginLog(db *gorm.DB, opts ...gen.DOOption) loginLog {
 type loginLog struct {
 	loginLogDo
 
-	ALL       field.Asterisk
-	ID        field.Int32
-	UserID    field.Int32
-	LoginTime field.Time
+	ALL           field.Asterisk
+	ID            field.Int32
+	UserID        field.Int32
+	LoginTime     field.Time
+	Email         field.String
+	Status        field.String
+	FailureReason field.String
+	IP            field.String
+	UserAgent     field.String
+	TokenID       field.String
 
 	fieldMap map[string]field.Expr
 }
@@ -62,6 +74,12 @@ func (l *loginLog) updateTableName(table string) *loginLog {
 	l.ID = field.NewInt32(table, "id")
 	l.UserID = field.NewInt32(table, "user_id")
 	l.LoginTime = field.NewTime(table, "login_time")
+	l.Email = field.NewString(table, "email")
+	l.Status = field.NewString(table, "status")
+	l.FailureReason = field.NewString(table, "failure_reason")
+	l.IP = field.NewString(table, "ip")
+	l.UserAgent = field.NewString(table, "user_agent")
+	l.TokenID = field.NewString(table, "token_id")
 
 	l.fillFieldMap()
 
@@ -78,10 +96,16 @@ func (l *loginLog) GetFieldByName(fieldName string) (field.OrderExpr, bool) {
 }
 
 func (l *loginLog) fillFieldMap() {
-	l.fieldMap = make(map[string]field.Expr, 3)
+	l.fieldMap = make(map[string]field.Expr, 9)
 	l.fieldMap["id"] = l.ID
 	l.fieldMap["user_id"] = l.UserID
 	l.fieldMap["login_time"] = l.LoginTime
+	l.fieldMap["email"] = l.Email
+	l.fieldMap["status"] = l.Status
+	l.fieldMap["failure_reason"] = l.FailureReason
+	l.fieldMap["ip"] = l.IP
+	l.fieldMap["user_agent"] = l.UserAgent
+	l.fieldMap["token_id"] = l.TokenID
 }
 
 func (l loginLog) clone(db *gorm.DB) loginLog {
@@ -31,6 +31,12 @@ func newOrder(db *gorm.DB, opts ...gen.DOOption) order {
 	_order.OrderDate = field.NewTime(tableName, "orderDate")
 	_order.Amount = field.NewFloat64(tableName, "amount")
 	_order.CustomerID = field.NewInt32(tableName, "customerId")
+	_order.CreatedBy = field.NewInt32(tableName, "createdBy")
+	_order.UpdatedBy = field.NewInt32(tableName, "updatedBy")
+	_order.ShippingAddressID = field.NewInt32(tableName, "shippingAddressId")
+	_order.ShippingAddress = field.NewField(tableName, "shippingAddress")
+	_order.BillingAddressID = field.NewInt32(tableName, "billingAddressId")
+	_order.BillingAddress = field.NewField(tableName, "billingAddress")
 
 	_order.fillFieldMap()
 
@@ -40,11 +46,17 @@ func newOrder(db *gorm.DB, opts ...gen.DOOption) order {
 type order struct {
 	orderDo
 
-	ALL        field.Asterisk
-	ID         field.Int32
-	OrderDate  field.Time
-	Amount     field.Float64
-	CustomerID field.Int32
+	ALL               field.Asterisk
+	ID                field.Int32
+	OrderDate         field.Time
+	Amount            field.Float64
+	CustomerID        field.Int32
+	CreatedBy         field.Int32
+	UpdatedBy         field.Int32
+	ShippingAddressID field.Int32
+	ShippingAddress   field.Field
+	BillingAddressID  field.Int32
+	BillingAddress    field.Field
 
 	fieldMap map[string]field.Expr
 }
@@ -65,6 +77,12 @@ func (o *order) updateTableName(table string) *order {
 	o.OrderDate = field.NewTime(table, "orderDate")
 	o.Amount = field.NewFloat64(table, "amount")
 	o.CustomerID = field.NewInt32(table, "customerId")
+	o.CreatedBy = field.NewInt32(table, "createdBy")
+	o.UpdatedBy = field.NewInt32(table, "updatedBy")
+	o.ShippingAddressID = field.NewInt32(table, "shippingAddressId")
+	o.ShippingAddress = field.NewField(table, "shippingAddress")
+	o.BillingAddressID = field.NewInt32(table, "billingAddressId")
+	o.BillingAddress = field.NewField(table, "billingAddress")
 
 	o.fillFieldMap()
 
@@ -81,11 +99,17 @@ func (o *order) GetFieldByName(fieldName string) (field.OrderExpr, bool) {
 }
 
 func (o *order) fillFieldMap() {
-	o.fieldMap = make(map[string]field.Expr, 4)
+	o.fieldMap = make(map[string]field.Expr, 10)
 	o.fieldMap["id"] = o.ID
 	o.fieldMap["orderDate"] = o.OrderDate
 	o.fieldMap["amount"] = o.Amount
 	o.fieldMap["customerId"] = o.CustomerID
+	o.fieldMap["createdBy"] = o.CreatedBy
+	o.fieldMap["updatedBy"] = o.UpdatedBy
+	o.fieldMap["shippingAddressId"] = o.ShippingAddressID
+	o.fieldMap["shippingAddress"] = o.ShippingAddress
+	o.fieldMap["billingAddressId"] = o.BillingAddressID
+	o.fieldMap["billingAddress"] = o.BillingAddress
 }
 
 func (o order) clone(db *gorm.DB) order {
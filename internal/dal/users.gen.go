@@ -30,6 +30,16 @@ func newUser(db *gorm.DB, opts ...gen.DOOption) user {
 	_user.ID = field.NewInt32(tableName, "id")
 	_user.Email = field.NewString(tableName, "email")
 	_user.Password = field.NewString(tableName, "password")
+	_user.DisabledAt = field.NewTime(tableName, "disabled_at")
+	_user.CreatedAt = field.NewTime(tableName, "created_at")
+	_user.TotpSecret = field.NewString(tableName, "totp_secret")
+	_user.TotpEnabledAt = field.NewTime(tableName, "totp_enabled_at")
+	_user.FailedLoginAttempts = field.NewInt32(tableName, "failed_login_attempts")
+	_user.LockedUntil = field.NewTime(tableName, "locked_until")
+	_user.OidcIssuer = field.NewString(tableName, "oidc_issuer")
+	_user.OidcSubject = field.NewString(tableName, "oidc_subject")
+	_user.EmailVerifiedAt = field.NewTime(tableName, "email_verified_at")
+	_user.TotpLastStep = field.NewInt64(tableName, "totp_last_step")
 
 	_user.fillFieldMap()
 
@@ -39,10 +49,20 @@ func newUser(db *gorm.DB, opts ...gen.DOOption) user {
 type user struct {
 	userDo
 
-	ALL      field.Asterisk
-	ID       field.Int32
-	Email    field.String
-	Password field.String
+	ALL                 field.Asterisk
+	ID                  field.Int32
+	Email               field.String
+	Password            field.String
+	DisabledAt          field.Time
+	CreatedAt           field.Time
+	TotpSecret          field.String
+	TotpEnabledAt       field.Time
+	FailedLoginAttempts field.Int32
+	LockedUntil         field.Time
+	OidcIssuer          field.String
+	OidcSubject         field.String
+	EmailVerifiedAt     field.Time
+	TotpLastStep        field.Int64
 
 	fieldMap map[string]field.Expr
 }
@@ -62,6 +82,16 @@ func (u *user) updateTableName(table string) *user {
 	u.ID = field.NewInt32(table, "id")
 	u.Email = field.NewString(table, "email")
 	u.Password = field.NewString(table, "password")
+	u.DisabledAt = field.NewTime(table, "disabled_at")
+	u.CreatedAt = field.NewTime(table, "created_at")
+	u.TotpSecret = field.NewString(table, "totp_secret")
+	u.TotpEnabledAt = field.NewTime(table, "totp_enabled_at")
+	u.FailedLoginAttempts = field.NewInt32(table, "failed_login_attempts")
+	u.LockedUntil = field.NewTime(table, "locked_until")
+	u.OidcIssuer = field.NewString(table, "oidc_issuer")
+	u.OidcSubject = field.NewString(table, "oidc_subject")
+	u.EmailVerifiedAt = field.NewTime(table, "email_verified_at")
+	u.TotpLastStep = field.NewInt64(table, "totp_last_step")
 
 	u.fillFieldMap()
 
@@ -78,10 +108,20 @@ func (u *user) GetFieldByName(fieldName string) (field.OrderExpr, bool) {
 }
 
 func (u *user) fillFieldMap() {
-	u.fieldMap = make(map[string]field.Expr, 3)
+	u.fieldMap = make(map[string]field.Expr, 13)
 	u.fieldMap["id"] = u.ID
 	u.fieldMap["email"] = u.Email
 	u.fieldMap["password"] = u.Password
+	u.fieldMap["disabled_at"] = u.DisabledAt
+	u.fieldMap["created_at"] = u.CreatedAt
+	u.fieldMap["totp_secret"] = u.TotpSecret
+	u.fieldMap["totp_enabled_at"] = u.TotpEnabledAt
+	u.fieldMap["failed_login_attempts"] = u.FailedLoginAttempts
+	u.fieldMap["locked_until"] = u.LockedUntil
+	u.fieldMap["oidc_issuer"] = u.OidcIssuer
+	u.fieldMap["oidc_subject"] = u.OidcSubject
+	u.fieldMap["email_verified_at"] = u.EmailVerifiedAt
+	u.fieldMap["totp_last_step"] = u.TotpLastStep
 }
 
 func (u user) clone(db *gorm.DB) user {
@@ -0,0 +1,186 @@
+// Package dedupe finds customers that are likely the same person.
+package dedupe
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Reasons why two customers are considered duplicates.
+const (
+	ReasonEmail = "email"
+	ReasonPhone = "phone"
+	ReasonName  = "name"
+)
+
+// phoneKeyDigits is how many trailing digits of a phone number are compared, so that
+// numbers written with and without a country or trunk prefix match.
+const phoneKeyDigits = 9
+
+// minPhoneDigits is the shortest phone number that is compared at all.
+const minPhoneDigits = 6
+
+// Candidate is a customer to compare.
+type Candidate struct {
+	ID    int32
+	Name  string
+	Email string
+	Phone string
+}
+
+// Match is a pair of likely duplicates. CustomerID is the older customer, which is
+// the suggested survivor of a merge.
+type Match struct {
+	CustomerID  int32    `json:"customer_id"`
+	DuplicateID int32    `json:"duplicate_id"`
+	Reasons     []string `json:"reasons"`
+	// NameScore is the similarity of the names from 0 to 1.
+	NameScore float64 `json:"name_score"`
+}
+
+// NormalizeEmail lowercases an email and removes a "+tag" from its local part.
+func NormalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok || local == "" || domain == "" {
+		return ""
+	}
+	if tag := strings.IndexByte(local, '+'); tag > 0 {
+		local = local[:tag]
+	}
+	return local + "@" + domain
+}
+
+// NormalizePhone returns the last digits of a phone number, or "" when it has too few digits.
+func NormalizePhone(phone string) string {
+	var digits []byte
+	for i := 0; i < len(phone); i++ {
+		if phone[i] >= '0' && phone[i] <= '9' {
+			digits = append(digits, phone[i])
+		}
+	}
+	if len(digits) < minPhoneDigits {
+		return ""
+	}
+	if len(digits) > phoneKeyDigits {
+		digits = digits[len(digits)-phoneKeyDigits:]
+	}
+	return string(digits)
+}
+
+// NormalizeName lowercases a name, drops punctuation and sorts its words,
+// so "Doe, John" and "john doe" are the same.
+func NormalizeName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// NameSimilarity returns how similar two names are from 0 to 1, based on the edit
+// distance of their normalized forms.
+func NameSimilarity(a, b string) float64 {
+	ra, rb := []rune(NormalizeName(a)), []rune(NormalizeName(b))
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 0
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// Find returns the pairs of candidates with the same normalized email or phone, or with
+// names at least nameThreshold similar. Names are only compared within the same block of
+// their first letters, which keeps the comparison from growing with the square of all customers.
+func Find(candidates []Candidate, nameThreshold float64) []Match {
+	matches := map[[2]int32]*Match{}
+	add := func(a, b Candidate, reason string) {
+		if a.ID > b.ID {
+			a, b = b, a
+		}
+		key := [2]int32{a.ID, b.ID}
+		m, ok := matches[key]
+		if !ok {
+			m = &Match{CustomerID: a.ID, DuplicateID: b.ID, NameScore: NameSimilarity(a.Name, b.Name)}
+			matches[key] = m
+		}
+		for _, r := range m.Reasons {
+			if r == reason {
+				return
+			}
+		}
+		m.Reasons = append(m.Reasons, reason)
+	}
+
+	byEmail := map[string][]Candidate{}
+	byPhone := map[string][]Candidate{}
+	byNameBlock := map[string][]Candidate{}
+	for _, c := range candidates {
+		if key := NormalizeEmail(c.Email); key != "" {
+			byEmail[key] = append(byEmail[key], c)
+		}
+		if key := NormalizePhone(c.Phone); key != "" {
+			byPhone[key] = append(byPhone[key], c)
+		}
+		if name := []rune(NormalizeName(c.Name)); len(name) > 0 {
+			block := string(name[:min(2, len(name))])
+			byNameBlock[block] = append(byNameBlock[block], c)
+		}
+	}
+
+	for _, group := range byEmail {
+		forEachPair(group, func(a, b Candidate) { add(a, b, ReasonEmail) })
+	}
+	for _, group := range byPhone {
+		forEachPair(group, func(a, b Candidate) { add(a, b, ReasonPhone) })
+	}
+	for _, group := range byNameBlock {
+		forEachPair(group, func(a, b Candidate) {
+			if NameSimilarity(a.Name, b.Name) >= nameThreshold {
+				add(a, b, ReasonName)
+			}
+		})
+	}
+
+	result := make([]Match, 0, len(matches))
+	for _, m := range matches {
+		sort.Strings(m.Reasons)
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CustomerID != result[j].CustomerID {
+			return result[i].CustomerID < result[j].CustomerID
+		}
+		return result[i].DuplicateID < result[j].DuplicateID
+	})
+	return result
+}
+
+func forEachPair(group []Candidate, fn func(a, b Candidate)) {
+	for i := 0; i < len(group); i++ {
+		for j := i + 1; j < len(group); j++ {
+			fn(group[i], group[j])
+		}
+	}
+}
+
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,195 @@
+// Package export writes rows to CSV, NDJSON and XLSX streams one row at a time,
+// so a result set of any size can be exported in constant memory.
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats rows can be exported in.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatXLSX   = "xlsx"
+)
+
+// Writer writes the rows of one export. Values may be strings, integers, floats,
+// times and pointers to them; nil pointers are written as empty cells.
+type Writer interface {
+	// Write writes a row with one value per column.
+	Write(values []any) error
+	// Flush sends the rows written so far to the underlying writer.
+	Flush() error
+	// Close finishes the export. The underlying writer is not closed.
+	Close() error
+}
+
+// NewWriter returns a Writer for the format that writes the columns header first.
+func NewWriter(w io.Writer, format string, columns []string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatNDJSON:
+		return &ndjsonWriter{w: w, columns: columns}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w, columns)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// ContentType returns the media type of the format.
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer, columns []string) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w)}
+	if err := cw.w.Write(columns); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *csvWriter) Write(values []any) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = formatCell(v)
+	}
+	return cw.w.Write(record)
+}
+
+func (cw *csvWriter) Flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *csvWriter) Close() error {
+	return cw.Flush()
+}
+
+type ndjsonWriter struct {
+	w       io.Writer
+	columns []string
+}
+
+// Write writes the row as one JSON object with the keys in column order.
+func (nw *ndjsonWriter) Write(values []any) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	buf.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		// Encode ends every value with a newline, which is dropped again.
+		if err := enc.Encode(nw.columns[i]); err != nil {
+			return err
+		}
+		buf.Truncate(buf.Len() - 1)
+		buf.WriteByte(':')
+		if err := enc.Encode(deref(v)); err != nil {
+			return err
+		}
+		buf.Truncate(buf.Len() - 1)
+	}
+	buf.WriteString("}\n")
+	_, err := nw.w.Write(buf.Bytes())
+	return err
+}
+
+func (nw *ndjsonWriter) Flush() error {
+	return nil
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nil
+}
+
+// deref returns the value a pointer points to, or nil for a nil pointer.
+func deref(v any) any {
+	switch v := v.(type) {
+	case *string:
+		if v != nil {
+			return *v
+		}
+	case *int32:
+		if v != nil {
+			return *v
+		}
+	case *int64:
+		if v != nil {
+			return *v
+		}
+	case *float64:
+		if v != nil {
+			return *v
+		}
+	case *time.Time:
+		if v != nil {
+			return *v
+		}
+	default:
+		return v
+	}
+	return nil
+}
+
+// formatValue returns the text of a value, with times in RFC 3339.
+func formatValue(v any) string {
+	switch v := deref(v).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// formulaPrefixes are the first characters that make spreadsheet applications read a
+// cell as a formula.
+const formulaPrefixes = "=+-@\t\r"
+
+// formatCell returns the text of a value for a spreadsheet cell. Text that would be read
+// as a formula, such as a customer named "=HYPERLINK(...)", is prefixed with a quote so
+// that it is shown as text instead.
+func formatCell(v any) string {
+	text := formatValue(v)
+	if _, ok := deref(v).(string); ok && text != "" && strings.ContainsRune(formulaPrefixes, rune(text[0])) {
+		return "'" + text
+	}
+	return text
+}
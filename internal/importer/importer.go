@@ -0,0 +1,135 @@
+// Package importer reads customers from CSV and NDJSON uploads.
+package importer
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formats an upload can be in.
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+)
+
+// maxLineLength is the longest NDJSON line accepted.
+const maxLineLength = 64 * 1024
+
+// Row is one customer of an upload. Line is where it starts in the file, counting from 1.
+type Row struct {
+	Line  int    `json:"line"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+// RowError lists why a row cannot be imported.
+type RowError struct {
+	Line   int      `json:"line"`
+	Errors []string `json:"errors"`
+}
+
+// Read parses an upload in the format. Rows that cannot be parsed are returned as
+// RowErrors; the error is only set when the upload as a whole is unreadable.
+func Read(r io.Reader, format string) ([]Row, []RowError, error) {
+	switch format {
+	case FormatCSV:
+		return ReadCSV(r)
+	case FormatNDJSON:
+		return ReadNDJSON(r)
+	default:
+		return nil, nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// ReadCSV parses a CSV upload. The first line is a header naming the name, email and
+// phone columns in any order; other columns are ignored.
+func ReadCSV(r io.Reader) ([]Row, []RowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil, errors.New("csv header is missing")
+		}
+		return nil, nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range []string{"name", "email", "phone"} {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("csv header has no %s column", name)
+		}
+	}
+
+	var rows []Row
+	var rowErrors []RowError
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, nil, err
+			}
+			rowErrors = append(rowErrors, RowError{Line: parseErr.StartLine, Errors: []string{parseErr.Err.Error()}})
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			if i := columns[name]; i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		rows = append(rows, Row{
+			Line:  line,
+			Name:  field("name"),
+			Email: field("email"),
+			Phone: field("phone"),
+		})
+	}
+	return rows, rowErrors, nil
+}
+
+// ReadNDJSON parses an upload with one JSON object per line. Blank lines are skipped.
+func ReadNDJSON(r io.Reader) ([]Row, []RowError, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineLength)
+
+	var rows []Row
+	var rowErrors []RowError
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var row Row
+		if err := json.Unmarshal([]byte(text), &row); err != nil {
+			rowErrors = append(rowErrors, RowError{Line: line, Errors: []string{"invalid JSON: " + err.Error()}})
+			continue
+		}
+		row.Line = line
+		row.Name = strings.TrimSpace(row.Name)
+		row.Email = strings.TrimSpace(row.Email)
+		row.Phone = strings.TrimSpace(row.Phone)
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return rows, rowErrors, nil
+}
@@ -0,0 +1,253 @@
+// Package jwtkeys holds the keys used to sign and verify our JWTs.
+//
+// Keys are PEM files named <kid>.pem in a local directory. Private keys (RSA or
+// P-256 EC) can sign and verify; public keys only verify, which lets a key be retired
+// from signing while tokens it already issued stay valid. Deleting a file retires
+// the key completely. The directory is reloaded periodically, so keys can be
+// rotated without a restart.
+package jwtkeys
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const keyFileExt = ".pem"
+
+// key is one signing key; private is nil for verify-only keys.
+type key struct {
+	kid     string
+	method  jwt.SigningMethod
+	private interface{}
+	public  interface{}
+}
+
+// KeySet signs tokens with its active key and verifies tokens signed by any of its keys.
+type KeySet struct {
+	dir       string
+	activeKID string
+
+	mu     sync.RWMutex
+	keys   map[string]*key
+	active *key
+
+	done chan struct{}
+	once sync.Once
+}
+
+// New creates a KeySet from the JWT_KEY_DIR env variable, reloading the directory every
+// JWT_KEY_RELOAD_INTERVAL seconds. Tokens are signed with the key JWT_ACTIVE_KID, or with
+// the last private key in file name order when it is unset.
+// Without JWT_KEY_DIR tokens are signed with HS256 and the JWT_SECRET env variable.
+func New() *KeySet {
+	dir := os.Getenv("JWT_KEY_DIR")
+	if dir == "" {
+		return NewHMAC([]byte(os.Getenv("JWT_SECRET")))
+	}
+
+	interval := time.Minute
+	if seconds, err := strconv.Atoi(os.Getenv("JWT_KEY_RELOAD_INTERVAL")); err == nil && seconds > 0 {
+		interval = time.Duration(seconds) * time.Second
+	}
+
+	keys, err := NewDir(dir, os.Getenv("JWT_ACTIVE_KID"), interval)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return keys
+}
+
+// NewHMAC creates a KeySet with a single HS256 secret. Its tokens carry no kid
+// and it publishes no keys, since the secret cannot be shared.
+func NewHMAC(secret []byte) *KeySet {
+	k := &key{
+		method:  jwt.SigningMethodHS256,
+		private: secret,
+		public:  secret,
+	}
+	return &KeySet{
+		keys:   map[string]*key{"": k},
+		active: k,
+		done:   make(chan struct{}),
+	}
+}
+
+// NewDir creates a KeySet from the key files in dir. When interval is positive
+// the directory is reloaded every interval until Close is called.
+func NewDir(dir, activeKID string, interval time.Duration) (*KeySet, error) {
+	s := &KeySet{
+		dir:       dir,
+		activeKID: activeKID,
+		done:      make(chan struct{}),
+	}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	if interval > 0 {
+		go s.reload(interval)
+	}
+	return s, nil
+}
+
+// Reload reads the key directory again. On error the current keys are kept.
+func (s *KeySet) Reload() error {
+	if s.dir == "" {
+		return nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*"+keyFileExt))
+	if err != nil {
+		return err
+	}
+	sort.Strings(paths)
+
+	keys := make(map[string]*key, len(paths))
+	var active *key
+	for _, path := range paths {
+		k, err := loadKey(path)
+		if err != nil {
+			return fmt.Errorf("cannot load JWT key %s: %w", path, err)
+		}
+		keys[k.kid] = k
+		if k.private != nil && (s.activeKID == "" || s.activeKID == k.kid) {
+			active = k
+		}
+	}
+	if active == nil {
+		if s.activeKID != "" {
+			return fmt.Errorf("no private key with kid %q in %s", s.activeKID, s.dir)
+		}
+		return fmt.Errorf("no private key in %s", s.dir)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+	s.active = active
+	return nil
+}
+
+// ActiveKID returns the kid of the key new tokens are signed with.
+func (s *KeySet) ActiveKID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.active.kid
+}
+
+// Sign returns a token with the claims signed by the active key.
+func (s *KeySet) Sign(claims jwt.MapClaims) (string, error) {
+	s.mu.RLock()
+	active := s.active
+	s.mu.RUnlock()
+
+	token := jwt.NewWithClaims(active.method, claims)
+	if active.kid != "" {
+		token.Header["kid"] = active.kid
+	}
+	return token.SignedString(active.private)
+}
+
+// Keyfunc returns the verification key for a token being parsed with jwt.Parse.
+// The token must name a known key in its kid header and use that key's algorithm.
+func (s *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	s.mu.RLock()
+	k, ok := s.keys[kid]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != k.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+	return k.public, nil
+}
+
+// Close stops reloading the key directory.
+func (s *KeySet) Close() error {
+	s.once.Do(func() { close(s.done) })
+	return nil
+}
+
+func (s *KeySet) reload(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			if err := s.Reload(); err != nil {
+				log.Printf("keeping current JWT keys: %v", err)
+			}
+		}
+	}
+}
+
+func loadKey(path string) (*key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("not a PEM file")
+	}
+
+	k := &key{kid: strings.TrimSuffix(filepath.Base(path), keyFileExt)}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		k.private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		k.private, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		k.private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		k.public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch private := k.private.(type) {
+	case *rsa.PrivateKey:
+		k.public = &private.PublicKey
+	case *ecdsa.PrivateKey:
+		k.public = &private.PublicKey
+	case nil:
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", private)
+	}
+
+	switch public := k.public.(type) {
+	case *rsa.PublicKey:
+		k.method = jwt.SigningMethodRS256
+	case *ecdsa.PublicKey:
+		if public.Curve != elliptic.P256() {
+			return nil, errors.New("only P-256 EC keys are supported")
+		}
+		k.method = jwt.SigningMethodES256
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", public)
+	}
+	return k, nil
+}
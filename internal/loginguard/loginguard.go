@@ -0,0 +1,166 @@
+// Package loginguard slows down password guessing by making clients wait longer
+// after every failed login, separately for each account and each client IP.
+package loginguard
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const cleanupInterval = time.Minute
+
+// Guard tracks failed logins per email and per client IP in memory.
+type Guard struct {
+	base   time.Duration
+	max    time.Duration
+	window time.Duration
+
+	mu      sync.Mutex
+	byEmail map[string]*entry
+	byIP    map[string]*entry
+
+	done chan struct{}
+	once sync.Once
+}
+
+type entry struct {
+	failures    int
+	lastFailure time.Time
+}
+
+// New creates a Guard configured by the LOGIN_BACKOFF_BASE and LOGIN_BACKOFF_MAX env variables,
+// both in seconds. Failures are forgotten after LOGIN_BACKOFF_WINDOW minutes without a new failure.
+func New() *Guard {
+	return FromEnv("LOGIN")
+}
+
+// FromEnv creates a Guard configured like New by the env variables with the given prefix,
+// e.g. REGISTER_BACKOFF_BASE for the prefix "REGISTER".
+func FromEnv(prefix string) *Guard {
+	return NewGuard(
+		envDuration(prefix+"_BACKOFF_BASE", time.Second, time.Second),
+		envDuration(prefix+"_BACKOFF_MAX", time.Second, 5*time.Minute),
+		envDuration(prefix+"_BACKOFF_WINDOW", time.Minute, 15*time.Minute),
+	)
+}
+
+// NewGuard creates a Guard whose delay starts at base and doubles with every failure up to max.
+func NewGuard(base, max, window time.Duration) *Guard {
+	g := &Guard{
+		base:    base,
+		max:     max,
+		window:  window,
+		byEmail: make(map[string]*entry),
+		byIP:    make(map[string]*entry),
+		done:    make(chan struct{}),
+	}
+	go g.cleanup()
+	return g
+}
+
+// Wait returns how long the client must wait before it may try to log in with the email again.
+func (g *Guard) Wait(email, ip string) time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	wait := g.wait(g.byEmail[normalizeEmail(email)], now)
+	if ipWait := g.wait(g.byIP[ip], now); ipWait > wait {
+		wait = ipWait
+	}
+	return wait
+}
+
+// Failure records a failed login.
+func (g *Guard) Failure(email, ip string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	g.record(g.byEmail, normalizeEmail(email), now)
+	g.record(g.byIP, ip, now)
+}
+
+// Reset forgets the failures recorded for the email, after a successful login or an unlock.
+// Failures of the client IP are kept so one valid account cannot be used to keep guessing others.
+func (g *Guard) Reset(email string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.byEmail, normalizeEmail(email))
+}
+
+// Close stops the background cleanup of forgotten failures.
+func (g *Guard) Close() error {
+	g.once.Do(func() { close(g.done) })
+	return nil
+}
+
+func (g *Guard) wait(e *entry, now time.Time) time.Duration {
+	if e == nil || now.Sub(e.lastFailure) > g.window {
+		return 0
+	}
+	remaining := g.delay(e.failures) - now.Sub(e.lastFailure)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+func (g *Guard) delay(failures int) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	delay := float64(g.base) * math.Pow(2, float64(failures-1))
+	if delay > float64(g.max) {
+		return g.max
+	}
+	return time.Duration(delay)
+}
+
+func (g *Guard) record(entries map[string]*entry, key string, now time.Time) {
+	e, ok := entries[key]
+	if !ok || now.Sub(e.lastFailure) > g.window {
+		e = &entry{}
+		entries[key] = e
+	}
+	e.failures++
+	e.lastFailure = now
+}
+
+func (g *Guard) cleanup() {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.done:
+			return
+		case now := <-ticker.C:
+			g.mu.Lock()
+			for _, entries := range []map[string]*entry{g.byEmail, g.byIP} {
+				for key, e := range entries {
+					if now.Sub(e.lastFailure) > g.window {
+						delete(entries, key)
+					}
+				}
+			}
+			g.mu.Unlock()
+		}
+	}
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func envDuration(key string, unit, fallback time.Duration) time.Duration {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return time.Duration(value) * unit
+}
@@ -0,0 +1,61 @@
+package middlewares
+
+import (
+	"dbo-test/internal/apikey"
+	"dbo-test/internal/problem"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader is the request header that carries an API key.
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyScopes are the roles an API key can act with. Keys never act as admins, so a
+// leaked key can neither manage users nor create further keys.
+var APIKeyScopes = []string{RoleSales, RoleReadOnly}
+
+// APIKeyAuthMiddleware authenticates requests that carry an API key and hands all other
+// requests to next, usually JWTAuthMiddleware. An API key resolves to a Principal like
+// an access token does, with the scopes of the key as its roles and no user. Scopes
+// outside APIKeyScopes, such as admin on keys created before the limit, are ignored.
+func APIKeyAuthMiddleware(next gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(APIKeyHeader)
+		if key == "" {
+			next(c)
+			return
+		}
+
+		stored, err := apikey.Authenticate(key)
+		if err != nil {
+			if errors.Is(err, apikey.ErrInvalidKey) {
+				problem.Write(c, http.StatusUnauthorized, problem.CodeInvalidToken, "Invalid or expired API key")
+			} else {
+				problem.Error(c, fmt.Errorf("cannot check API key: %w", err))
+			}
+			return
+		}
+
+		SetPrincipal(c, &Principal{
+			APIKeyID: stored.ID,
+			Roles:    keyRoles(apikey.Scopes(stored)),
+		})
+
+		c.Next()
+	}
+}
+
+// keyRoles returns the scopes of a key that are in APIKeyScopes.
+func keyRoles(scopes []string) []string {
+	roles := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if slices.Contains(APIKeyScopes, scope) {
+			roles = append(roles, scope)
+		}
+	}
+	return roles
+}
@@ -1,53 +1,117 @@
 package middlewares
 
 import (
+	"dbo-test/internal/jwtkeys"
+	"dbo-test/internal/problem"
+	"dbo-test/internal/revocation"
+	"dbo-test/internal/session"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt"
 )
 
-func JWTAuthMiddleware(secretKey string) gin.HandlerFunc {
+// TokenTypeAccess is the "typ" claim of access tokens. Tokens of any other type,
+// such as two-factor challenge tokens, are rejected by JWTAuthMiddleware.
+const TokenTypeAccess = "access"
+
+func JWTAuthMiddleware(keys *jwtkeys.KeySet, revoked revocation.Store) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
-			c.Abort()
+			problem.Write(c, http.StatusUnauthorized, problem.CodeUnauthorized, "Authorization header is required")
 			return
 		}
 
 		bearerToken := strings.Split(authHeader, " ")
 		if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
-			c.Abort()
+			problem.Write(c, http.StatusUnauthorized, problem.CodeUnauthorized, "Invalid authorization header format")
 			return
 		}
 
-		token, err := jwt.Parse(bearerToken[1], func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(secretKey), nil
-		})
+		token, err := jwt.Parse(bearerToken[1], keys.Keyfunc)
 
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+			problem.Write(c, http.StatusUnauthorized, problem.CodeInvalidToken, "Invalid or expired token")
 			return
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			// You can access claims here and add them to the context if needed
-			// For example: c.Set("user_id", claims["user_id"])
-			c.Set("claims", claims)
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
-			c.Abort()
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok || !token.Valid {
+			problem.Write(c, http.StatusUnauthorized, problem.CodeInvalidToken, "Invalid token claims")
 			return
 		}
 
+		principal, ok := accessTokenPrincipal(claims)
+		if !ok {
+			problem.Write(c, http.StatusUnauthorized, problem.CodeInvalidToken, "Invalid token claims")
+			return
+		}
+
+		isRevoked, err := revoked.IsRevoked(principal.TokenID)
+		if err != nil {
+			problem.Error(c, fmt.Errorf("cannot check token revocation: %w", err))
+			return
+		}
+		if isRevoked {
+			problem.Write(c, http.StatusUnauthorized, problem.CodeInvalidToken, "Token has been revoked")
+			return
+		}
+
+		// Tokens issued before sessions were tracked carry no sid and stay valid until they expire.
+		if principal.SessionID != "" {
+			if _, err := session.Authenticate(principal.SessionID); err != nil {
+				if errors.Is(err, session.ErrInvalidSession) {
+					problem.Write(c, http.StatusUnauthorized, problem.CodeInvalidToken, "Session has been revoked")
+				} else {
+					problem.Error(c, fmt.Errorf("cannot check session: %w", err))
+				}
+				return
+			}
+		}
+
+		SetPrincipal(c, principal)
+
 		c.Next()
 	}
 }
+
+// accessTokenPrincipal reads the principal from the claims of an access token.
+func accessTokenPrincipal(claims jwt.MapClaims) (*Principal, bool) {
+	jti, _ := claims["jti"].(string)
+	if jti == "" || claims["typ"] != TokenTypeAccess {
+		return nil, false
+	}
+
+	sub, _ := claims["sub"].(string)
+	userID, err := strconv.Atoi(sub)
+	if err != nil || userID <= 0 {
+		return nil, false
+	}
+
+	values, _ := claims["roles"].([]interface{})
+	roles := make([]string, 0, len(values))
+	for _, v := range values {
+		if role, ok := v.(string); ok {
+			roles = append(roles, role)
+		}
+	}
+
+	p := &Principal{
+		UserID:  int32(userID),
+		Roles:   roles,
+		TokenID: jti,
+	}
+	p.Email, _ = claims["email"].(string)
+	p.Tenant, _ = claims["tenant"].(string)
+	p.SessionID, _ = claims["sid"].(string)
+	if exp, ok := claims["exp"].(float64); ok {
+		p.ExpiresAt = time.Unix(int64(exp), 0)
+	}
+	return p, true
+}
@@ -0,0 +1,63 @@
+package middlewares
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// principalKey is the gin context key the authenticated Principal is stored under.
+const principalKey = "principal"
+
+// Principal is who a request is authenticated as: a user with an access token,
+// or a service with an API key.
+type Principal struct {
+	// UserID is 0 for API keys.
+	UserID int32
+	Email  string
+	Roles  []string
+	Tenant string
+
+	// APIKeyID is set when the request carries an API key instead of an access token.
+	APIKeyID int32
+
+	// TokenID and ExpiresAt describe the access token, they are empty for API keys.
+	TokenID   string
+	ExpiresAt time.Time
+	// SessionID is the "sid" claim naming the session the access token was issued for.
+	SessionID string
+}
+
+// IsUser reports whether the principal is a user rather than a service.
+func (p *Principal) IsUser() bool {
+	return p.UserID != 0
+}
+
+// HasRole reports whether the principal has any of the roles.
+func (p *Principal) HasRole(roles ...string) bool {
+	return hasAnyRole(p.Roles, roles)
+}
+
+// ActorID returns the user to record as the author of a change, or nil for services.
+func (p *Principal) ActorID() *int32 {
+	if !p.IsUser() {
+		return nil
+	}
+	id := p.UserID
+	return &id
+}
+
+// SetPrincipal stores the authenticated principal of the request.
+func SetPrincipal(c *gin.Context, p *Principal) {
+	c.Set(principalKey, p)
+}
+
+// GetPrincipal returns the principal stored by JWTAuthMiddleware or APIKeyAuthMiddleware.
+func GetPrincipal(c *gin.Context) (*Principal, bool) {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return nil, false
+	}
+	p, ok := value.(*Principal)
+	return p, ok && p != nil
+}
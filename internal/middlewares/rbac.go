@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"dbo-test/internal/problem"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RoleAdmin    = "admin"
+	RoleSales    = "sales"
+	RoleReadOnly = "readonly"
+)
+
+// Policy lists the roles allowed to call each route.
+// Keys are the request method and the path the route was registered with,
+// for example "GET /customer/:id".
+type Policy map[string][]string
+
+// RBACMiddleware rejects requests whose token carries none of the roles the policy
+// allows for the matched route. Routes missing from the policy are denied.
+// It must run after JWTAuthMiddleware or APIKeyAuthMiddleware.
+func RBACMiddleware(policy Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			// No route matched, the NoRoute handler answers with 404.
+			c.Next()
+			return
+		}
+
+		allowed, ok := policy[c.Request.Method+" "+route]
+		principal, authenticated := GetPrincipal(c)
+		if !ok || !authenticated || !principal.HasRole(allowed...) {
+			problem.Write(c, http.StatusForbidden, problem.CodeForbidden, "you are not allowed to access this resource")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func hasAnyRole(roles, allowed []string) bool {
+	for _, role := range roles {
+		for _, a := range allowed {
+			if role == a {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package model
+
+// Address types.
+const (
+	AddressTypeBilling  = "billing"
+	AddressTypeShipping = "shipping"
+)
+
+// AddressSnapshot is a copy of an address kept on an order, so later changes to the
+// address do not rewrite the order. It is stored as JSON.
+type AddressSnapshot struct {
+	Recipient  string `json:"recipient"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2"`
+	City       string `json:"city"`
+	Region     string `json:"region"`
+	PostalCode string `json:"postal_code"`
+	Country    string `json:"country"`
+}
+
+// Snapshot returns a copy of the address fields.
+func (a *Address) Snapshot() *AddressSnapshot {
+	return &AddressSnapshot{
+		Recipient:  a.Recipient,
+		Line1:      a.Line1,
+		Line2:      a.Line2,
+		City:       a.City,
+		Region:     a.Region,
+		PostalCode: a.PostalCode,
+		Country:    a.Country,
+	}
+}
@@ -4,14 +4,22 @@
 
 package model
 
+import (
+	"gorm.io/gorm"
+)
+
 const TableNameCustomer = "customers"
 
 // Customer mapped from table <customers>
 type Customer struct {
-	ID    int32  `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
-	Name  string `gorm:"column:name;not null" json:"name"`
-	Email string `gorm:"column:email;not null" json:"email"`
-	Phone string `gorm:"column:phone;not null" json:"phone"`
+	ID         int32          `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	Name       string         `gorm:"column:name;not null" json:"name"`
+	Email      string         `gorm:"column:email;not null" json:"email"`
+	Phone      string         `gorm:"column:phone;not null" json:"phone"`
+	CreatedBy  *int32         `gorm:"column:created_by" json:"created_by"`
+	UpdatedBy  *int32         `gorm:"column:updated_by" json:"updated_by"`
+	DeletedAt  gorm.DeletedAt `gorm:"column:deleted_at" json:"deleted_at" format:"date-time" swaggertype:"string"`
+	Attributes map[string]any `gorm:"column:attributes;serializer:json" json:"attributes"`
 }
 
 // TableName Customer's table name
@@ -12,9 +12,15 @@ const TableNameLoginLog = "login_log"
 
 // LoginLog mapped from table <login_log>
 type LoginLog struct {
-	ID        int32     `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
-	UserID    int32     `gorm:"column:user_id;not null" json:"user_id"`
-	LoginTime time.Time `gorm:"column:login_time;not null" json:"login_time"`
+	ID            int32     `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	UserID        *int32    `gorm:"column:user_id" json:"user_id"`
+	LoginTime     time.Time `gorm:"column:login_time;not null" json:"login_time"`
+	Email         *string   `gorm:"column:email" json:"email"`
+	Status        string    `gorm:"column:status;not null;default:success" json:"status"`
+	FailureReason *string   `gorm:"column:failure_reason" json:"failure_reason"`
+	IP            *string   `gorm:"column:ip" json:"ip"`
+	UserAgent     *string   `gorm:"column:user_agent" json:"user_agent"`
+	TokenID       *string   `gorm:"column:token_id" json:"token_id"`
 }
 
 // TableName LoginLog's table name
@@ -0,0 +1,9 @@
+package model
+
+// MergedOrderAddresses are the address IDs an order had before a customer merge moved it to
+// the survivor. They are stored as JSON on the merge, so undoing it can give them back.
+type MergedOrderAddresses struct {
+	OrderID           int32  `json:"order_id"`
+	ShippingAddressID *int32 `json:"shipping_address_id"`
+	BillingAddressID  *int32 `json:"billing_address_id"`
+}
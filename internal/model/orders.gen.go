@@ -12,10 +12,16 @@ const TableNameOrder = "orders"
 
 // Order mapped from table <orders>
 type Order struct {
-	ID         int32     `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
-	OrderDate  time.Time `gorm:"column:orderDate;not null" json:"orderDate"`
-	Amount     float64   `gorm:"column:amount;not null" json:"amount"`
-	CustomerID int32     `gorm:"column:customerId;not null" json:"customerId"`
+	ID                int32            `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	OrderDate         time.Time        `gorm:"column:orderDate;not null" json:"orderDate"`
+	Amount            float64          `gorm:"column:amount;not null" json:"amount"`
+	CustomerID        int32            `gorm:"column:customerId;not null" json:"customerId"`
+	CreatedBy         *int32           `gorm:"column:createdBy" json:"createdBy"`
+	UpdatedBy         *int32           `gorm:"column:updatedBy" json:"updatedBy"`
+	ShippingAddressID *int32           `gorm:"column:shippingAddressId" json:"shippingAddressId"`
+	ShippingAddress   *AddressSnapshot `gorm:"column:shippingAddress;serializer:json" json:"shippingAddress"`
+	BillingAddressID  *int32           `gorm:"column:billingAddressId" json:"billingAddressId"`
+	BillingAddress    *AddressSnapshot `gorm:"column:billingAddress;serializer:json" json:"billingAddress"`
 }
 
 // TableName Order's table name
@@ -4,13 +4,27 @@
 
 package model
 
+import (
+	"time"
+)
+
 const TableNameUser = "users"
 
 // User mapped from table <users>
 type User struct {
-	ID       int32  `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
-	Email    string `gorm:"column:email;not null" json:"email"`
-	Password string `gorm:"column:password;not null" json:"password"`
+	ID                  int32      `gorm:"column:id;primaryKey;autoIncrement:true" json:"id"`
+	Email               string     `gorm:"column:email;not null" json:"email"`
+	Password            string     `gorm:"column:password;not null" json:"password"`
+	DisabledAt          *time.Time `gorm:"column:disabled_at" json:"disabled_at"`
+	CreatedAt           time.Time  `gorm:"column:created_at;not null;default:CURRENT_TIMESTAMP" json:"created_at"`
+	TotpSecret          *string    `gorm:"column:totp_secret" json:"totp_secret"`
+	TotpEnabledAt       *time.Time `gorm:"column:totp_enabled_at" json:"totp_enabled_at"`
+	FailedLoginAttempts int32      `gorm:"column:failed_login_attempts;not null;default:0" json:"failed_login_attempts"`
+	LockedUntil         *time.Time `gorm:"column:locked_until" json:"locked_until"`
+	OidcIssuer          *string    `gorm:"column:oidc_issuer" json:"oidc_issuer"`
+	OidcSubject         *string    `gorm:"column:oidc_subject" json:"oidc_subject"`
+	EmailVerifiedAt     *time.Time `gorm:"column:email_verified_at" json:"email_verified_at"`
+	TotpLastStep        *int64     `gorm:"column:totp_last_step" json:"totp_last_step"`
 }
 
 // TableName User's table name
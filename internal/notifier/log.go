@@ -0,0 +1,25 @@
+package notifier
+
+import (
+	"context"
+	"log"
+)
+
+// LogNotifier writes messages to a logger instead of delivering them.
+// It is meant for local development.
+type LogNotifier struct {
+	logger *log.Logger
+}
+
+// NewLogNotifier creates a LogNotifier. A nil logger uses the standard logger.
+func NewLogNotifier(logger *log.Logger) *LogNotifier {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogNotifier{logger: logger}
+}
+
+func (n *LogNotifier) Notify(_ context.Context, msg Message) error {
+	n.logger.Printf("notification to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
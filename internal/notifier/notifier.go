@@ -0,0 +1,34 @@
+package notifier
+
+import (
+	"context"
+	"os"
+)
+
+// Message is a notification addressed to a single recipient.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages to users.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// New returns the notifier selected by the NOTIFIER env variable.
+// "smtp" sends e-mails through the server configured by the SMTP_* variables,
+// anything else only writes the messages to the log.
+func New() Notifier {
+	if os.Getenv("NOTIFIER") == "smtp" {
+		return NewSMTPNotifier(SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+		})
+	}
+	return NewLogNotifier(nil)
+}
@@ -0,0 +1,100 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// breachedList is a small built-in list of the most common leaked passwords.
+//
+//go:embed breached.txt
+var breachedList string
+
+var (
+	builtinOnce sync.Once
+	builtin     map[string]struct{}
+
+	filesMu sync.Mutex
+	files   = map[string]map[string]struct{}{}
+)
+
+// IsBreached reports whether the password is in the built-in list or in the list at path.
+// Lists are read once and kept in memory. Each line holds a password, or its SHA-1 digest
+// in hex as in the offline downloads of Have I Been Pwned, optionally followed by ":count".
+func IsBreached(password, path string) (bool, error) {
+	builtinOnce.Do(func() {
+		builtin, _ = readBreachedList(strings.NewReader(breachedList))
+	})
+
+	digest := sha1Hex(password)
+	if _, ok := builtin[digest]; ok {
+		return true, nil
+	}
+	if path == "" {
+		return false, nil
+	}
+
+	list, err := breachedFile(path)
+	if err != nil {
+		return false, err
+	}
+	_, ok := list[digest]
+	return ok, nil
+}
+
+func breachedFile(path string) (map[string]struct{}, error) {
+	filesMu.Lock()
+	defer filesMu.Unlock()
+	if list, ok := files[path]; ok {
+		return list, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	list, err := readBreachedList(f)
+	if err != nil {
+		return nil, err
+	}
+	files[path] = list
+	return list, nil
+}
+
+func readBreachedList(r io.Reader) (map[string]struct{}, error) {
+	list := make(map[string]struct{})
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if digest, _, _ := strings.Cut(line, ":"); isSHA1Hex(digest) {
+			list[strings.ToUpper(digest)] = struct{}{}
+			continue
+		}
+		list[sha1Hex(line)] = struct{}{}
+	}
+	return list, scanner.Err()
+}
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func isSHA1Hex(s string) bool {
+	if len(s) != sha1.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,150 @@
+// Package password hashes passwords and checks them against the password policy.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+var errInvalidHash = errors.New("invalid password hash")
+
+// Argon2Params are the cost parameters of argon2id hashes.
+type Argon2Params struct {
+	Memory  uint32 // KiB
+	Time    uint32
+	Threads uint8
+	KeyLen  uint32
+	SaltLen uint32
+}
+
+// Hasher hashes new passwords with the configured algorithm and cost.
+type Hasher struct {
+	Algorithm  string
+	BcryptCost int
+	Argon2     Argon2Params
+}
+
+// HasherFromEnv returns the Hasher configured by PASSWORD_HASH_ALGORITHM (bcrypt or argon2id),
+// PASSWORD_BCRYPT_COST and PASSWORD_ARGON2_MEMORY (KiB), PASSWORD_ARGON2_TIME and PASSWORD_ARGON2_THREADS.
+func HasherFromEnv() Hasher {
+	h := Hasher{
+		Algorithm:  AlgorithmBcrypt,
+		BcryptCost: envInt("PASSWORD_BCRYPT_COST", bcrypt.DefaultCost),
+		Argon2: Argon2Params{
+			Memory:  uint32(envInt("PASSWORD_ARGON2_MEMORY", 64*1024)),
+			Time:    uint32(envInt("PASSWORD_ARGON2_TIME", 3)),
+			Threads: uint8(envInt("PASSWORD_ARGON2_THREADS", 2)),
+			KeyLen:  32,
+			SaltLen: 16,
+		},
+	}
+	if os.Getenv("PASSWORD_HASH_ALGORITHM") == AlgorithmArgon2id {
+		h.Algorithm = AlgorithmArgon2id
+	}
+	return h
+}
+
+// Hash returns the hash of the password.
+func (h Hasher) Hash(password string) (string, error) {
+	if h.Algorithm == AlgorithmArgon2id {
+		return h.hashArgon2id(password)
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.BcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// NeedsRehash reports whether the hash was made with another algorithm or a lower cost
+// than the Hasher would use now.
+func (h Hasher) NeedsRehash(hash string) bool {
+	if h.Algorithm == AlgorithmArgon2id {
+		params, _, _, err := decodeArgon2id(hash)
+		if err != nil {
+			return true
+		}
+		return params.Memory < h.Argon2.Memory || params.Time < h.Argon2.Time || params.Threads < h.Argon2.Threads
+	}
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost < h.BcryptCost
+}
+
+// Verify reports whether the password matches the hash, whichever supported algorithm made it.
+func Verify(hash, password string) bool {
+	if strings.HasPrefix(hash, "$"+AlgorithmArgon2id+"$") {
+		params, salt, key, err := decodeArgon2id(hash)
+		if err != nil {
+			return false
+		}
+		other := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+		return subtle.ConstantTimeCompare(key, other) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// hashArgon2id encodes the hash in the PHC string format,
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>.
+func (h Hasher) hashArgon2id(password string) (string, error) {
+	salt := make([]byte, h.Argon2.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	p := h.Argon2
+	key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", AlgorithmArgon2id, argon2.Version,
+		p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func decodeArgon2id(hash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return params, nil, nil, errInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return params, nil, nil, errInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, errInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errInvalidHash
+	}
+	return params, salt, key, nil
+}
+
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,124 @@
+package password
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Character classes a policy can require.
+const (
+	ClassLower  = "lower"
+	ClassUpper  = "upper"
+	ClassDigit  = "digit"
+	ClassSymbol = "symbol"
+)
+
+// Policy is what a new password must satisfy.
+type Policy struct {
+	MinLength       int
+	RequiredClasses []string
+	// History is how many previous passwords of a user cannot be reused.
+	History int
+	// BreachedListPath is an optional file of known-breached passwords, added to the built-in list.
+	BreachedListPath string
+}
+
+// PolicyError lists every rule a password breaks.
+type PolicyError struct {
+	Violations []string
+}
+
+func (e *PolicyError) Error() string {
+	return "password " + strings.Join(e.Violations, ", ")
+}
+
+// PolicyFromEnv returns the policy configured by PASSWORD_MIN_LENGTH, PASSWORD_REQUIRE_CLASSES
+// (a comma separated list of lower, upper, digit and symbol), PASSWORD_HISTORY and PASSWORD_BREACHED_LIST.
+func PolicyFromEnv() Policy {
+	var classes []string
+	for _, class := range strings.Split(os.Getenv("PASSWORD_REQUIRE_CLASSES"), ",") {
+		if class = strings.TrimSpace(class); class != "" {
+			classes = append(classes, class)
+		}
+	}
+
+	history := envInt("PASSWORD_HISTORY", 5)
+	if os.Getenv("PASSWORD_HISTORY") == "0" {
+		history = 0
+	}
+
+	return Policy{
+		MinLength:        envInt("PASSWORD_MIN_LENGTH", 8),
+		RequiredClasses:  classes,
+		History:          history,
+		BreachedListPath: os.Getenv("PASSWORD_BREACHED_LIST"),
+	}
+}
+
+// Check returns a *PolicyError when the password breaks the length, character class or
+// breached-password rules. The email is rejected as a password as well.
+// Reuse of previous passwords is checked by the caller, which owns the history.
+func (p Policy) Check(password, email string) error {
+	var violations []string
+
+	if len([]rune(password)) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters long", p.MinLength))
+	}
+	for _, class := range p.RequiredClasses {
+		if !hasClass(password, class) {
+			violations = append(violations, "must contain a "+classNames[class])
+		}
+	}
+	if email != "" && strings.EqualFold(password, email) {
+		violations = append(violations, "must not be the email")
+	}
+
+	breached, err := IsBreached(password, p.BreachedListPath)
+	if err != nil {
+		return err
+	}
+	if breached {
+		violations = append(violations, "is known to be breached")
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+var classNames = map[string]string{
+	ClassLower:  "lowercase letter",
+	ClassUpper:  "uppercase letter",
+	ClassDigit:  "digit",
+	ClassSymbol: "symbol",
+}
+
+func hasClass(password, class string) bool {
+	for _, r := range password {
+		switch class {
+		case ClassLower:
+			if unicode.IsLower(r) {
+				return true
+			}
+		case ClassUpper:
+			if unicode.IsUpper(r) {
+				return true
+			}
+		case ClassDigit:
+			if unicode.IsDigit(r) {
+				return true
+			}
+		case ClassSymbol:
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r) {
+				return true
+			}
+		default:
+			// Unknown classes are configuration mistakes, they must not lock everyone out.
+			return true
+		}
+	}
+	return false
+}
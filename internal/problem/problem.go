@@ -0,0 +1,127 @@
+// Package problem writes error responses as RFC 7807 problem details with a stable,
+// machine-readable code and the trace ID of the request.
+package problem
+
+import (
+	"context"
+	"database/sql/driver"
+	"dbo-test/internal/trace"
+	"dbo-test/internal/validation"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// ContentType is the media type of problem details.
+const ContentType = "application/problem+json"
+
+// Codes of the problems. Clients can rely on them; the detail text may change.
+const (
+	CodeBadRequest           = "bad_request"
+	CodeInvalidParameter     = "invalid_parameter"
+	CodeValidationFailed     = "validation_failed"
+	CodeUnauthorized         = "unauthorized"
+	CodeInvalidToken         = "invalid_token"
+	CodeInvalidCredentials   = "invalid_credentials"
+	CodeInvalidCode          = "invalid_code"
+	CodeEmailUnverified      = "email_unverified"
+	CodeUserDisabled         = "user_disabled"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeConflict             = "conflict"
+	CodePayloadTooLarge      = "payload_too_large"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeAccountLocked        = "account_locked"
+	CodeTooManyRequests      = "too_many_requests"
+	CodeInternal             = "internal_error"
+	CodeBadGateway           = "bad_gateway"
+	CodeUnavailable          = "service_unavailable"
+)
+
+// MySQL error numbers that Error maps to a conflict.
+const (
+	mysqlDuplicateEntry  = 1062
+	mysqlRowIsReferenced = 1451
+	mysqlNoReferencedRow = 1452
+)
+
+// Details is an RFC 7807 problem with the code, trace ID and field errors as extensions.
+type Details struct {
+	// Type is always about:blank; Code tells the problems apart.
+	Type     string `json:"type" example:"about:blank"`
+	Title    string `json:"title" example:"Bad Request"`
+	Status   int    `json:"status" example:"400"`
+	Detail   string `json:"detail,omitempty" example:"invalid id"`
+	Instance string `json:"instance,omitempty" example:"/customer/abc"`
+	Code     string `json:"code" example:"invalid_parameter"`
+	TraceID  string `json:"trace_id" example:"4bf92f3577b34da6a3ce929d0e0e4736"`
+	// Errors lists the problems per field when the request body is invalid.
+	Errors validation.Errors `json:"errors,omitempty"`
+}
+
+// Write answers the request with a problem and aborts the handler chain.
+func Write(c *gin.Context, status int, code, detail string) {
+	write(c, &Details{Status: status, Code: code, Detail: detail})
+}
+
+// Invalid answers with 400 and the problems of the request body per field.
+func Invalid(c *gin.Context, errs validation.Errors) {
+	write(c, &Details{
+		Status: http.StatusBadRequest,
+		Code:   CodeValidationFailed,
+		Detail: "request body is invalid",
+		Errors: errs,
+	})
+}
+
+// Error answers with the problem an unexpected error stands for. Database errors a
+// client can cause, such as a duplicate key, get a sanitized message. Everything else
+// is logged with the trace ID and answered with a generic 500, so that internal error
+// text never reaches clients.
+func Error(c *gin.Context, err error) {
+	var mysqlErr *mysql.MySQLError
+	isMySQL := errors.As(err, &mysqlErr)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		Write(c, http.StatusNotFound, CodeNotFound, "the record does not exist")
+	case IsDuplicate(err):
+		Write(c, http.StatusConflict, CodeConflict, "a record with the same unique value already exists")
+	case isMySQL && (mysqlErr.Number == mysqlRowIsReferenced || mysqlErr.Number == mysqlNoReferencedRow):
+		Write(c, http.StatusConflict, CodeConflict, "the record is referenced by, or refers to, another record")
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, mysql.ErrInvalidConn),
+		errors.Is(err, context.DeadlineExceeded):
+		Log(c, err)
+		Write(c, http.StatusServiceUnavailable, CodeUnavailable, "the database is unavailable, please retry")
+	default:
+		Log(c, err)
+		Write(c, http.StatusInternalServerError, CodeInternal, "an internal error occurred")
+	}
+}
+
+// IsDuplicate reports whether err is a violation of a unique key.
+func IsDuplicate(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlDuplicateEntry
+	}
+	// Dialectors translate the error when gorm is configured with TranslateError.
+	return errors.Is(err, gorm.ErrDuplicatedKey)
+}
+
+// Log records a server side failure with the trace ID of the request.
+func Log(c *gin.Context, err error) {
+	log.Printf("trace %s: %s %s: %v", trace.ID(c), c.Request.Method, c.Request.URL.Path, err)
+}
+
+func write(c *gin.Context, d *Details) {
+	d.Type = "about:blank"
+	d.Title = http.StatusText(d.Status)
+	d.Instance = c.Request.URL.Path
+	d.TraceID = trace.ID(c)
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(d.Status, d)
+}
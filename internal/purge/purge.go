@@ -0,0 +1,105 @@
+// Package purge permanently deletes customers that have been in the trash longer
+// than the retention period.
+package purge
+
+import (
+	"dbo-test/internal/dal"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Purger runs the purge in the background at a fixed interval.
+type Purger struct {
+	retention time.Duration
+	done      chan struct{}
+	once      sync.Once
+}
+
+// New creates a Purger from the CUSTOMER_TRASH_RETENTION (days, default 30) and
+// CUSTOMER_PURGE_INTERVAL (minutes, default 60) env variables.
+func New() *Purger {
+	retention := envInt("CUSTOMER_TRASH_RETENTION", 30)
+	interval := envInt("CUSTOMER_PURGE_INTERVAL", 60)
+	return NewPurger(time.Duration(retention)*24*time.Hour, time.Duration(interval)*time.Minute)
+}
+
+// NewPurger creates a Purger that deletes customers trashed more than retention ago every interval.
+// The dal package must have been initialized before calling it.
+func NewPurger(retention, interval time.Duration) *Purger {
+	p := &Purger{
+		retention: retention,
+		done:      make(chan struct{}),
+	}
+	go p.run(interval)
+	return p
+}
+
+// Purge permanently deletes the customers trashed before now minus the retention period,
+// along with their addresses and tags, and returns how many were deleted. Customers that orders
+// still refer to are kept.
+func (p *Purger) Purge(now time.Time) (int64, error) {
+	cutoff := gorm.DeletedAt{Time: now.Add(-p.retention), Valid: true}
+	var purged int64
+	err := dal.Q.Transaction(func(tx *dal.Query) error {
+		referenced := tx.Order.Select(tx.Order.CustomerID)
+		info, err := tx.Customer.Unscoped().Where(
+			tx.Customer.DeletedAt.Lt(cutoff),
+			tx.Customer.Columns(tx.Customer.ID).NotIn(referenced),
+		).Delete()
+		if err != nil {
+			return err
+		}
+		purged = info.RowsAffected
+
+		remaining := tx.Customer.Unscoped().Select(tx.Customer.ID)
+		if _, err := tx.Address.Where(tx.Address.Columns(tx.Address.CustomerID).NotIn(remaining)).Delete(); err != nil {
+			return err
+		}
+		_, err = tx.CustomerTag.Where(tx.CustomerTag.Columns(tx.CustomerTag.CustomerID).NotIn(remaining)).Delete()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+// Close stops the background purge.
+func (p *Purger) Close() error {
+	p.once.Do(func() { close(p.done) })
+	return nil
+}
+
+func (p *Purger) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case now := <-ticker.C:
+			purged, err := p.Purge(now)
+			if err != nil {
+				log.Printf("cannot purge deleted customers: %v", err)
+				continue
+			}
+			if purged > 0 {
+				log.Printf("purged %d deleted customers", purged)
+			}
+		}
+	}
+}
+
+func envInt(key string, fallback int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || v <= 0 {
+		return fallback
+	}
+	return v
+}
@@ -0,0 +1,80 @@
+package revocation
+
+import (
+	"dbo-test/internal/dal"
+	"dbo-test/internal/model"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// DatabaseStore is a Store backed by the revoked_tokens table.
+type DatabaseStore struct {
+	done chan struct{}
+	once sync.Once
+}
+
+// NewDatabaseStore creates a DatabaseStore that deletes expired rows every interval.
+// The dal package must have been initialized before calling it.
+func NewDatabaseStore(interval time.Duration) *DatabaseStore {
+	s := &DatabaseStore{
+		done: make(chan struct{}),
+	}
+	go s.cleanup(interval)
+	return s
+}
+
+func (s *DatabaseStore) Revoke(jti string, expiresAt time.Time) error {
+	return dal.RevokedToken.Save(&model.RevokedToken{
+		Jti:       jti,
+		ExpiresAt: expiresAt,
+		RevokedAt: time.Now(),
+	})
+}
+
+func (s *DatabaseStore) IsRevoked(jti string) (bool, error) {
+	count, err := dal.RevokedToken.Where(
+		dal.RevokedToken.Jti.Eq(jti),
+		dal.RevokedToken.ExpiresAt.Gt(time.Now()),
+	).Count()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (s *DatabaseStore) Consume(jti string, expiresAt time.Time) (bool, error) {
+	// The primary key lets only one insert of the token ID succeed.
+	result := dal.RevokedToken.UnderlyingDB().Clauses(clause.OnConflict{DoNothing: true}).Create(&model.RevokedToken{
+		Jti:       jti,
+		ExpiresAt: expiresAt,
+		RevokedAt: time.Now(),
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (s *DatabaseStore) Close() error {
+	s.once.Do(func() { close(s.done) })
+	return nil
+}
+
+func (s *DatabaseStore) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			if _, err := dal.RevokedToken.Where(dal.RevokedToken.ExpiresAt.Lte(now)).Delete(); err != nil {
+				log.Printf("cannot delete expired revoked tokens: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package revocation
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore is a Store that lives in the memory of a single process.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+	done    chan struct{}
+	once    sync.Once
+}
+
+// NewMemoryStore creates a MemoryStore that drops expired entries every interval.
+func NewMemoryStore(interval time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		revoked: make(map[string]time.Time),
+		done:    make(chan struct{}),
+	}
+	go s.cleanup(interval)
+	return s
+}
+
+func (s *MemoryStore) Revoke(jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked[jti] = expiresAt
+	return nil
+}
+
+func (s *MemoryStore) IsRevoked(jti string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	expiresAt, ok := s.revoked[jti]
+	return ok && time.Now().Before(expiresAt), nil
+}
+
+func (s *MemoryStore) Consume(jti string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if revokedUntil, ok := s.revoked[jti]; ok && time.Now().Before(revokedUntil) {
+		return false, nil
+	}
+	s.revoked[jti] = expiresAt
+	return true, nil
+}
+
+func (s *MemoryStore) Close() error {
+	s.once.Do(func() { close(s.done) })
+	return nil
+}
+
+// Len returns the number of entries currently held, expired or not.
+func (s *MemoryStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.revoked)
+}
+
+func (s *MemoryStore) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			for jti, expiresAt := range s.revoked {
+				if !now.Before(expiresAt) {
+					delete(s.revoked, jti)
+				}
+			}
+			s.mu.Unlock()
+		}
+	}
+}
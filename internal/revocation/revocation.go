@@ -0,0 +1,35 @@
+package revocation
+
+import (
+	"os"
+	"time"
+)
+
+// cleanupInterval is how often expired revocation entries are removed.
+const cleanupInterval = time.Minute
+
+// Store keeps the IDs (jti) of revoked tokens until the tokens expire on their own.
+type Store interface {
+	// Revoke marks the token ID as revoked until expiresAt.
+	Revoke(jti string, expiresAt time.Time) error
+
+	// IsRevoked reports whether the token ID has been revoked.
+	IsRevoked(jti string) (bool, error)
+
+	// Consume revokes a single-use token ID until expiresAt. It reports false when the
+	// token ID was already revoked, so that only one of concurrent uses succeeds.
+	Consume(jti string, expiresAt time.Time) (bool, error)
+
+	// Close stops the background cleanup of expired entries.
+	Close() error
+}
+
+// New returns the store selected by the TOKEN_REVOCATION_STORE env variable.
+// "database" keeps revocations in the revoked_tokens table so they are shared
+// between instances and survive restarts; anything else keeps them in memory.
+func New() Store {
+	if os.Getenv("TOKEN_REVOCATION_STORE") == "database" {
+		return NewDatabaseStore(cleanupInterval)
+	}
+	return NewMemoryStore(cleanupInterval)
+}
@@ -0,0 +1,73 @@
+package server
+
+import "dbo-test/internal/middlewares"
+
+var (
+	allRoles   = []string{middlewares.RoleAdmin, middlewares.RoleSales, middlewares.RoleReadOnly}
+	writeRoles = []string{middlewares.RoleAdmin, middlewares.RoleSales}
+	adminOnly  = []string{middlewares.RoleAdmin}
+)
+
+// RoutePolicy declares which roles may call each authenticated route.
+// A route that is not listed here cannot be called by anyone.
+var RoutePolicy = middlewares.Policy{
+	"POST /customer/":                           writeRoles,
+	"POST /customer/import":                     writeRoles,
+	"GET /customer/":                            allRoles,
+	"GET /customer/:id":                         allRoles,
+	"GET /customer/export":                      allRoles,
+	"PUT /customer/:id":                         writeRoles,
+	"PATCH /customer/:id":                       writeRoles,
+	"DELETE /customer/:id":                      adminOnly,
+	"GET /customer/trash":                       adminOnly,
+	"POST /customer/:id/restore":                adminOnly,
+	"GET /customer/duplicates":                  allRoles,
+	"POST /customer/:id/merge":                  adminOnly,
+	"GET /customer/merges":                      adminOnly,
+	"GET /customer/:id/addresses":               allRoles,
+	"POST /customer/:id/addresses":              writeRoles,
+	"GET /customer/:id/addresses/:addressId":    allRoles,
+	"PUT /customer/:id/addresses/:addressId":    writeRoles,
+	"DELETE /customer/:id/addresses/:addressId": writeRoles,
+	"POST /customer/merges/:id/undo":            adminOnly,
+	"GET /customer/tags":                        allRoles,
+	"POST /customer/tags/bulk":                  writeRoles,
+	"DELETE /customer/tags/:tagId":              adminOnly,
+	"GET /customer/:id/tags":                    allRoles,
+	"POST /customer/:id/tags":                   writeRoles,
+	"DELETE /customer/:id/tags/:tag":            writeRoles,
+	"GET /customer/:id/orders":                  allRoles,
+	"GET /customer/:id/summary":                 allRoles,
+	"GET /customer/attributes":                  allRoles,
+	"PUT /customer/attributes/:key":             adminOnly,
+	"DELETE /customer/attributes/:key":          adminOnly,
+
+	"POST /order/":      writeRoles,
+	"GET /order/":       allRoles,
+	"GET /order/:id":    allRoles,
+	"GET /order/export": allRoles,
+	"PUT /order/:id":    writeRoles,
+	"PATCH /order/:id":  writeRoles,
+	"DELETE /order/:id": adminOnly,
+
+	"POST /user/":            adminOnly,
+	"GET /user/":             adminOnly,
+	"PUT /user/me/password":  allRoles,
+	"GET /user/:id":          adminOnly,
+	"PUT /user/:id":          adminOnly,
+	"DELETE /user/:id":       adminOnly,
+	"POST /user/:id/disable": adminOnly,
+	"POST /user/:id/enable":  adminOnly,
+	"POST /user/:id/unlock":  adminOnly,
+
+	"GET /user/:id/sessions":               adminOnly,
+	"DELETE /user/:id/sessions":            adminOnly,
+	"DELETE /user/:id/sessions/:sessionId": adminOnly,
+
+	"POST /api-key/":      adminOnly,
+	"GET /api-key/":       adminOnly,
+	"DELETE /api-key/:id": adminOnly,
+
+	"GET /login-data":         adminOnly,
+	"GET /login-data/summary": adminOnly,
+}
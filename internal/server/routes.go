@@ -3,8 +3,10 @@ package server
 import (
 	"dbo-test/internal/controllers"
 	"dbo-test/internal/middlewares"
+	"dbo-test/internal/problem"
+	"dbo-test/internal/trace"
+	"fmt"
 	"net/http"
-	"os"
 
 	"github.com/gin-gonic/gin"
 	swaggerfiles "github.com/swaggo/files"
@@ -12,37 +14,112 @@ import (
 )
 
 func (s *Server) RegisterRoutes() http.Handler {
-	r := gin.Default()
+	r := gin.New()
+	r.Use(trace.Middleware(), gin.Logger(), gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		problem.Error(c, fmt.Errorf("panic: %v", recovered))
+	}))
+	r.NoRoute(func(c *gin.Context) {
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "no route matches "+c.Request.Method+" "+c.Request.URL.Path)
+	})
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerfiles.Handler))
 
 	r.GET("/", s.HelloWorldHandler)
 	r.GET("/health", s.healthHandler)
+	r.GET("/.well-known/jwks.json", controllers.JWKSHandler(s.keys))
+
+	authMiddleware := middlewares.JWTAuthMiddleware(s.keys, s.revoked)
 
 	//auth routes
 	authGroup := r.Group("/auth")
-	authGroup.POST("/login", controllers.LoginHandler)
+	authGroup.POST("/login", controllers.LoginHandler(s.guard, s.keys))
+	authGroup.POST("/login/2fa", controllers.LoginTwoFactorHandler(s.guard, s.keys, s.revoked))
+	authGroup.POST("/refresh", controllers.RefreshTokenHandler(s.keys))
+	authGroup.POST("/logout", authMiddleware, controllers.LogoutHandler(s.revoked))
+	authGroup.GET("/sessions", authMiddleware, controllers.GetMySessions)
+	authGroup.DELETE("/sessions/:id", authMiddleware, controllers.RevokeMySession)
+	authGroup.POST("/register", controllers.RegisterHandler(s.notifier, s.registerGuard, s.keys))
+	authGroup.POST("/register/verify", controllers.VerifyEmailHandler(s.keys))
+	authGroup.POST("/register/resend", controllers.ResendVerificationHandler(s.notifier, s.registerGuard, s.keys))
+	authGroup.POST("/password/forgot", controllers.ForgotPasswordHandler(s.notifier, s.resetGuard))
+	authGroup.POST("/password/reset", controllers.ResetPasswordHandler)
+	authGroup.POST("/2fa/enroll", authMiddleware, controllers.EnrollTwoFactor)
+	authGroup.POST("/2fa/confirm", authMiddleware, controllers.ConfirmTwoFactor)
+	authGroup.POST("/2fa/disable", authMiddleware, controllers.DisableTwoFactorHandler(s.guard))
+	if s.oidc != nil {
+		authGroup.GET("/oidc/login", controllers.OIDCLoginHandler(s.oidc, s.keys))
+		authGroup.GET("/oidc/callback", controllers.OIDCCallbackHandler(s.oidc, s.guard, s.keys))
+	}
 
-	r.Use(middlewares.JWTAuthMiddleware(os.Getenv("JWT_SECRET")))
+	// Services may call the remaining routes with an API key instead of an access token.
+	r.Use(middlewares.APIKeyAuthMiddleware(authMiddleware), middlewares.RBACMiddleware(RoutePolicy))
 
 	//customer routes
 	customerGroup := r.Group("/customer")
 	customerGroup.POST("/", controllers.CreateCustomer)
+	customerGroup.POST("/import", controllers.ImportCustomers)
 	customerGroup.GET("/", controllers.GetMultipleCustomer)
+	customerGroup.GET("/trash", controllers.GetDeletedCustomers)
+	customerGroup.GET("/export", controllers.ExportCustomers)
+	customerGroup.GET("/duplicates", controllers.GetDuplicateCustomers)
+	customerGroup.GET("/merges", controllers.GetCustomerMerges)
+	customerGroup.POST("/merges/:id/undo", controllers.UndoCustomerMerge)
+	customerGroup.GET("/tags", controllers.GetTags)
+	customerGroup.POST("/tags/bulk", controllers.BulkTagCustomers)
+	customerGroup.DELETE("/tags/:tagId", controllers.DeleteTag)
+	customerGroup.GET("/attributes", controllers.GetCustomerAttributes)
+	customerGroup.PUT("/attributes/:key", controllers.PutCustomerAttribute)
+	customerGroup.DELETE("/attributes/:key", controllers.DeleteCustomerAttribute)
 	customerGroup.GET("/:id", controllers.GetSingleCustomer)
 	customerGroup.PUT("/:id", controllers.UpdateCustomer)
+	customerGroup.PATCH("/:id", controllers.PatchCustomer)
 	customerGroup.DELETE("/:id", controllers.DeleteCustomer)
+	customerGroup.POST("/:id/restore", controllers.RestoreCustomer)
+	customerGroup.POST("/:id/merge", controllers.MergeCustomer)
+	customerGroup.GET("/:id/addresses", controllers.GetCustomerAddresses)
+	customerGroup.POST("/:id/addresses", controllers.CreateCustomerAddress)
+	customerGroup.GET("/:id/addresses/:addressId", controllers.GetCustomerAddress)
+	customerGroup.PUT("/:id/addresses/:addressId", controllers.UpdateCustomerAddress)
+	customerGroup.DELETE("/:id/addresses/:addressId", controllers.DeleteCustomerAddress)
+	customerGroup.GET("/:id/tags", controllers.GetCustomerTags)
+	customerGroup.POST("/:id/tags", controllers.AddCustomerTags)
+	customerGroup.DELETE("/:id/tags/:tag", controllers.RemoveCustomerTag)
+	customerGroup.GET("/:id/orders", controllers.GetCustomerOrders)
+	customerGroup.GET("/:id/summary", controllers.GetCustomerSummary)
 
 	//order routes
 	orderGroup := r.Group("/order")
 	orderGroup.POST("/", controllers.CreateOrder)
 	orderGroup.GET("/", controllers.GetMultipleOrder)
+	orderGroup.GET("/export", controllers.ExportOrders)
 	orderGroup.GET("/:id", controllers.GetSingleOrder)
 	orderGroup.PUT("/:id", controllers.UpdateOrder)
+	orderGroup.PATCH("/:id", controllers.PatchOrder)
 	orderGroup.DELETE("/:id", controllers.DeleteOrder)
 
+	//user routes
+	userGroup := r.Group("/user")
+	userGroup.POST("/", controllers.CreateUser)
+	userGroup.GET("/", controllers.GetMultipleUser)
+	userGroup.PUT("/me/password", controllers.ChangeOwnPasswordHandler(s.guard))
+	userGroup.GET("/:id", controllers.GetSingleUser)
+	userGroup.PUT("/:id", controllers.UpdateUser)
+	userGroup.DELETE("/:id", controllers.DeleteUser)
+	userGroup.POST("/:id/disable", controllers.DisableUser)
+	userGroup.POST("/:id/enable", controllers.EnableUser)
+	userGroup.POST("/:id/unlock", controllers.UnlockUser(s.guard))
+	userGroup.GET("/:id/sessions", controllers.GetUserSessions)
+	userGroup.DELETE("/:id/sessions", controllers.RevokeUserSessions)
+	userGroup.DELETE("/:id/sessions/:sessionId", controllers.RevokeUserSession)
+
+	//api key routes
+	apiKeyGroup := r.Group("/api-key")
+	apiKeyGroup.POST("/", controllers.CreateAPIKey)
+	apiKeyGroup.GET("/", controllers.GetMultipleAPIKey)
+	apiKeyGroup.DELETE("/:id", controllers.RevokeAPIKey)
+
 	r.GET("/login-data", controllers.GetLoginData)
-	r.POST("/user", controllers.CreateUser)
+	r.GET("/login-data/summary", controllers.GetLoginSummary)
 
 	return r
 }
@@ -10,12 +10,29 @@ import (
 	_ "github.com/joho/godotenv/autoload"
 
 	"dbo-test/internal/database"
+	"dbo-test/internal/jwtkeys"
+	"dbo-test/internal/loginguard"
+	"dbo-test/internal/notifier"
+	"dbo-test/internal/oidc"
+	"dbo-test/internal/purge"
+	"dbo-test/internal/revocation"
 )
 
 type Server struct {
 	port int
 
-	db database.Service
+	db       database.Service
+	revoked  revocation.Store
+	notifier notifier.Notifier
+	guard    *loginguard.Guard
+	// registerGuard slows down registrations and verification emails per email and client IP.
+	registerGuard *loginguard.Guard
+	// resetGuard slows down password reset requests per email and client IP.
+	resetGuard *loginguard.Guard
+	keys       *jwtkeys.KeySet
+	oidc       *oidc.Provider
+	// purger permanently deletes customers once their time in the trash is over.
+	purger *purge.Purger
 }
 
 func NewServer() *http.Server {
@@ -23,7 +40,15 @@ func NewServer() *http.Server {
 	NewServer := &Server{
 		port: port,
 
-		db: database.New(),
+		db:            database.New(),
+		revoked:       revocation.New(),
+		notifier:      notifier.New(),
+		guard:         loginguard.New(),
+		registerGuard: loginguard.FromEnv("REGISTER"),
+		resetGuard:    loginguard.FromEnv("PASSWORD_RESET"),
+		keys:          jwtkeys.New(),
+		oidc:          oidc.New(),
+		purger:        purge.New(),
 	}
 
 	// Declare Server config
@@ -0,0 +1,97 @@
+// Package session tracks the logins of users so they can see and end them.
+// A session belongs to one refresh token family; its ID is the "sid" claim of the
+// access tokens issued for it.
+package session
+
+import (
+	"dbo-test/internal/dal"
+	"dbo-test/internal/model"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// lastSeenInterval limits how often the last activity of a session is written, so a busy
+// client does not update its row on every request.
+const lastSeenInterval = time.Minute
+
+// ErrInvalidSession is returned for unknown, revoked and expired sessions.
+var ErrInvalidSession = errors.New("session has expired or been revoked")
+
+// Create starts a session for the refresh token family.
+func Create(q *dal.Query, userID int32, familyID, ip, userAgent string, expiresAt time.Time) (*model.Session, error) {
+	now := time.Now()
+	s := &model.Session{
+		UserID:     userID,
+		FamilyID:   familyID,
+		IP:         &ip,
+		UserAgent:  &userAgent,
+		ExpiresAt:  expiresAt,
+		LastSeenAt: now,
+		CreatedAt:  now,
+	}
+	if err := q.Session.Create(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Authenticate returns the active session of the refresh token family and records its use.
+func Authenticate(familyID string) (*model.Session, error) {
+	s, err := dal.Session.Where(dal.Session.FamilyID.Eq(familyID)).First()
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidSession
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if !IsActive(s, now) {
+		return nil, ErrInvalidSession
+	}
+
+	if now.Sub(s.LastSeenAt) >= lastSeenInterval {
+		if _, err := dal.Session.Where(dal.Session.ID.Eq(s.ID)).Update(dal.Session.LastSeenAt, now); err != nil {
+			return nil, err
+		}
+		s.LastSeenAt = now
+	}
+	return s, nil
+}
+
+// IsActive reports whether the session can still be used at now.
+func IsActive(s *model.Session, now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// Extend moves the expiry of the session to that of a rotated refresh token and
+// records where it was used from.
+func Extend(q *dal.Query, familyID, ip, userAgent string, expiresAt time.Time) error {
+	_, err := q.Session.Where(q.Session.FamilyID.Eq(familyID)).UpdateSimple(
+		q.Session.ExpiresAt.Value(expiresAt),
+		q.Session.LastSeenAt.Value(time.Now()),
+		q.Session.IP.Value(ip),
+		q.Session.UserAgent.Value(userAgent),
+	)
+	return err
+}
+
+// RevokeFamily ends the session of the refresh token family.
+func RevokeFamily(q *dal.Query, familyID string) error {
+	_, err := q.Session.Where(
+		q.Session.FamilyID.Eq(familyID),
+		q.Session.RevokedAt.IsNull(),
+	).Update(q.Session.RevokedAt, time.Now())
+	return err
+}
+
+// RevokeUser ends every session of the user.
+func RevokeUser(q *dal.Query, userID int32) error {
+	_, err := q.Session.Where(
+		q.Session.UserID.Eq(userID),
+		q.Session.RevokedAt.IsNull(),
+	).Update(q.Session.RevokedAt, time.Now())
+	return err
+}
@@ -0,0 +1,104 @@
+// Package totp implements time-based one-time passwords as described in RFC 6238,
+// using the defaults understood by common authenticator apps: HMAC-SHA1, 6 digits and a 30 second period.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	digits = 6
+	period = 30 * time.Second
+
+	// skew is the number of periods before and after the current one that are still accepted,
+	// to tolerate clock drift between the server and the authenticator.
+	skew = 1
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret returns a new random base32 encoded secret.
+func GenerateSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(b), nil
+}
+
+// Code returns the one-time password of the secret at time t.
+func Code(secret string, t time.Time) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid secret: %w", err)
+	}
+	return code(key, uint64(t.Unix())/uint64(period/time.Second)), nil
+}
+
+// Validate reports whether code is a valid one-time password of the secret around time t.
+func Validate(secret, code string, t time.Time) bool {
+	_, ok := Step(secret, code, t)
+	return ok
+}
+
+// Step returns the time step a valid one-time password of the secret around time t belongs to.
+// A code is valid during several steps, so callers that must not accept it twice remember the
+// step and reject codes of the same or an earlier one.
+func Step(secret, code string, t time.Time) (int64, bool) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != digits {
+		return 0, false
+	}
+
+	counter := uint64(t.Unix()) / uint64(period/time.Second)
+	for i := -skew; i <= skew; i++ {
+		expected := codeAt(key, counter, i)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return int64(counter) + int64(i), true
+		}
+	}
+	return 0, false
+}
+
+// URI returns the otpauth URI that authenticator apps import, usually through a QR code.
+func URI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer + ":" + account)
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(digits))
+	query.Set("period", fmt.Sprint(int(period/time.Second)))
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+func codeAt(key []byte, counter uint64, offset int) string {
+	if offset < 0 && counter < uint64(-offset) {
+		return ""
+	}
+	return code(key, uint64(int64(counter)+int64(offset)))
+}
+
+func code(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation, RFC 4226 section 5.3.
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", digits, value%1000000)
+}
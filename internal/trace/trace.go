@@ -0,0 +1,51 @@
+// Package trace gives every request an ID. The ID is returned in a response header and
+// in error responses, and logged with server errors, so that a failure a client reports
+// can be found in the logs.
+package trace
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Header is the request and response header that carries the trace ID. A client may
+// send its own ID, which is kept when it looks like one.
+const Header = "X-Request-ID"
+
+const contextKey = "traceID"
+
+var validID = regexp.MustCompile(`^[A-Za-z0-9._-]{8,64}$`)
+
+// Middleware assigns the trace ID of the request and sets the response header.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if id := c.GetHeader(Header); validID.MatchString(id) {
+			c.Set(contextKey, id)
+		}
+		c.Header(Header, ID(c))
+		c.Next()
+	}
+}
+
+// ID returns the trace ID of the request, creating one when Middleware did not run.
+func ID(c *gin.Context) string {
+	if id := c.GetString(contextKey); id != "" {
+		return id
+	}
+	id := newID()
+	c.Set(contextKey, id)
+	return id
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,167 @@
+// Package validation checks request bodies against the rules in their binding struct
+// tags and reports every problem with the JSON name of the field it belongs to.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Codes of the problems a field can have.
+const (
+	CodeRequired = "required"
+	CodeFormat   = "format"
+	CodeType     = "type"
+	CodeMin      = "min"
+	CodeMax      = "max"
+	CodeOneOf    = "one_of"
+	CodeUnique   = "unique"
+	CodeFuture   = "future"
+	CodeNotFound = "not_found"
+	CodeUnknown  = "unknown"
+	CodeInvalid  = "invalid"
+)
+
+// MaxFuture is how far in the future a date checked with the notfarfuture rule can be.
+const MaxFuture = 365 * 24 * time.Hour
+
+// FieldError is one problem with one field of a request body.
+type FieldError struct {
+	Field   string `json:"field" example:"email"`
+	Code    string `json:"code" example:"format"`
+	Message string `json:"message" example:"email must be a valid email address"`
+}
+
+// Errors lists the problems of a request body.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.SetTagName("binding")
+	v.RegisterTagNameFunc(jsonName)
+	if err := v.RegisterValidation("notfarfuture", notFarFuture); err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// jsonName returns the name of a field in JSON documents, or "" when it is not part of them.
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// notFarFuture accepts times no more than MaxFuture from now.
+func notFarFuture(fl validator.FieldLevel) bool {
+	t, ok := fl.Field().Interface().(time.Time)
+	return ok && !t.After(time.Now().Add(MaxFuture))
+}
+
+// Struct checks s against its binding tags. It returns Errors when fields break their
+// rules and another error when s cannot be validated at all.
+func Struct(s any) error {
+	return convert(validate.Struct(s))
+}
+
+// StructPartial is Struct for the fields with the given JSON names only, such as the members
+// a patch changes. Fields of nested structs cannot be named.
+func StructPartial(s any, names ...string) error {
+	t := reflect.TypeOf(s)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return convert(validate.StructPartial(s))
+	}
+
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); slices.Contains(names, jsonName(f)) {
+			fields = append(fields, f.Name)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return convert(validate.StructPartial(s, fields...))
+}
+
+// convert turns the errors of the validator into Errors.
+func convert(err error) error {
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return err
+	}
+
+	errs := make(Errors, len(invalid))
+	for i, fe := range invalid {
+		errs[i] = fieldError(fe)
+	}
+	return errs
+}
+
+func fieldError(fe validator.FieldError) FieldError {
+	// The namespace starts with the struct name, which is not part of the JSON document.
+	_, field, _ := strings.Cut(fe.Namespace(), ".")
+	code, message := CodeInvalid, "is invalid"
+	switch fe.Tag() {
+	case "required":
+		code, message = CodeRequired, "is required"
+	case "email":
+		code, message = CodeFormat, "must be a valid email address"
+	case "e164":
+		code, message = CodeFormat, "must be an E.164 phone number such as +6281234567890"
+	case "iso3166_1_alpha2":
+		code, message = CodeFormat, "must be a two letter ISO 3166 country code"
+	case "gt":
+		code, message = CodeMin, limitMessage(fe, "greater than", "more than")
+	case "gte", "min":
+		code, message = CodeMin, limitMessage(fe, "at least", "at least")
+	case "lte", "max":
+		code, message = CodeMax, limitMessage(fe, "at most", "at most")
+	case "oneof":
+		code, message = CodeOneOf, "must be one of "+strings.Join(strings.Fields(fe.Param()), ", ")
+	case "unique":
+		code, message = CodeUnique, "must not contain duplicates"
+	case "notfarfuture":
+		code, message = CodeFuture, fmt.Sprintf("must not be more than %d days in the future", int(MaxFuture.Hours()/24))
+	}
+	return FieldError{Field: field, Code: code, Message: field + " " + message}
+}
+
+// limitMessage describes a limit on a number, or on the length of a string or list.
+func limitMessage(fe validator.FieldError, number, length string) string {
+	switch fe.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("must be %s %s characters", length, fe.Param())
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if fe.Param() == "1" {
+			return fmt.Sprintf("must have %s 1 item", length)
+		}
+		return fmt.Sprintf("must have %s %s items", length, fe.Param())
+	default:
+		return fmt.Sprintf("must be %s %s", number, fe.Param())
+	}
+}
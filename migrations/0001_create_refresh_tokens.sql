@@ -0,0 +1,13 @@
+CREATE TABLE refresh_tokens (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    user_id     INT          NOT NULL,
+    family_id   CHAR(32)     NOT NULL,
+    token_hash  CHAR(64)     NOT NULL,
+    expires_at  DATETIME     NOT NULL,
+    revoked_at  DATETIME     NULL,
+    replaced_by INT          NULL,
+    created_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_refresh_tokens_token_hash (token_hash),
+    KEY idx_refresh_tokens_family_id (family_id),
+    KEY idx_refresh_tokens_user_id (user_id)
+);
@@ -0,0 +1,6 @@
+CREATE TABLE revoked_tokens (
+    jti        CHAR(32) PRIMARY KEY,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY idx_revoked_tokens_expires_at (expires_at)
+);
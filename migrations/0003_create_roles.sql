@@ -0,0 +1,25 @@
+CREATE TABLE roles (
+    id   INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    UNIQUE KEY uq_roles_name (name)
+);
+
+CREATE TABLE user_roles (
+    user_id INT NOT NULL,
+    role_id INT NOT NULL,
+    PRIMARY KEY (user_id, role_id),
+    KEY idx_user_roles_role_id (role_id)
+);
+
+INSERT INTO roles (name) VALUES ('admin'), ('sales'), ('readonly');
+
+-- Every existing user could call every route before roles existed. Only the bootstrap account
+-- becomes an admin, chosen with SET @bootstrap_admin_email = '...' in the same session before
+-- this file runs; without it nobody does. Everyone else gets the readonly role.
+INSERT INTO user_roles (user_id, role_id)
+SELECT users.id, roles.id FROM users JOIN roles ON roles.name = 'admin'
+WHERE users.email = @bootstrap_admin_email;
+
+INSERT INTO user_roles (user_id, role_id)
+SELECT users.id, roles.id FROM users JOIN roles ON roles.name = 'readonly'
+WHERE NOT (users.email <=> @bootstrap_admin_email);
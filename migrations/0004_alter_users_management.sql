@@ -0,0 +1,13 @@
+-- The unique key below cannot be added while emails repeat. The oldest user keeps each
+-- repeated email; the others are renamed to duplicate-<id>-<email> so that no rows or
+-- references are lost. Review them before deleting or merging them by hand:
+--   SELECT id, email FROM users WHERE email LIKE 'duplicate-%';
+UPDATE users u
+    JOIN (SELECT email, MIN(id) AS id FROM users GROUP BY email HAVING COUNT(*) > 1) keep
+        ON u.email = keep.email AND u.id <> keep.id
+SET u.email = CONCAT('duplicate-', u.id, '-', LEFT(u.email, 200));
+
+ALTER TABLE users
+    ADD COLUMN disabled_at DATETIME NULL,
+    ADD COLUMN created_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD UNIQUE KEY uq_users_email (email);
@@ -0,0 +1,10 @@
+CREATE TABLE password_resets (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    user_id    INT      NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at    DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_password_resets_token_hash (token_hash),
+    KEY idx_password_resets_user_id (user_id)
+);
@@ -0,0 +1,12 @@
+ALTER TABLE users
+    ADD COLUMN totp_secret     VARCHAR(64) NULL,
+    ADD COLUMN totp_enabled_at DATETIME    NULL;
+
+CREATE TABLE recovery_codes (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    user_id    INT      NOT NULL,
+    code_hash  CHAR(64) NOT NULL,
+    used_at    DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY idx_recovery_codes_user_id (user_id)
+);
@@ -0,0 +1,9 @@
+ALTER TABLE users
+    ADD COLUMN failed_login_attempts INT      NOT NULL DEFAULT 0,
+    ADD COLUMN locked_until          DATETIME NULL;
+
+-- Failed attempts are logged too, possibly for emails that do not belong to any user.
+ALTER TABLE login_log
+    MODIFY COLUMN user_id INT NULL,
+    ADD COLUMN email  VARCHAR(255) NULL,
+    ADD COLUMN status VARCHAR(16)  NOT NULL DEFAULT 'success';
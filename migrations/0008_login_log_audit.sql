@@ -0,0 +1,8 @@
+ALTER TABLE login_log
+    ADD COLUMN failure_reason VARCHAR(32)  NULL,
+    ADD COLUMN ip             VARCHAR(45)  NULL,
+    ADD COLUMN user_agent     VARCHAR(512) NULL,
+    ADD COLUMN token_id       CHAR(32)     NULL,
+    ADD KEY idx_login_log_login_time (login_time),
+    ADD KEY idx_login_log_user_id (user_id),
+    ADD KEY idx_login_log_ip (ip);
@@ -0,0 +1,14 @@
+-- Scopes are a comma separated list of role names the key acts with.
+CREATE TABLE api_keys (
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    name         VARCHAR(255) NOT NULL,
+    prefix       VARCHAR(16)  NOT NULL,
+    key_hash     CHAR(64)     NOT NULL,
+    scopes       VARCHAR(255) NOT NULL,
+    created_by   INT          NULL,
+    expires_at   DATETIME     NULL,
+    last_used_at DATETIME     NULL,
+    revoked_at   DATETIME     NULL,
+    created_at   DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_api_keys_key_hash (key_hash)
+);
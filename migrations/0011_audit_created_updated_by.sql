@@ -0,0 +1,9 @@
+-- The user who created and last updated each row, NULL when a service made the change.
+ALTER TABLE customers
+    ADD COLUMN created_by INT NULL,
+    ADD COLUMN updated_by INT NULL;
+
+-- orders keeps its camelCase column naming.
+ALTER TABLE orders
+    ADD COLUMN createdBy INT NULL,
+    ADD COLUMN updatedBy INT NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE users
+    ADD COLUMN email_verified_at DATETIME NULL;
+
+-- Users created before self-service registration were added by an admin and count as verified.
+UPDATE users SET email_verified_at = created_at;
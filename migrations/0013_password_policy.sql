@@ -0,0 +1,11 @@
+-- argon2id hashes are longer than bcrypt's 60 characters.
+ALTER TABLE users
+    MODIFY COLUMN password VARCHAR(255) NOT NULL;
+
+CREATE TABLE password_history (
+    id            INT AUTO_INCREMENT PRIMARY KEY,
+    user_id       INT          NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at    DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    KEY idx_password_history_user_id (user_id)
+);
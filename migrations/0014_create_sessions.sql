@@ -0,0 +1,14 @@
+-- A session is one login on one device. It lives as long as its refresh token family.
+CREATE TABLE sessions (
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    user_id      INT          NOT NULL,
+    family_id    CHAR(32)     NOT NULL,
+    ip           VARCHAR(45)  NULL,
+    user_agent   VARCHAR(512) NULL,
+    expires_at   DATETIME     NOT NULL,
+    last_seen_at DATETIME     NOT NULL,
+    revoked_at   DATETIME     NULL,
+    created_at   DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_sessions_family_id (family_id),
+    KEY idx_sessions_user_id (user_id)
+);
@@ -0,0 +1,4 @@
+-- Deleted customers stay in the trash until they are restored or purged.
+ALTER TABLE customers
+    ADD COLUMN deleted_at DATETIME NULL,
+    ADD KEY idx_customers_deleted_at (deleted_at);
@@ -0,0 +1,14 @@
+-- A merge moves the orders of a duplicate customer to the survivor and trashes the duplicate.
+-- order_ids lists the moved orders, comma separated, so the merge can be undone.
+CREATE TABLE customer_merges (
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    survivor_id  INT      NOT NULL,
+    duplicate_id INT      NOT NULL,
+    order_ids    TEXT     NOT NULL,
+    merged_by    INT      NULL,
+    merged_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    undone_by    INT      NULL,
+    undone_at    DATETIME NULL,
+    KEY idx_customer_merges_survivor_id (survivor_id),
+    KEY idx_customer_merges_duplicate_id (duplicate_id)
+);
@@ -0,0 +1,25 @@
+-- Billing and shipping addresses of customers. At most one address of each type is the default.
+CREATE TABLE addresses (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    customer_id INT          NOT NULL,
+    type        VARCHAR(16)  NOT NULL,
+    recipient   VARCHAR(255) NOT NULL DEFAULT '',
+    line1       VARCHAR(255) NOT NULL,
+    line2       VARCHAR(255) NOT NULL DEFAULT '',
+    city        VARCHAR(128) NOT NULL,
+    region      VARCHAR(128) NOT NULL DEFAULT '',
+    postal_code VARCHAR(32)  NOT NULL DEFAULT '',
+    country     CHAR(2)      NOT NULL,
+    is_default  TINYINT(1)   NOT NULL DEFAULT 0,
+    created_by  INT          NULL,
+    updated_by  INT          NULL,
+    KEY idx_addresses_customer_id (customer_id)
+);
+
+-- Orders keep a copy of their addresses as they were when the order was placed,
+-- so editing or deleting an address later does not change the order.
+ALTER TABLE orders
+    ADD COLUMN shippingAddressId INT  NULL,
+    ADD COLUMN shippingAddress   JSON NULL,
+    ADD COLUMN billingAddressId  INT  NULL,
+    ADD COLUMN billingAddress    JSON NULL;
@@ -0,0 +1,31 @@
+-- Tags segment customers, e.g. vip or wholesale. A customer can have any number of tags.
+CREATE TABLE tags (
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    name       VARCHAR(64) NOT NULL,
+    created_by INT         NULL,
+    created_at DATETIME    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_tags_name (name)
+);
+
+CREATE TABLE customer_tags (
+    customer_id INT      NOT NULL,
+    tag_id      INT      NOT NULL,
+    created_by  INT      NULL,
+    created_at  DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (customer_id, tag_id),
+    KEY idx_customer_tags_tag_id (tag_id)
+);
+
+-- Custom attributes are stored as a JSON object on the customer. Every key must be
+-- defined by an admin in customer_attributes, which gives its type.
+ALTER TABLE customers
+    ADD COLUMN attributes JSON NULL;
+
+CREATE TABLE customer_attributes (
+    `key`       VARCHAR(64)  NOT NULL PRIMARY KEY,
+    type        VARCHAR(16)  NOT NULL,
+    options     JSON         NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_by  INT          NULL,
+    updated_by  INT          NULL
+);
@@ -0,0 +1,4 @@
+-- A merge clears the address IDs of the orders it moves, since the addresses belong to the
+-- duplicate. order_addresses keeps them, as JSON, so undoing the merge can restore them.
+ALTER TABLE customer_merges
+    ADD COLUMN order_addresses JSON NULL;
@@ -0,0 +1,362 @@
+package tests
+
+import (
+	"bytes"
+	"dbo-test/internal/controllers"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/model"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func createTestCustomer(t *testing.T, name string) *model.Customer {
+	t.Helper()
+	customer := &model.Customer{Name: name, Email: name + "@example.com", Phone: "+6281234567890"}
+	if err := dal.Customer.Create(customer); err != nil {
+		t.Fatal(err)
+	}
+	return customer
+}
+
+func createTestAddress(t *testing.T, customerID int32, addressType, city string, isDefault bool) *model.Address {
+	t.Helper()
+	address := &model.Address{
+		CustomerID: customerID,
+		Type:       addressType,
+		Recipient:  "Recipient",
+		Line1:      "Line 1",
+		City:       city,
+		PostalCode: "12345",
+		Country:    "ID",
+		IsDefault:  isDefault,
+	}
+	if err := dal.Address.Create(address); err != nil {
+		t.Fatal(err)
+	}
+	return address
+}
+
+func findTestOrder(t *testing.T, id int32) *model.Order {
+	t.Helper()
+	order, err := dal.Order.Where(dal.Order.ID.Eq(id)).First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return order
+}
+
+func TestUpdateOrderToOtherCustomerReplacesAddresses(t *testing.T) {
+	newTestDB(t)
+	alice, bob := createTestCustomer(t, "alice"), createTestCustomer(t, "bob")
+	aliceShipping := createTestAddress(t, alice.ID, model.AddressTypeShipping, "Jakarta", true)
+	aliceBilling := createTestAddress(t, alice.ID, model.AddressTypeBilling, "Jakarta", true)
+	bobShipping := createTestAddress(t, bob.ID, model.AddressTypeShipping, "Bandung", true)
+
+	order := &model.Order{
+		OrderDate:         time.Now(),
+		Amount:            10,
+		CustomerID:        alice.ID,
+		ShippingAddressID: &aliceShipping.ID,
+		ShippingAddress:   aliceShipping.Snapshot(),
+		BillingAddressID:  &aliceBilling.ID,
+		BillingAddress:    aliceBilling.Snapshot(),
+	}
+	if err := dal.Order.Create(order); err != nil {
+		t.Fatal(err)
+	}
+
+	rr := serveRoute(t, http.MethodPut, "/order/:id", fmt.Sprintf("/order/%d", order.ID), map[string]any{
+		"order_date":  time.Now(),
+		"amount":      20,
+		"customer_id": bob.ID,
+	}, controllers.UpdateOrder)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+
+	updated := findTestOrder(t, order.ID)
+	if updated.ShippingAddressID == nil || *updated.ShippingAddressID != bobShipping.ID {
+		t.Errorf("shipping address ID = %v, want bob's default %d", updated.ShippingAddressID, bobShipping.ID)
+	}
+	if updated.ShippingAddress == nil || updated.ShippingAddress.City != "Bandung" {
+		t.Errorf("shipping address = %+v, want a copy of bob's default", updated.ShippingAddress)
+	}
+	if updated.BillingAddressID != nil || updated.BillingAddress != nil {
+		t.Errorf("billing address = %v %+v, want none since bob has no billing address", updated.BillingAddressID, updated.BillingAddress)
+	}
+}
+
+func TestUpdateOrderKeepsAddressesOfSameCustomer(t *testing.T) {
+	newTestDB(t)
+	alice := createTestCustomer(t, "alice")
+	shipping := createTestAddress(t, alice.ID, model.AddressTypeShipping, "Jakarta", true)
+	order := &model.Order{
+		OrderDate:         time.Now(),
+		Amount:            10,
+		CustomerID:        alice.ID,
+		ShippingAddressID: &shipping.ID,
+		ShippingAddress:   shipping.Snapshot(),
+	}
+	if err := dal.Order.Create(order); err != nil {
+		t.Fatal(err)
+	}
+
+	rr := serveRoute(t, http.MethodPut, "/order/:id", fmt.Sprintf("/order/%d", order.ID), map[string]any{
+		"order_date":  time.Now(),
+		"amount":      20,
+		"customer_id": alice.ID,
+	}, controllers.UpdateOrder)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+
+	updated := findTestOrder(t, order.ID)
+	if updated.Amount != 20 {
+		t.Errorf("amount = %v, want 20", updated.Amount)
+	}
+	if updated.ShippingAddressID == nil || *updated.ShippingAddressID != shipping.ID || updated.ShippingAddress == nil {
+		t.Errorf("shipping address = %v %+v, want it kept", updated.ShippingAddressID, updated.ShippingAddress)
+	}
+}
+
+// patchOrder sends a merge patch to PATCH /order/:id.
+func patchOrder(t *testing.T, id int32, patch string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := gin.New()
+	r.PATCH("/order/:id", controllers.PatchOrder)
+	req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/order/%d", id), bytes.NewBufferString(patch))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestPatchOrderToOtherCustomerReplacesAddresses(t *testing.T) {
+	newTestDB(t)
+	alice, bob := createTestCustomer(t, "alice"), createTestCustomer(t, "bob")
+	createTestAddress(t, alice.ID, model.AddressTypeShipping, "Jakarta", true)
+	createTestAddress(t, alice.ID, model.AddressTypeBilling, "Jakarta", true)
+	bobShipping := createTestAddress(t, bob.ID, model.AddressTypeShipping, "Bandung", true)
+	bobBilling := createTestAddress(t, bob.ID, model.AddressTypeBilling, "Bogor", false)
+	newOrder := func() *model.Order {
+		return createTestOrder(t, map[string]any{"order_date": time.Now(), "amount": 10, "customer_id": alice.ID})
+	}
+
+	order := newOrder()
+	if rr := patchOrder(t, order.ID, fmt.Sprintf(`{"customer_id":%d}`, bob.ID)); rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+	updated := findTestOrder(t, order.ID)
+	if updated.ShippingAddressID == nil || *updated.ShippingAddressID != bobShipping.ID ||
+		updated.ShippingAddress == nil || updated.ShippingAddress.City != "Bandung" {
+		t.Errorf("shipping address = %v %+v, want a copy of bob's default %d", updated.ShippingAddressID, updated.ShippingAddress, bobShipping.ID)
+	}
+	if updated.BillingAddressID != nil || updated.BillingAddress != nil {
+		t.Errorf("billing address = %v %+v, want none since bob has no default billing address", updated.BillingAddressID, updated.BillingAddress)
+	}
+
+	order = newOrder()
+	rr := patchOrder(t, order.ID, fmt.Sprintf(`{"customer_id":%d,"billing_address_id":%d}`, bob.ID, bobBilling.ID))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+	updated = findTestOrder(t, order.ID)
+	if updated.BillingAddressID == nil || *updated.BillingAddressID != bobBilling.ID || updated.BillingAddress.City != "Bogor" {
+		t.Errorf("billing address = %v %+v, want the given address %d", updated.BillingAddressID, updated.BillingAddress, bobBilling.ID)
+	}
+	if updated.ShippingAddressID == nil || *updated.ShippingAddressID != bobShipping.ID {
+		t.Errorf("shipping address ID = %v, want bob's default %d", updated.ShippingAddressID, bobShipping.ID)
+	}
+}
+
+func TestPatchOrderKeepsAddressesOfSameCustomer(t *testing.T) {
+	newTestDB(t)
+	alice := createTestCustomer(t, "alice")
+	shipping := createTestAddress(t, alice.ID, model.AddressTypeShipping, "Jakarta", true)
+	order := createTestOrder(t, map[string]any{"order_date": time.Now(), "amount": 10, "customer_id": alice.ID})
+	// A new default does not change the address of an existing order.
+	createTestAddress(t, alice.ID, model.AddressTypeShipping, "Bandung", true)
+
+	if rr := patchOrder(t, order.ID, fmt.Sprintf(`{"customer_id":%d,"amount":20}`, alice.ID)); rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+	updated := findTestOrder(t, order.ID)
+	if updated.Amount != 20 {
+		t.Errorf("amount = %v, want 20", updated.Amount)
+	}
+	if updated.ShippingAddressID == nil || *updated.ShippingAddressID != shipping.ID || updated.ShippingAddress.City != "Jakarta" {
+		t.Errorf("shipping address = %v %+v, want it kept", updated.ShippingAddressID, updated.ShippingAddress)
+	}
+}
+
+func updateTestAddress(t *testing.T, address *model.Address, addressType string, isDefault bool) {
+	t.Helper()
+	rr := serveRoute(t, http.MethodPut, "/customer/:id/addresses/:addressId",
+		fmt.Sprintf("/customer/%d/addresses/%d", address.CustomerID, address.ID), map[string]any{
+			"type":        addressType,
+			"recipient":   address.Recipient,
+			"line1":       address.Line1,
+			"city":        address.City,
+			"postal_code": address.PostalCode,
+			"country":     address.Country,
+			"is_default":  isDefault,
+		}, controllers.UpdateCustomerAddress)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+}
+
+// defaultAddressIDs returns the IDs of the default addresses of a type.
+func defaultAddressIDs(t *testing.T, customerID int32, addressType string) []int32 {
+	t.Helper()
+	addresses, err := dal.Address.Where(
+		dal.Address.CustomerID.Eq(customerID),
+		dal.Address.Type.Eq(addressType),
+		dal.Address.IsDefault.Is(true),
+	).Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]int32, len(addresses))
+	for i, address := range addresses {
+		ids[i] = address.ID
+	}
+	return ids
+}
+
+func TestUpdateAddressKeepsOneDefault(t *testing.T) {
+	t.Run("unset default promotes another address", func(t *testing.T) {
+		newTestDB(t)
+		customer := createTestCustomer(t, "alice")
+		first := createTestAddress(t, customer.ID, model.AddressTypeShipping, "Jakarta", true)
+		second := createTestAddress(t, customer.ID, model.AddressTypeShipping, "Bandung", false)
+
+		updateTestAddress(t, first, model.AddressTypeShipping, false)
+
+		if ids := defaultAddressIDs(t, customer.ID, model.AddressTypeShipping); len(ids) != 1 || ids[0] != second.ID {
+			t.Errorf("default addresses = %v, want [%d]", ids, second.ID)
+		}
+	})
+
+	t.Run("only address stays default", func(t *testing.T) {
+		newTestDB(t)
+		customer := createTestCustomer(t, "alice")
+		only := createTestAddress(t, customer.ID, model.AddressTypeShipping, "Jakarta", true)
+
+		updateTestAddress(t, only, model.AddressTypeShipping, false)
+
+		if ids := defaultAddressIDs(t, customer.ID, model.AddressTypeShipping); len(ids) != 1 || ids[0] != only.ID {
+			t.Errorf("default addresses = %v, want [%d]", ids, only.ID)
+		}
+	})
+
+	t.Run("change of type promotes another address", func(t *testing.T) {
+		newTestDB(t)
+		customer := createTestCustomer(t, "alice")
+		moved := createTestAddress(t, customer.ID, model.AddressTypeShipping, "Jakarta", true)
+		staying := createTestAddress(t, customer.ID, model.AddressTypeShipping, "Bandung", false)
+		billing := createTestAddress(t, customer.ID, model.AddressTypeBilling, "Surabaya", true)
+
+		updateTestAddress(t, moved, model.AddressTypeBilling, false)
+
+		if ids := defaultAddressIDs(t, customer.ID, model.AddressTypeShipping); len(ids) != 1 || ids[0] != staying.ID {
+			t.Errorf("default shipping addresses = %v, want [%d]", ids, staying.ID)
+		}
+		if ids := defaultAddressIDs(t, customer.ID, model.AddressTypeBilling); len(ids) != 1 || ids[0] != billing.ID {
+			t.Errorf("default billing addresses = %v, want [%d]", ids, billing.ID)
+		}
+	})
+}
+
+// createTestOrder places an order through the handler and returns it.
+func createTestOrder(t *testing.T, body map[string]any) *model.Order {
+	t.Helper()
+	rr := serve(t, http.MethodPost, "/order", body, controllers.CreateOrder)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+	order, err := dal.Order.Order(dal.Order.ID.Desc()).First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return order
+}
+
+func TestCreateOrderUsesDefaultAddresses(t *testing.T) {
+	newTestDB(t)
+	customer := createTestCustomer(t, "alice")
+	shipping := createTestAddress(t, customer.ID, model.AddressTypeShipping, "Jakarta", true)
+	createTestAddress(t, customer.ID, model.AddressTypeShipping, "Bandung", false)
+	billing := createTestAddress(t, customer.ID, model.AddressTypeBilling, "Surabaya", true)
+
+	order := createTestOrder(t, map[string]any{"order_date": time.Now(), "amount": 10, "customer_id": customer.ID})
+
+	if order.ShippingAddressID == nil || *order.ShippingAddressID != shipping.ID || order.ShippingAddress.City != "Jakarta" {
+		t.Errorf("shipping address = %v %+v, want the default %d", order.ShippingAddressID, order.ShippingAddress, shipping.ID)
+	}
+	if order.BillingAddressID == nil || *order.BillingAddressID != billing.ID || order.BillingAddress.City != "Surabaya" {
+		t.Errorf("billing address = %v %+v, want the default %d", order.BillingAddressID, order.BillingAddress, billing.ID)
+	}
+}
+
+func TestCreateOrderWithoutAddresses(t *testing.T) {
+	newTestDB(t)
+	customer := createTestCustomer(t, "alice")
+
+	order := createTestOrder(t, map[string]any{"order_date": time.Now(), "amount": 10, "customer_id": customer.ID})
+
+	if order.ShippingAddressID != nil || order.ShippingAddress != nil || order.BillingAddressID != nil || order.BillingAddress != nil {
+		t.Errorf("order has addresses %v %v without the customer having any", order.ShippingAddressID, order.BillingAddressID)
+	}
+}
+
+func TestCreateOrderRejectsAddressOfOtherCustomer(t *testing.T) {
+	newTestDB(t)
+	alice, bob := createTestCustomer(t, "alice"), createTestCustomer(t, "bob")
+	bobShipping := createTestAddress(t, bob.ID, model.AddressTypeShipping, "Bandung", true)
+
+	rr := serve(t, http.MethodPost, "/order", map[string]any{
+		"order_date":          time.Now(),
+		"amount":              10,
+		"customer_id":         alice.ID,
+		"shipping_address_id": bobShipping.ID,
+	}, controllers.CreateOrder)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d, body %s", rr.Code, http.StatusBadRequest, rr.Body)
+	}
+}
+
+func TestOrderKeepsAddressSnapshot(t *testing.T) {
+	newTestDB(t)
+	customer := createTestCustomer(t, "alice")
+	shipping := createTestAddress(t, customer.ID, model.AddressTypeShipping, "Jakarta", true)
+	order := createTestOrder(t, map[string]any{"order_date": time.Now(), "amount": 10, "customer_id": customer.ID})
+
+	shipping.City = "Bandung"
+	updateTestAddress(t, shipping, model.AddressTypeShipping, true)
+
+	kept := findTestOrder(t, order.ID)
+	if kept.ShippingAddress == nil || kept.ShippingAddress.City != "Jakarta" {
+		t.Errorf("shipping address after the address changed = %+v, want the copy from Jakarta", kept.ShippingAddress)
+	}
+
+	rr := serveRoute(t, http.MethodDelete, "/customer/:id/addresses/:addressId",
+		fmt.Sprintf("/customer/%d/addresses/%d", customer.ID, shipping.ID), nil, controllers.DeleteCustomerAddress)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+
+	kept = findTestOrder(t, order.ID)
+	if kept.ShippingAddressID != nil {
+		t.Errorf("shipping address ID after the address was deleted = %d, want none", *kept.ShippingAddressID)
+	}
+	if kept.ShippingAddress == nil || kept.ShippingAddress.City != "Jakarta" {
+		t.Errorf("shipping address after the address was deleted = %+v, want the copy from Jakarta", kept.ShippingAddress)
+	}
+}
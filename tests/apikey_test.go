@@ -0,0 +1,166 @@
+package tests
+
+import (
+	"dbo-test/internal/apikey"
+	"dbo-test/internal/controllers"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/middlewares"
+	"dbo-test/internal/model"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAPIKeyGenerate(t *testing.T) {
+	key, prefix, err := apikey.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(key, prefix+"_") || !strings.HasPrefix(prefix, "dbo_") {
+		t.Fatalf("key %q does not start with its prefix %q", key, prefix)
+	}
+
+	other, _, err := apikey.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key == other {
+		t.Fatal("Generate returned the same key twice")
+	}
+	if apikey.Hash(key) != apikey.Hash(key) || apikey.Hash(key) == apikey.Hash(other) {
+		t.Fatal("Hash is not a stable, distinct digest of the key")
+	}
+	if strings.Contains(apikey.Hash(key), key) {
+		t.Fatal("Hash contains the key")
+	}
+}
+
+func TestAPIKeyAuthenticateRejectsForeignKeys(t *testing.T) {
+	// Keys that we cannot have issued are rejected without a database lookup.
+	if _, err := apikey.Authenticate("Bearer abc"); !errors.Is(err, apikey.ErrInvalidKey) {
+		t.Fatalf("Authenticate error = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	newTestDB(t)
+
+	rr := serve(t, http.MethodPost, "/api-key", map[string]any{"name": "billing", "scopes": []string{"sales", "readonly"}},
+		controllers.CreateAPIKey)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+	var created struct {
+		Key    string   `json:"key"`
+		Scopes []string `json:"scopes"`
+	}
+	decodeData(t, rr, &created)
+	stored, err := apikey.Authenticate(created.Key)
+	if err != nil {
+		t.Fatalf("Authenticate error = %v", err)
+	}
+	if stored.KeyHash == created.Key || len(apikey.Scopes(stored)) != 2 {
+		t.Errorf("stored key %+v for scopes %v", stored, created.Scopes)
+	}
+}
+
+func TestCreateAPIKeyRejectsAdminScope(t *testing.T) {
+	newTestDB(t)
+
+	rr := serve(t, http.MethodPost, "/api-key", map[string]any{"name": "billing", "scopes": []string{"sales", "admin"}},
+		controllers.CreateAPIKey)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+	if count, err := dal.APIKey.Count(); err != nil || count != 0 {
+		t.Errorf("stored %d keys, error %v", count, err)
+	}
+}
+
+// createTestAPIKey stores a key with the scopes as CreateAPIKey would, without checking them.
+func createTestAPIKey(t *testing.T, scopes string, modify func(*model.APIKey)) string {
+	t.Helper()
+	key, prefix, err := apikey.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := &model.APIKey{Name: "test", Prefix: prefix, KeyHash: apikey.Hash(key), Scopes: scopes, CreatedAt: time.Now()}
+	if modify != nil {
+		modify(stored)
+	}
+	if err := dal.APIKey.Create(stored); err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// serveWithAPIKey sends a request with the key through the API key and RBAC middlewares
+// and returns the status. Requests without a key are rejected like JWTAuthMiddleware would.
+func serveWithAPIKey(t *testing.T, method, path, key string) int {
+	t.Helper()
+	policy := middlewares.Policy{
+		"GET /customer/":  {middlewares.RoleAdmin, middlewares.RoleSales, middlewares.RoleReadOnly},
+		"POST /customer/": {middlewares.RoleAdmin, middlewares.RoleSales},
+		"POST /api-key/":  {middlewares.RoleAdmin},
+		"GET /user/":      {middlewares.RoleAdmin},
+	}
+	noToken := func(c *gin.Context) { c.AbortWithStatus(http.StatusUnauthorized) }
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+	r := gin.New()
+	r.Use(middlewares.APIKeyAuthMiddleware(noToken), middlewares.RBACMiddleware(policy))
+	for route := range policy {
+		method, path, _ := strings.Cut(route, " ")
+		r.Handle(method, path, ok)
+	}
+
+	req := httptest.NewRequest(method, path, nil)
+	if key != "" {
+		req.Header.Set(middlewares.APIKeyHeader, key)
+	}
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+func TestAPIKeyAuthMiddleware(t *testing.T) {
+	newTestDB(t)
+	past := time.Now().Add(-time.Minute)
+	sales := createTestAPIKey(t, "sales", nil)
+	readonly := createTestAPIKey(t, "readonly", nil)
+	admin := createTestAPIKey(t, "admin,readonly", nil)
+	revoked := createTestAPIKey(t, "sales", func(k *model.APIKey) { k.RevokedAt = &past })
+	expired := createTestAPIKey(t, "sales", func(k *model.APIKey) { k.ExpiresAt = &past })
+
+	tests := []struct {
+		name, method, path, key string
+		want                    int
+	}{
+		{"sales key reads", http.MethodGet, "/customer/", sales, http.StatusOK},
+		{"sales key writes", http.MethodPost, "/customer/", sales, http.StatusOK},
+		{"readonly key reads", http.MethodGet, "/customer/", readonly, http.StatusOK},
+		{"readonly key cannot write", http.MethodPost, "/customer/", readonly, http.StatusForbidden},
+		{"sales key cannot create keys", http.MethodPost, "/api-key/", sales, http.StatusForbidden},
+		{"admin scope cannot create keys", http.MethodPost, "/api-key/", admin, http.StatusForbidden},
+		{"admin scope cannot manage users", http.MethodGet, "/user/", admin, http.StatusForbidden},
+		{"admin scope keeps its other scopes", http.MethodGet, "/customer/", admin, http.StatusOK},
+		{"revoked key", http.MethodGet, "/customer/", revoked, http.StatusUnauthorized},
+		{"expired key", http.MethodGet, "/customer/", expired, http.StatusUnauthorized},
+		{"unknown key", http.MethodGet, "/customer/", "dbo_00000000_unknown", http.StatusUnauthorized},
+		{"no key", http.MethodGet, "/customer/", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serveWithAPIKey(t, tt.method, tt.path, tt.key); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"dbo-test/internal/attributes"
+	"errors"
+	"testing"
+)
+
+func TestAttributeDefinitionCheck(t *testing.T) {
+	valid := []attributes.Definition{
+		{Key: "region", Type: attributes.TypeString},
+		{Key: "credit_limit", Type: attributes.TypeNumber},
+		{Key: "tier", Type: attributes.TypeEnum, Options: []string{"gold", "silver"}},
+	}
+	for _, def := range valid {
+		if err := def.Check(); err != nil {
+			t.Errorf("%+v rejected: %v", def, err)
+		}
+	}
+
+	invalid := []attributes.Definition{
+		{Key: "Region", Type: attributes.TypeString},
+		{Key: "1st", Type: attributes.TypeString},
+		{Key: "region", Type: "date"},
+		{Key: "tier", Type: attributes.TypeEnum},
+		{Key: "tier", Type: attributes.TypeEnum, Options: []string{"gold", "gold"}},
+		{Key: "vip", Type: attributes.TypeBoolean, Options: []string{"yes"}},
+	}
+	for _, def := range invalid {
+		if err := def.Check(); err == nil {
+			t.Errorf("%+v accepted", def)
+		}
+	}
+}
+
+func TestAttributesValidate(t *testing.T) {
+	defs := map[string]attributes.Definition{
+		"region":       {Key: "region", Type: attributes.TypeString},
+		"credit_limit": {Key: "credit_limit", Type: attributes.TypeNumber},
+		"vip":          {Key: "vip", Type: attributes.TypeBoolean},
+		"tier":         {Key: "tier", Type: attributes.TypeEnum, Options: []string{"gold", "silver"}},
+	}
+
+	values := map[string]any{"region": "west", "credit_limit": 1500.0, "vip": true, "tier": "gold", "old": nil}
+	var errs attributes.Errors
+	if err := attributes.Validate(defs, values); !errors.As(err, &errs) || !errors.Is(errs["old"], attributes.ErrUndefined) {
+		t.Errorf("undefined key: got %v", err)
+	}
+
+	values = map[string]any{"region": "west", "credit_limit": 1500.0, "vip": nil, "tier": "gold"}
+	if err := attributes.Validate(defs, values); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := values["vip"]; ok {
+		t.Error("nil value not dropped")
+	}
+
+	for key, value := range map[string]any{"region": 5.0, "credit_limit": "1500", "vip": "yes", "tier": "bronze"} {
+		if err := attributes.Validate(defs, map[string]any{key: value}); err == nil {
+			t.Errorf("%s = %v accepted", key, value)
+		}
+	}
+}
+
+func TestAttributeParseFilter(t *testing.T) {
+	number := attributes.Definition{Key: "credit_limit", Type: attributes.TypeNumber}
+	if v, err := number.ParseFilter("1500"); err != nil || v != 1500.0 {
+		t.Errorf("ParseFilter = %v, %v", v, err)
+	}
+	boolean := attributes.Definition{Key: "vip", Type: attributes.TypeBoolean}
+	if v, err := boolean.ParseFilter("true"); err != nil || v != true {
+		t.Errorf("ParseFilter = %v, %v", v, err)
+	}
+	enum := attributes.Definition{Key: "tier", Type: attributes.TypeEnum, Options: []string{"gold"}}
+	if _, err := enum.ParseFilter("bronze"); err == nil {
+		t.Error("unknown enum option accepted")
+	}
+}
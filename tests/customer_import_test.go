@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"bytes"
+	"dbo-test/internal/controllers"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/problem"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type importReport struct {
+	DryRun   bool `json:"dry_run"`
+	Atomic   bool `json:"atomic"`
+	Total    int  `json:"total"`
+	Valid    int  `json:"valid"`
+	Inserted int  `json:"inserted"`
+	Errors   []struct {
+		Line   int      `json:"line"`
+		Errors []string `json:"errors"`
+	} `json:"errors"`
+}
+
+func (r importReport) errorLines() []int {
+	var lines []int
+	for _, e := range r.Errors {
+		lines = append(lines, e.Line)
+	}
+	return lines
+}
+
+// importCustomers posts the upload to ImportCustomers with the content type and query.
+func importCustomers(t *testing.T, query, contentType string, upload []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	r := gin.New()
+	r.POST("/customer/import", controllers.ImportCustomers)
+
+	req := httptest.NewRequest(http.MethodPost, "/customer/import?"+query, bytes.NewReader(upload))
+	req.Header.Set("Content-Type", contentType)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+// importCSV imports the CSV rows, which follow a name, email and phone header, and returns the report.
+func importCSV(t *testing.T, query string, rows ...string) importReport {
+	t.Helper()
+	upload := "name,email,phone\n" + strings.Join(rows, "\n") + "\n"
+	rr := importCustomers(t, query, "text/csv", []byte(upload))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("import status = %d, body %s", rr.Code, rr.Body)
+	}
+	var report importReport
+	decodeData(t, rr, &report)
+	return report
+}
+
+func countCustomers(t *testing.T) int64 {
+	t.Helper()
+	count, err := dal.Customer.Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+// rejectCustomerName makes the database refuse to insert customers with the name.
+func rejectCustomerName(t *testing.T, db *gorm.DB, name string) {
+	t.Helper()
+	err := db.Exec(fmt.Sprintf("CREATE TRIGGER reject_customer BEFORE INSERT ON customers WHEN NEW.name = '%s' "+
+		"BEGIN SELECT RAISE(ABORT, 'customer rejected'); END", name)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+var validImportRows = []string{
+	"Jane,jane@example.com,+62812345678",
+	"Joe,joe@example.com,+62812345679",
+	"Jill,jill@example.com,+62812345670",
+}
+
+func TestImportCustomersDryRun(t *testing.T) {
+	newTestDB(t)
+
+	report := importCSV(t, "dry_run=true", append(validImportRows, ",not an email,+62812345671")...)
+	if !report.DryRun || report.Total != 4 || report.Valid != 3 || report.Inserted != 0 {
+		t.Errorf("report = %+v, want 3 of 4 valid and none inserted", report)
+	}
+	if got := report.errorLines(); len(got) != 1 || got[0] != 5 {
+		t.Errorf("error lines = %v, want [5]", got)
+	}
+	if count := countCustomers(t); count != 0 {
+		t.Errorf("customers = %d after a dry run, want 0", count)
+	}
+}
+
+func TestImportCustomersAtomic(t *testing.T) {
+	db := newTestDB(t)
+
+	report := importCSV(t, "", append(validImportRows, ",not an email,+62812345671")...)
+	if !report.Atomic || report.Valid != 3 || report.Inserted != 0 || len(report.Errors) != 1 {
+		t.Errorf("report = %+v, want 3 valid rows and none inserted", report)
+	}
+	if count := countCustomers(t); count != 0 {
+		t.Fatalf("customers = %d after an invalid row, want 0", count)
+	}
+
+	// The first batch is rolled back when a later one fails.
+	rejectCustomerName(t, db, "Jill")
+	upload := "name,email,phone\n" + strings.Join(validImportRows, "\n") + "\n"
+	rr := importCustomers(t, "batch_size=2", "text/csv", []byte(upload))
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusInternalServerError)
+	}
+	if count := countCustomers(t); count != 0 {
+		t.Errorf("customers = %d after a failed batch, want 0", count)
+	}
+}
+
+func TestImportCustomersCommitsEveryBatch(t *testing.T) {
+	db := newTestDB(t)
+	rejectCustomerName(t, db, "Jill")
+
+	rows := append(validImportRows, "Jack,jack@example.com,+62812345671", ",not an email,+62812345672")
+	report := importCSV(t, "atomic=false&batch_size=2", rows...)
+	if report.Atomic || report.Total != 5 || report.Valid != 4 || report.Inserted != 2 {
+		t.Errorf("report = %+v, want 4 of 5 valid and 2 inserted", report)
+	}
+	// Jill fails the second batch, so Jack is not inserted either.
+	if got := report.errorLines(); len(got) != 3 || got[0] != 4 || got[1] != 5 || got[2] != 6 {
+		t.Errorf("error lines = %v, want [4 5 6]", got)
+	}
+	// The database error is logged, not reported.
+	for _, e := range report.Errors[:2] {
+		if len(e.Errors) != 1 || e.Errors[0] != "could not be saved" {
+			t.Errorf("line %d errors = %q, want \"could not be saved\"", e.Line, e.Errors)
+		}
+	}
+	if count := countCustomers(t); count != 2 {
+		t.Errorf("customers = %d, want 2", count)
+	}
+}
+
+func TestImportCustomersMultipart(t *testing.T) {
+	newTestDB(t)
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", "customers.ndjson")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(`{"name":"Jane","email":"jane@example.com","phone":"+62812345678"}` + "\n"))
+	w.Close()
+
+	rr := importCustomers(t, "", w.FormDataContentType(), body.Bytes())
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+	var report importReport
+	decodeData(t, rr, &report)
+	if report.Inserted != 1 || countCustomers(t) != 1 {
+		t.Errorf("report = %+v, want one customer inserted", report)
+	}
+}
+
+func TestImportCustomersTooLarge(t *testing.T) {
+	newTestDB(t)
+	row := "Jane,jane@example.com,+62812345678\n"
+	upload := "name,email,phone\n" + strings.Repeat(row, (10<<20)/len(row)+1)
+
+	rr := importCustomers(t, "", "text/csv", []byte(upload))
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusRequestEntityTooLarge)
+	}
+	if got := decodeProblem(t, rr).Code; got != problem.CodePayloadTooLarge {
+		t.Errorf("code = %q, want %q", got, problem.CodePayloadTooLarge)
+	}
+	if count := countCustomers(t); count != 0 {
+		t.Errorf("customers = %d, want 0", count)
+	}
+}
+
+func TestImportCustomersValidatesLikeCreate(t *testing.T) {
+	newTestDB(t)
+	long := strings.Repeat("x", 256)
+
+	report := importCSV(t, "atomic=false",
+		" Jane , jane@example.com ,+62 (812) 345-678",
+		long+",long@example.com,+62812345679",
+		"Joe,joe@example.com,0812345670",
+		"Jill,Jill <jill@example.com>,+62812345671",
+	)
+	if report.Inserted != 1 {
+		t.Errorf("report = %+v, want one customer inserted", report)
+	}
+	if got := report.errorLines(); fmt.Sprint(got) != "[3 4 5]" {
+		t.Errorf("error lines = %v, want [3 4 5]", got)
+	}
+
+	customer, err := dal.Customer.First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if customer.Name != "Jane" || customer.Email != "jane@example.com" || customer.Phone != "+62812345678" {
+		t.Errorf("customer = %q %q %q, want normalized values", customer.Name, customer.Email, customer.Phone)
+	}
+}
@@ -0,0 +1,220 @@
+package tests
+
+import (
+	"dbo-test/internal/controllers"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/model"
+	"dbo-test/internal/problem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func mergeTestCustomers(t *testing.T, survivorID, duplicateID int32) *httptest.ResponseRecorder {
+	t.Helper()
+	return serveRoute(t, http.MethodPost, "/customer/:id/merge", fmt.Sprintf("/customer/%d/merge", survivorID),
+		map[string]any{"duplicate_id": duplicateID}, controllers.MergeCustomer)
+}
+
+// mergeTestCustomer merges the duplicate into the survivor and returns the id of the merge.
+func mergeTestCustomer(t *testing.T, survivorID, duplicateID int32) int32 {
+	t.Helper()
+	rr := mergeTestCustomers(t, survivorID, duplicateID)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("merge status = %d, body %s", rr.Code, rr.Body)
+	}
+	var merge struct {
+		ID int32 `json:"id"`
+	}
+	decodeData(t, rr, &merge)
+	return merge.ID
+}
+
+func undoTestMerge(t *testing.T, mergeID int32) *httptest.ResponseRecorder {
+	t.Helper()
+	return serveRoute(t, http.MethodPost, "/customer/merges/:id/undo", fmt.Sprintf("/customer/merges/%d/undo", mergeID),
+		nil, controllers.UndoCustomerMerge)
+}
+
+func createCustomerOrder(t *testing.T, customerID int32) *model.Order {
+	t.Helper()
+	return createTestOrder(t, map[string]any{"order_date": time.Now(), "amount": 10, "customer_id": customerID})
+}
+
+func orderCustomerID(t *testing.T, order *model.Order) int32 {
+	t.Helper()
+	return findTestOrder(t, order.ID).CustomerID
+}
+
+func TestMergeCustomerMovesOrders(t *testing.T) {
+	newTestDB(t)
+	survivor, duplicate := createTestCustomer(t, "alice"), createTestCustomer(t, "alice2")
+	survivorOrder := createCustomerOrder(t, survivor.ID)
+	duplicateOrders := []*model.Order{createCustomerOrder(t, duplicate.ID), createCustomerOrder(t, duplicate.ID)}
+
+	rr := mergeTestCustomers(t, survivor.ID, duplicate.ID)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+	var merge struct {
+		OrderIDs []int32 `json:"order_ids"`
+	}
+	decodeData(t, rr, &merge)
+
+	if len(merge.OrderIDs) != 2 {
+		t.Errorf("order_ids = %v, want the 2 orders of the duplicate", merge.OrderIDs)
+	}
+	for _, order := range append(duplicateOrders, survivorOrder) {
+		if got := orderCustomerID(t, order); got != survivor.ID {
+			t.Errorf("order %d belongs to customer %d, want %d", order.ID, got, survivor.ID)
+		}
+	}
+	if count, err := dal.Customer.Where(dal.Customer.ID.Eq(duplicate.ID)).Count(); err != nil || count != 0 {
+		t.Errorf("duplicate is not in the trash: %d %v", count, err)
+	}
+}
+
+func TestMergeCustomerClearsAddressIDs(t *testing.T) {
+	newTestDB(t)
+	survivor, duplicate := createTestCustomer(t, "alice"), createTestCustomer(t, "alice2")
+	shipping := createTestAddress(t, duplicate.ID, model.AddressTypeShipping, "Jakarta", true)
+	billing := createTestAddress(t, duplicate.ID, model.AddressTypeBilling, "Bandung", true)
+	kept, readdressed := createCustomerOrder(t, duplicate.ID), createCustomerOrder(t, duplicate.ID)
+
+	mergeID := mergeTestCustomer(t, survivor.ID, duplicate.ID)
+
+	for _, order := range []*model.Order{kept, readdressed} {
+		merged := findTestOrder(t, order.ID)
+		if merged.ShippingAddressID != nil || merged.BillingAddressID != nil {
+			t.Errorf("order %d address IDs = %v %v, want none", order.ID, merged.ShippingAddressID, merged.BillingAddressID)
+		}
+		if merged.ShippingAddress == nil || merged.ShippingAddress.City != "Jakarta" ||
+			merged.BillingAddress == nil || merged.BillingAddress.City != "Bandung" {
+			t.Errorf("order %d addresses = %+v %+v, want the copies kept", order.ID, merged.ShippingAddress, merged.BillingAddress)
+		}
+	}
+
+	// An address given to an order after the merge is kept by the undo.
+	survivorShipping := createTestAddress(t, survivor.ID, model.AddressTypeShipping, "Bogor", true)
+	if _, err := dal.Order.Where(dal.Order.ID.Eq(readdressed.ID)).Update(dal.Order.ShippingAddressID, survivorShipping.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if rr := undoTestMerge(t, mergeID); rr.Code != http.StatusOK {
+		t.Fatalf("undo status = %d, body %s", rr.Code, rr.Body)
+	}
+
+	restored := findTestOrder(t, kept.ID)
+	if restored.ShippingAddressID == nil || *restored.ShippingAddressID != shipping.ID ||
+		restored.BillingAddressID == nil || *restored.BillingAddressID != billing.ID {
+		t.Errorf("address IDs = %v %v, want %d %d", restored.ShippingAddressID, restored.BillingAddressID, shipping.ID, billing.ID)
+	}
+	restored = findTestOrder(t, readdressed.ID)
+	if restored.ShippingAddressID == nil || *restored.ShippingAddressID != survivorShipping.ID {
+		t.Errorf("shipping address ID = %v, want the new address %d", restored.ShippingAddressID, survivorShipping.ID)
+	}
+	if restored.BillingAddressID == nil || *restored.BillingAddressID != billing.ID {
+		t.Errorf("billing address ID = %v, want %d", restored.BillingAddressID, billing.ID)
+	}
+}
+
+func TestMergeCustomerErrors(t *testing.T) {
+	newTestDB(t)
+	alice := createTestCustomer(t, "alice")
+
+	if rr := mergeTestCustomers(t, alice.ID, alice.ID); rr.Code != http.StatusBadRequest {
+		t.Errorf("merge into itself: status = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+	if rr := mergeTestCustomers(t, alice.ID, 99); rr.Code != http.StatusNotFound {
+		t.Errorf("merge of an unknown customer: status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+}
+
+func TestUndoCustomerMerge(t *testing.T) {
+	newTestDB(t)
+	survivor, duplicate, other := createTestCustomer(t, "alice"), createTestCustomer(t, "alice2"), createTestCustomer(t, "bob")
+	survivorOrder := createCustomerOrder(t, survivor.ID)
+	returned, moved := createCustomerOrder(t, duplicate.ID), createCustomerOrder(t, duplicate.ID)
+	mergeID := mergeTestCustomer(t, survivor.ID, duplicate.ID)
+	// An order moved on since the merge stays where it is.
+	if _, err := dal.Order.Where(dal.Order.ID.Eq(moved.ID)).Update(dal.Order.CustomerID, other.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	rr := undoTestMerge(t, mergeID)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+
+	if count, err := dal.Customer.Where(dal.Customer.ID.Eq(duplicate.ID)).Count(); err != nil || count != 1 {
+		t.Errorf("duplicate is not restored: %d %v", count, err)
+	}
+	for order, want := range map[*model.Order]int32{survivorOrder: survivor.ID, returned: duplicate.ID, moved: other.ID} {
+		if got := orderCustomerID(t, order); got != want {
+			t.Errorf("order %d belongs to customer %d, want %d", order.ID, got, want)
+		}
+	}
+
+	rr = undoTestMerge(t, mergeID)
+	if rr.Code != http.StatusConflict {
+		t.Errorf("second undo: status = %d, want %d", rr.Code, http.StatusConflict)
+	}
+}
+
+func TestUndoCustomerMergeErrors(t *testing.T) {
+	t.Run("not the latest merge", func(t *testing.T) {
+		newTestDB(t)
+		first, second, duplicate := createTestCustomer(t, "alice"), createTestCustomer(t, "bob"), createTestCustomer(t, "alice2")
+		order := createCustomerOrder(t, duplicate.ID)
+		firstMerge := mergeTestCustomer(t, first.ID, duplicate.ID)
+		// The duplicate is restored from the trash and merged into another customer.
+		if _, err := dal.Customer.Unscoped().Where(dal.Customer.ID.Eq(duplicate.ID)).UpdateSimple(dal.Customer.DeletedAt.Null()); err != nil {
+			t.Fatal(err)
+		}
+		mergeTestCustomer(t, second.ID, duplicate.ID)
+
+		rr := undoTestMerge(t, firstMerge)
+
+		if rr.Code != http.StatusConflict || decodeProblem(t, rr).Code != problem.CodeConflict {
+			t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+		}
+		if got := orderCustomerID(t, order); got != first.ID {
+			t.Errorf("order belongs to customer %d, want %d", got, first.ID)
+		}
+		merge, err := dal.CustomerMerge.Where(dal.CustomerMerge.ID.Eq(firstMerge)).First()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if merge.UndoneAt != nil {
+			t.Error("failed undo is recorded")
+		}
+	})
+
+	t.Run("purged duplicate", func(t *testing.T) {
+		newTestDB(t)
+		survivor, duplicate := createTestCustomer(t, "alice"), createTestCustomer(t, "alice2")
+		order := createCustomerOrder(t, duplicate.ID)
+		mergeID := mergeTestCustomer(t, survivor.ID, duplicate.ID)
+		if _, err := dal.Customer.Unscoped().Where(dal.Customer.ID.Eq(duplicate.ID)).Delete(); err != nil {
+			t.Fatal(err)
+		}
+
+		rr := undoTestMerge(t, mergeID)
+
+		if rr.Code != http.StatusConflict || decodeProblem(t, rr).Code != problem.CodeConflict {
+			t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+		}
+		if got := orderCustomerID(t, order); got != survivor.ID {
+			t.Errorf("order belongs to customer %d, want %d", got, survivor.ID)
+		}
+	})
+
+	t.Run("unknown merge", func(t *testing.T) {
+		newTestDB(t)
+		if rr := undoTestMerge(t, 99); rr.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want %d", rr.Code, http.StatusNotFound)
+		}
+	})
+}
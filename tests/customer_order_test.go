@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"dbo-test/internal/controllers"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/model"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func createDatedOrder(t *testing.T, customerID int32, date string, amount float64) *model.Order {
+	t.Helper()
+	orderDate, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := &model.Order{CustomerID: customerID, OrderDate: orderDate, Amount: amount}
+	if err := dal.Order.Create(order); err != nil {
+		t.Fatal(err)
+	}
+	return order
+}
+
+func TestGetCustomerOrders(t *testing.T) {
+	newTestDB(t)
+	alice, bob := createTestCustomer(t, "alice"), createTestCustomer(t, "bob")
+	createDatedOrder(t, alice.ID, "2024-01-10", 30)
+	createDatedOrder(t, alice.ID, "2024-02-10", 10)
+	createDatedOrder(t, alice.ID, "2024-03-10", 20)
+	createDatedOrder(t, bob.ID, "2024-01-10", 50)
+	get := func(id int32, query string) (int, []model.Order, int) {
+		rr := serveRoute(t, http.MethodGet, "/customer/:id/orders", fmt.Sprintf("/customer/%d/orders?%s", id, query), nil,
+			controllers.GetCustomerOrders)
+		if rr.Code != http.StatusOK {
+			return rr.Code, nil, 0
+		}
+		var page struct {
+			Data         []model.Order `json:"data"`
+			TotalRecords int           `json:"total_records"`
+		}
+		decodeData(t, rr, &page)
+		return rr.Code, page.Data, page.TotalRecords
+	}
+	amounts := func(orders []model.Order) []float64 {
+		var amounts []float64
+		for _, order := range orders {
+			amounts = append(amounts, order.Amount)
+		}
+		return amounts
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []float64
+		total int
+	}{
+		{"sorted by amount", "order=amount%20desc", []float64{30, 20, 10}, 3},
+		{"paged", "order=amount&page=2&pagesize=2", []float64{30}, 3},
+		{"filtered", "order=amount&dateFrom=2024-02-01", []float64{10, 20}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, orders, total := get(alice.ID, tt.query)
+			if code != http.StatusOK {
+				t.Fatalf("status = %d", code)
+			}
+			if got := amounts(orders); fmt.Sprint(got) != fmt.Sprint(tt.want) || total != tt.total {
+				t.Errorf("amounts = %v of %d, want %v of %d", got, total, tt.want, tt.total)
+			}
+		})
+	}
+
+	if code, _, _ := get(alice.ID, "page=abc"); code != http.StatusBadRequest {
+		t.Errorf("invalid page: status = %d, want %d", code, http.StatusBadRequest)
+	}
+	if code, _, _ := get(99, ""); code != http.StatusNotFound {
+		t.Errorf("unknown customer: status = %d, want %d", code, http.StatusNotFound)
+	}
+}
+
+func getCustomerSummary(t *testing.T, id int32, query string) map[string]any {
+	t.Helper()
+	rr := serveRoute(t, http.MethodGet, "/customer/:id/summary", fmt.Sprintf("/customer/%d/summary?%s", id, query), nil,
+		controllers.GetCustomerSummary)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+	var summary map[string]any
+	decodeData(t, rr, &summary)
+	return summary
+}
+
+func TestGetCustomerSummaryWithoutOrders(t *testing.T) {
+	newTestDB(t)
+	alice := createTestCustomer(t, "alice")
+
+	summary := getCustomerSummary(t, alice.ID, "")
+
+	// SUM and AVG of no orders are NULL and reported as 0.
+	want := map[string]any{
+		"customer_id":      float64(alice.ID),
+		"order_count":      float64(0),
+		"total_amount":     float64(0),
+		"average_amount":   float64(0),
+		"first_order_date": nil,
+		"last_order_date":  nil,
+		"lifetime_value":   float64(0),
+	}
+	if fmt.Sprint(summary) != fmt.Sprint(want) {
+		t.Errorf("summary = %v, want %v", summary, want)
+	}
+}
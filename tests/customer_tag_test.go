@@ -0,0 +1,132 @@
+package tests
+
+import (
+	"dbo-test/internal/controllers"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/model"
+	"net/http"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+)
+
+// tagTestCustomer gives the customer the tags, creating the ones that do not exist.
+func tagTestCustomer(t *testing.T, customerID int32, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		tag, err := dal.Tag.Where(dal.Tag.Name.Eq(name)).FirstOrCreate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := dal.CustomerTag.Create(&model.CustomerTag{CustomerID: customerID, TagID: tag.ID, CreatedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// listCustomerNames returns the names of the customers GET /customer answers the query with.
+func listCustomerNames(t *testing.T, query string) []string {
+	t.Helper()
+	rr := serveRoute(t, http.MethodGet, "/customer", "/customer?"+query, nil, controllers.GetMultipleCustomer)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("%s: status = %d, body %s", query, rr.Code, rr.Body)
+	}
+	var page struct {
+		Data []model.Customer `json:"data"`
+	}
+	decodeData(t, rr, &page)
+	names := make([]string, len(page.Data))
+	for i, customer := range page.Data {
+		names[i] = customer.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCustomerTagFilter(t *testing.T) {
+	newTestDB(t)
+	alice, bob := createTestCustomer(t, "alice"), createTestCustomer(t, "bob")
+	createTestCustomer(t, "carol")
+	tagTestCustomer(t, alice.ID, "vip", "wholesale")
+	tagTestCustomer(t, bob.ID, "vip")
+
+	tests := map[string][]string{
+		"tag=vip":                               {"alice", "bob"},
+		"tag=vip,wholesale":                     {"alice", "bob"},
+		"tag=vip&tag=wholesale&tag_match=all":   {"alice"},
+		"tag=VIP&tag=vip&tag_match=all":         {"alice", "bob"},
+		"tag=vip,+Vip+,wholesale&tag_match=all": {"alice"},
+		"tag=unknown":                           {},
+		"tag=vip&tag=unknown&tag_match=all":     {},
+		"tag_match=all":                         {"alice", "bob", "carol"},
+	}
+	for query, want := range tests {
+		if got := listCustomerNames(t, query); !slices.Equal(got, want) {
+			t.Errorf("%s: got %v, want %v", query, got, want)
+		}
+	}
+}
+
+type bulkTagResult struct {
+	Added      int64   `json:"added"`
+	Removed    int64   `json:"removed"`
+	MissingIDs []int32 `json:"missing_ids"`
+}
+
+// bulkTag sends a bulk tag request and returns its counts.
+func bulkTag(t *testing.T, body map[string]any) bulkTagResult {
+	t.Helper()
+	rr := serve(t, http.MethodPost, "/customer/tags/bulk", body, controllers.BulkTagCustomers)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+	var result bulkTagResult
+	decodeData(t, rr, &result)
+	return result
+}
+
+func TestBulkTagCustomers(t *testing.T) {
+	newTestDB(t)
+	alice, bob, trashed := createTestCustomer(t, "alice"), createTestCustomer(t, "bob"), createTestCustomer(t, "trashed")
+	tagTestCustomer(t, alice.ID, "vip")
+	trashTestCustomer(t, trashed, time.Now())
+
+	// alice has vip already, so only her new tag counts.
+	got := bulkTag(t, map[string]any{
+		"customer_ids": []int32{alice.ID, bob.ID, trashed.ID, 999, 999},
+		"add":          []string{"VIP", " vip ", "New"},
+	})
+	if got.Added != 3 || got.Removed != 0 || !slices.Equal(got.MissingIDs, []int32{trashed.ID, 999}) {
+		t.Errorf("add got %+v, want 3 added and %d and 999 missing", got, trashed.ID)
+	}
+	if names := listCustomerNames(t, "tag=vip,new&tag_match=all"); !slices.Equal(names, []string{"alice", "bob"}) {
+		t.Errorf("customers with both tags = %v", names)
+	}
+
+	got = bulkTag(t, map[string]any{
+		"customer_ids": []int32{alice.ID, bob.ID},
+		"add":          []string{"new"},
+		"remove":       []string{"vip", "unknown"},
+	})
+	if got.Added != 0 || got.Removed != 2 || len(got.MissingIDs) != 0 {
+		t.Errorf("remove got %+v, want 0 added and 2 removed", got)
+	}
+	if names := listCustomerNames(t, "tag=vip"); len(names) != 0 {
+		t.Errorf("customers with vip = %v, want none", names)
+	}
+}
+
+func TestBulkTagCustomersNeedsChanges(t *testing.T) {
+	newTestDB(t)
+	customer := createTestCustomer(t, "alice")
+
+	rr := serve(t, http.MethodPost, "/customer/tags/bulk", map[string]any{
+		"customer_ids": []int32{customer.ID},
+		"add":          []string{" "},
+	}, controllers.BulkTagCustomers)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"bytes"
+	"dbo-test/internal/controllers"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/model"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// patchCustomer sends a merge patch to PATCH /customer/:id.
+func patchCustomer(t *testing.T, id int32, patch string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := gin.New()
+	r.PATCH("/customer/:id", controllers.PatchCustomer)
+	req := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/customer/%d", id), bytes.NewBufferString(patch))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestPatchCustomerChecksChangedFieldsOnly(t *testing.T) {
+	newTestDB(t)
+	// Customers saved before phones had to be E.164 can have local numbers.
+	customer := createTestCustomer(t, "alice")
+	if _, err := dal.Customer.Where(dal.Customer.ID.Eq(customer.ID)).Update(dal.Customer.Phone, "0812-3456-7890"); err != nil {
+		t.Fatal(err)
+	}
+
+	rr := patchCustomer(t, customer.ID, `{"name":" Alice Smith "}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+	patched, err := dal.Customer.Where(dal.Customer.ID.Eq(customer.ID)).First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patched.Name != "Alice Smith" || patched.Phone != "0812-3456-7890" {
+		t.Errorf("customer = %q %q, want the new name and the old phone", patched.Name, patched.Phone)
+	}
+
+	rr = patchCustomer(t, customer.ID, `{"phone":"0812"}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+	if errs := decodeProblem(t, rr).Errors; len(errs) != 1 || errs[0].Field != "phone" {
+		t.Errorf("errors = %v, want one with phone", errs)
+	}
+}
+
+func TestDeleteAndRestoreCustomer(t *testing.T) {
+	newTestDB(t)
+	customer := createTestCustomer(t, "alice")
+	path := fmt.Sprintf("/customer/%d", customer.ID)
+
+	rr := serveRoute(t, http.MethodDelete, "/customer/:id", path, nil, controllers.DeleteCustomer)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("delete status = %d, body %s", rr.Code, rr.Body)
+	}
+	if names := listCustomerNames(t, ""); len(names) != 0 {
+		t.Errorf("customers after delete = %v, want none", names)
+	}
+	rr = serveRoute(t, http.MethodGet, "/customer/trash", "/customer/trash", nil, controllers.GetDeletedCustomers)
+	var trash struct {
+		Data []model.Customer `json:"data"`
+	}
+	decodeData(t, rr, &trash)
+	if len(trash.Data) != 1 || trash.Data[0].ID != customer.ID {
+		t.Errorf("trash = %v, want the deleted customer", trash.Data)
+	}
+
+	rr = serveRoute(t, http.MethodPost, "/customer/:id/restore", path+"/restore", nil, controllers.RestoreCustomer)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("restore status = %d, body %s", rr.Code, rr.Body)
+	}
+	if names := listCustomerNames(t, ""); len(names) != 1 || names[0] != "alice" {
+		t.Errorf("customers after restore = %v, want alice", names)
+	}
+
+	// Only customers in the trash can be restored.
+	rr = serveRoute(t, http.MethodPost, "/customer/:id/restore", path+"/restore", nil, controllers.RestoreCustomer)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("second restore status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"dbo-test/internal/dedupe"
+	"reflect"
+	"testing"
+)
+
+func TestDedupeNormalize(t *testing.T) {
+	if got := dedupe.NormalizeEmail(" Jane.Doe+shop@Example.COM "); got != "jane.doe@example.com" {
+		t.Errorf("NormalizeEmail = %q", got)
+	}
+	if got := dedupe.NormalizeEmail("not-an-email"); got != "" {
+		t.Errorf("NormalizeEmail of invalid email = %q", got)
+	}
+	if a, b := dedupe.NormalizePhone("+62 812-3456-7890"), dedupe.NormalizePhone("0812 3456 7890"); a != b || a == "" {
+		t.Errorf("NormalizePhone = %q and %q", a, b)
+	}
+	if got := dedupe.NormalizePhone("12-34"); got != "" {
+		t.Errorf("NormalizePhone of short number = %q", got)
+	}
+	if got := dedupe.NormalizeName("Doe, John"); got != "doe john" {
+		t.Errorf("NormalizeName = %q", got)
+	}
+}
+
+func TestDedupeNameSimilarity(t *testing.T) {
+	if got := dedupe.NameSimilarity("John Doe", "doe, john"); got != 1 {
+		t.Errorf("similarity of reordered names = %v", got)
+	}
+	if got := dedupe.NameSimilarity("Jonathan Smith", "Jonathon Smith"); got < 0.9 {
+		t.Errorf("similarity of misspelled names = %v", got)
+	}
+	if got := dedupe.NameSimilarity("Jane Doe", "Zed Zulu"); got > 0.5 {
+		t.Errorf("similarity of different names = %v", got)
+	}
+}
+
+func TestDedupeFind(t *testing.T) {
+	candidates := []dedupe.Candidate{
+		{ID: 1, Name: "Jane Doe", Email: "jane@example.com", Phone: "+62 812 3456 7890"},
+		{ID: 2, Name: "Jane Doh", Email: "JANE+promo@example.com", Phone: "0812 3456 7890"},
+		{ID: 3, Name: "Bob Stone", Email: "bob@example.com", Phone: "555-0100"},
+		{ID: 4, Name: "Robert Stone", Email: "robert@example.com", Phone: "555 0100"},
+		{ID: 5, Name: "Alice Wong", Email: "alice@example.com", Phone: "0899 1111 2222"},
+	}
+
+	got := dedupe.Find(candidates, 0.85)
+	want := []dedupe.Match{
+		{CustomerID: 1, DuplicateID: 2, Reasons: []string{dedupe.ReasonEmail, dedupe.ReasonName, dedupe.ReasonPhone}, NameScore: 0.875},
+		{CustomerID: 3, DuplicateID: 4, Reasons: []string{dedupe.ReasonPhone}, NameScore: dedupe.NameSimilarity("Bob Stone", "Robert Stone")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Find = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,209 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"dbo-test/internal/controllers"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/export"
+	"dbo-test/internal/model"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func writeExport(t *testing.T, format string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := export.NewWriter(&buf, format, []string{"id", "name", "orderDate", "amount", "createdBy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	createdBy := int32(7)
+	rows := [][]any{
+		{int32(1), "Jane, \"J\" <Doe>", time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), 12.5, &createdBy},
+		{int32(2), "Joe", time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), 3.0, (*int32)(nil)},
+	}
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExportCSV(t *testing.T) {
+	got := string(writeExport(t, export.FormatCSV))
+	want := "id,name,orderDate,amount,createdBy\n" +
+		"1,\"Jane, \"\"J\"\" <Doe>\",2024-05-01T10:00:00Z,12.5,7\n" +
+		"2,Joe,2024-05-02T00:00:00Z,3,\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestExportNDJSON(t *testing.T) {
+	got := string(writeExport(t, export.FormatNDJSON))
+	want := `{"id":1,"name":"Jane, \"J\" <Doe>","orderDate":"2024-05-01T10:00:00Z","amount":12.5,"createdBy":7}` + "\n" +
+		`{"id":2,"name":"Joe","orderDate":"2024-05-02T00:00:00Z","amount":3,"createdBy":null}` + "\n"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+type xlsxCell struct {
+	Type   string `xml:"t,attr"`
+	Value  string `xml:"v"`
+	Inline string `xml:"is>t"`
+}
+
+// readXLSXRows returns the cells of each row of the sheet of a workbook.
+func readXLSXRows(t *testing.T, data []byte) [][]xlsxCell {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sheet []byte
+	for _, f := range archive.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sheet, err = io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sheet == nil {
+		t.Fatal("workbook has no sheet")
+	}
+
+	var worksheet struct {
+		Rows []struct {
+			Cells []xlsxCell `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(sheet, &worksheet); err != nil {
+		t.Fatalf("sheet is not valid XML: %v", err)
+	}
+	rows := make([][]xlsxCell, len(worksheet.Rows))
+	for i, row := range worksheet.Rows {
+		rows[i] = row.Cells
+	}
+	return rows
+}
+
+func TestExportXLSX(t *testing.T) {
+	rows := readXLSXRows(t, writeExport(t, export.FormatXLSX))
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want header and 2 rows", len(rows))
+	}
+	first := rows[1]
+	if first[0].Value != "1" || first[1].Inline != "Jane, \"J\" <Doe>" || first[3].Value != "12.5" || first[4].Value != "7" {
+		t.Errorf("first row = %+v", first)
+	}
+	if last := rows[2][4]; last.Value != "" || last.Inline != "" {
+		t.Errorf("nil value written as %+v", last)
+	}
+}
+
+// writeFormulaExport exports a row of text that spreadsheet applications would read as formulas.
+func writeFormulaExport(t *testing.T, format string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := export.NewWriter(&buf, format, []string{"a", "b", "c", "d", "e", "f"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Write([]any{"=1+1", "+6281234567890", "-2", "@SUM(A1)", "Jane", -3.5}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExportEscapesFormulas(t *testing.T) {
+	t.Run("csv", func(t *testing.T) {
+		got := string(writeFormulaExport(t, export.FormatCSV))
+		want := "a,b,c,d,e,f\n'=1+1,'+6281234567890,'-2,'@SUM(A1),Jane,-3.5\n"
+		if got != want {
+			t.Errorf("got\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("xlsx", func(t *testing.T) {
+		row := readXLSXRows(t, writeFormulaExport(t, export.FormatXLSX))[1]
+		for i, want := range []string{"'=1+1", "'+6281234567890", "'-2", "'@SUM(A1)", "Jane"} {
+			if row[i].Inline != want {
+				t.Errorf("cell %d = %q, want %q", i, row[i].Inline, want)
+			}
+		}
+		if row[5].Value != "-3.5" {
+			t.Errorf("number written as %+v", row[5])
+		}
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		got := string(writeFormulaExport(t, export.FormatNDJSON))
+		want := `{"a":"=1+1","b":"+6281234567890","c":"-2","d":"@SUM(A1)","e":"Jane","f":-3.5}` + "\n"
+		if got != want {
+			t.Errorf("got\n%s\nwant\n%s", got, want)
+		}
+	})
+}
+
+func TestExportOutlastsWriteTimeout(t *testing.T) {
+	db := newTestDB(t)
+	customers := make([]*model.Customer, 2500)
+	for i := range customers {
+		customers[i] = &model.Customer{Name: fmt.Sprintf("customer %d", i), Email: "c@example.com", Phone: "0812345678"}
+	}
+	if err := dal.Customer.CreateInBatches(customers, 500); err != nil {
+		t.Fatal(err)
+	}
+	// Every batch fits in the write timeout of the server, the whole export does not.
+	err := db.Callback().Query().Before("gorm:query").Register("test:slow", func(*gorm.DB) {
+		time.Sleep(150 * time.Millisecond)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.GET("/customer/export", controllers.ExportCustomers)
+	srv := httptest.NewUnstartedServer(r)
+	srv.Config.WriteTimeout = 250 * time.Millisecond
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	resp, err := http.Get(srv.URL + "/customer/export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("export ended early: %v", err)
+	}
+	if lines := bytes.Count(body, []byte("\n")); lines != len(customers)+1 {
+		t.Errorf("lines = %d, want %d", lines, len(customers)+1)
+	}
+}
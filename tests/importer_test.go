@@ -0,0 +1,45 @@
+package tests
+
+import (
+	"dbo-test/internal/importer"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	upload := "Email,Name,Phone,Notes\n" +
+		"jane@example.com,Jane,+62 812 3456 7890,vip\n" +
+		"\"broken,Joe\n"
+
+	rows, rowErrors, err := importer.ReadCSV(strings.NewReader(upload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0] != (importer.Row{Line: 2, Name: "Jane", Email: "jane@example.com", Phone: "+62 812 3456 7890"}) {
+		t.Fatalf("rows = %+v", rows)
+	}
+	if len(rowErrors) != 1 || rowErrors[0].Line != 3 {
+		t.Fatalf("row errors = %+v", rowErrors)
+	}
+
+	if _, _, err := importer.ReadCSV(strings.NewReader("name,email\nJane,jane@example.com\n")); err == nil {
+		t.Error("header without phone column accepted")
+	}
+}
+
+func TestReadNDJSON(t *testing.T) {
+	upload := `{"name":"Jane","email":"jane@example.com","phone":"0812345678"}` + "\n" +
+		"\n" +
+		`{"name":"Joe",` + "\n"
+
+	rows, rowErrors, err := importer.ReadNDJSON(strings.NewReader(upload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Line != 1 || rows[0].Name != "Jane" {
+		t.Fatalf("rows = %+v", rows)
+	}
+	if len(rowErrors) != 1 || rowErrors[0].Line != 3 {
+		t.Fatalf("row errors = %+v", rowErrors)
+	}
+}
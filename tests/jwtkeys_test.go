@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"dbo-test/internal/jwtkeys"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func writeRSAKey(t *testing.T, dir, kid string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, kid, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+}
+
+func writeECKey(t *testing.T, dir, kid string) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, kid, "EC PRIVATE KEY", der)
+	return key
+}
+
+func writePEM(t *testing.T, dir, kid, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, kid+".pem"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func signTestToken(t *testing.T, keys *jwtkeys.KeySet) string {
+	t.Helper()
+	token, err := keys.Sign(jwt.MapClaims{"sub": "1", "exp": time.Now().Add(time.Minute).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func verifyTestToken(keys *jwtkeys.KeySet, token string) error {
+	_, err := jwt.Parse(token, keys.Keyfunc)
+	return err
+}
+
+func TestKeySetRotation(t *testing.T) {
+	dir := t.TempDir()
+	oldKey := writeECKey(t, dir, "2024-05")
+
+	keys, err := jwtkeys.NewDir(dir, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer keys.Close()
+
+	oldToken := signTestToken(t, keys)
+	if err := verifyTestToken(keys, oldToken); err != nil {
+		t.Fatalf("token of the active key: %v", err)
+	}
+
+	// Add a newer key and keep only the public half of the old one.
+	writeRSAKey(t, dir, "2024-06")
+	der, err := x509.MarshalPKIXPublicKey(&oldKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, dir, "2024-05", "PUBLIC KEY", der)
+	if err := keys.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := keys.ActiveKID(); got != "2024-06" {
+		t.Fatalf("active kid = %q, want 2024-06", got)
+	}
+	newToken := signTestToken(t, keys)
+	parsed, _ := jwt.Parse(newToken, keys.Keyfunc)
+	if parsed == nil || parsed.Header["alg"] != "RS256" || parsed.Header["kid"] != "2024-06" {
+		t.Fatalf("new token header = %v, want RS256 signed with 2024-06", parsed)
+	}
+	if err := verifyTestToken(keys, oldToken); err != nil {
+		t.Fatalf("token of the retiring key: %v", err)
+	}
+
+	jwks := keys.JWKS()
+	if len(jwks.Keys) != 2 || jwks.Keys[0].Kty != "EC" || jwks.Keys[1].Kty != "RSA" {
+		t.Fatalf("JWKS = %+v, want the EC and RSA keys", jwks.Keys)
+	}
+
+	// Deleting the file retires the old key completely.
+	if err := os.Remove(filepath.Join(dir, "2024-05.pem")); err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyTestToken(keys, oldToken); err == nil {
+		t.Fatal("token of a retired key was accepted")
+	}
+	if err := verifyTestToken(keys, newToken); err != nil {
+		t.Fatalf("token of the active key: %v", err)
+	}
+}
+
+func TestKeySetRejectsAlgorithmMismatch(t *testing.T) {
+	dir := t.TempDir()
+	writeRSAKey(t, dir, "rsa")
+	keys, err := jwtkeys.NewDir(dir, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer keys.Close()
+
+	// An HS256 token naming the RSA key must not be verified with the public key as secret.
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1"})
+	forged.Header["kid"] = "rsa"
+	signed, err := forged.SignedString([]byte("anything"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyTestToken(keys, signed); err == nil {
+		t.Fatal("HS256 token was accepted by an RS256 key")
+	}
+}
+
+func TestKeySetHMACFallback(t *testing.T) {
+	keys := jwtkeys.NewHMAC([]byte("secret"))
+	token := signTestToken(t, keys)
+	if err := verifyTestToken(keys, token); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(keys.JWKS().Keys); got != 0 {
+		t.Fatalf("JWKS published %d keys for an HMAC secret", got)
+	}
+}
@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"dbo-test/internal/controllers"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/model"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// createLoginLog writes a login attempt of the user at the given time.
+func createLoginLog(t *testing.T, user *model.User, status, ip string, at time.Time) *model.LoginLog {
+	t.Helper()
+	entry := &model.LoginLog{UserID: &user.ID, Email: &user.Email, Status: status, IP: &ip, LoginTime: at}
+	if err := dal.LoginLog.Create(entry); err != nil {
+		t.Fatal(err)
+	}
+	return entry
+}
+
+func TestGetLoginData(t *testing.T) {
+	newTestDB(t)
+	alice, bob := createPasswordUser(t, "alice@example.com"), createPasswordUser(t, "bob@example.com")
+	createLoginLog(t, alice, "success", "10.0.0.1", time.Date(2024, 1, 10, 9, 0, 0, 0, time.UTC))
+	createLoginLog(t, alice, "failed", "10.0.0.2", time.Date(2024, 2, 10, 9, 0, 0, 0, time.UTC))
+	createLoginLog(t, alice, "locked", "10.0.0.2", time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC))
+	createLoginLog(t, bob, "success", "10.0.0.3", time.Date(2024, 4, 10, 9, 0, 0, 0, time.UTC))
+	get := func(query string) (int, []model.LoginLog, int) {
+		rr := serveRoute(t, http.MethodGet, "/login-data", "/login-data?"+query, nil, controllers.GetLoginData)
+		if rr.Code != http.StatusOK {
+			return rr.Code, nil, 0
+		}
+		var page struct {
+			Data         []model.LoginLog `json:"data"`
+			TotalRecords int              `json:"total_records"`
+		}
+		decodeData(t, rr, &page)
+		return rr.Code, page.Data, page.TotalRecords
+	}
+	months := func(entries []model.LoginLog) []time.Month {
+		var months []time.Month
+		for _, entry := range entries {
+			months = append(months, entry.LoginTime.UTC().Month())
+		}
+		return months
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []time.Month
+		total int
+	}{
+		{"newest first", "", []time.Month{4, 3, 2, 1}, 4},
+		{"paged", "order=login_time&page=2&pagesize=3", []time.Month{4}, 4},
+		{"by user", fmt.Sprintf("user_id=%d&order=login_time", alice.ID), []time.Month{1, 2, 3}, 3},
+		{"by status", "status=failed", []time.Month{2}, 1},
+		{"by ip", "ip=10.0.0.2&order=login_time", []time.Month{2, 3}, 2},
+		{"by date", "dateFrom=2024-02-01&dateTo=2024-03-31&order=login_time", []time.Month{2, 3}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, entries, total := get(tt.query)
+			if code != http.StatusOK {
+				t.Fatalf("status = %d", code)
+			}
+			if got := months(entries); fmt.Sprint(got) != fmt.Sprint(tt.want) || total != tt.total {
+				t.Errorf("months = %v of %d, want %v of %d", got, total, tt.want, tt.total)
+			}
+		})
+	}
+
+	for _, query := range []string{"status=unknown", "user_id=alice", "dateFrom=yesterday", "page=first"} {
+		if code, _, _ := get(query); code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want %d", query, code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestGetLoginSummary(t *testing.T) {
+	newTestDB(t)
+	now := time.Now().Truncate(time.Second)
+	alice := createPasswordUser(t, "alice@example.com")
+	bob := createPasswordUser(t, "bob@example.com")
+	carol := createPasswordUser(t, "carol@example.com")
+	createLoginLog(t, alice, "failed", "10.0.0.1", now.Add(-30*time.Hour))
+	lastLogin := createLoginLog(t, alice, "success", "10.0.0.1", now.Add(-2*time.Hour))
+	createLoginLog(t, alice, "failed", "10.0.0.1", now.Add(-time.Hour))
+	lastFailure := createLoginLog(t, alice, "locked", "10.0.0.1", now.Add(-10*time.Minute))
+	bobLogin := createLoginLog(t, bob, "success", "10.0.0.2", now.Add(-time.Minute))
+	lockedUntil := now.Add(time.Hour)
+	if _, err := dal.User.Where(dal.User.ID.Eq(alice.ID)).Update(dal.User.LockedUntil, lockedUntil); err != nil {
+		t.Fatal(err)
+	}
+	get := func(query string) ([]map[string]any, int) {
+		t.Helper()
+		rr := serveRoute(t, http.MethodGet, "/login-data/summary", "/login-data/summary?"+query, nil, controllers.GetLoginSummary)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+		}
+		var page struct {
+			Data         []map[string]any `json:"data"`
+			TotalRecords int              `json:"total_records"`
+		}
+		decodeData(t, rr, &page)
+		return page.Data, page.TotalRecords
+	}
+	timeField := func(summary map[string]any, field string) *time.Time {
+		t.Helper()
+		s, ok := summary[field].(string)
+		if !ok {
+			return nil
+		}
+		at, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &at
+	}
+	expectTime := func(summary map[string]any, field string, want *time.Time) {
+		t.Helper()
+		got := timeField(summary, field)
+		if (got == nil) != (want == nil) || got != nil && !got.Equal(*want) {
+			t.Errorf("%s %s = %v, want %v", summary["email"], field, got, want)
+		}
+	}
+
+	summaries, total := get("")
+	if len(summaries) != 3 || total != 3 {
+		t.Fatalf("summaries = %d of %d, want 3 of 3", len(summaries), total)
+	}
+	expectTime(summaries[0], "last_login", &lastLogin.LoginTime)
+	expectTime(summaries[0], "last_failure", &lastFailure.LoginTime)
+	expectTime(summaries[0], "locked_until", &lockedUntil)
+	if got := summaries[0]["failures_last_24h"]; got != float64(2) {
+		t.Errorf("alice failures_last_24h = %v, want 2", got)
+	}
+	expectTime(summaries[1], "last_login", &bobLogin.LoginTime)
+	expectTime(summaries[1], "last_failure", nil)
+	expectTime(summaries[1], "locked_until", nil)
+	expectTime(summaries[2], "last_login", nil)
+	if got := summaries[2]["failures_last_24h"]; got != float64(0) {
+		t.Errorf("carol failures_last_24h = %v, want 0", got)
+	}
+
+	summaries, total = get("page=2&pagesize=2")
+	if len(summaries) != 1 || total != 3 || summaries[0]["email"] != carol.Email {
+		t.Errorf("page 2 = %v of %d, want carol of 3", summaries, total)
+	}
+	summaries, total = get("email=bob")
+	if len(summaries) != 1 || total != 1 || summaries[0]["email"] != bob.Email {
+		t.Errorf("filtered = %v of %d, want bob of 1", summaries, total)
+	}
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"dbo-test/internal/controllers"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/jwtkeys"
+	"dbo-test/internal/model"
+	"dbo-test/internal/password"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// createPasswordUser creates a verified user with the password testPassword.
+func createPasswordUser(t *testing.T, email string) *model.User {
+	t.Helper()
+	hash, err := password.Hasher{Algorithm: password.AlgorithmBcrypt, BcryptCost: 4}.Hash(testPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	user := &model.User{Email: email, Password: hash, CreatedAt: now, EmailVerifiedAt: &now}
+	if err := dal.User.Create(user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestLoginLocksAccountAfterFailures(t *testing.T) {
+	newTestDB(t)
+	setTokenEnv(t)
+	t.Setenv("LOGIN_MAX_FAILED_ATTEMPTS", "3")
+	t.Setenv("LOGIN_LOCKOUT_DURATION", "15")
+	user := createPasswordUser(t, "alice@example.com")
+	handler := controllers.LoginHandler(newTestGuard(t), jwtkeys.NewHMAC([]byte("secret")))
+	login := func(pass string) int {
+		return serve(t, http.MethodPost, "/auth/login", map[string]string{"email": user.Email, "password": pass}, handler).Code
+	}
+
+	for i := 0; i < 2; i++ {
+		if code := login("wrong password"); code != http.StatusBadRequest {
+			t.Fatalf("failure %d: status = %d, want %d", i+1, code, http.StatusBadRequest)
+		}
+	}
+	if stored := findTestUser(t, user.Email); stored.FailedLoginAttempts != 2 || stored.LockedUntil != nil {
+		t.Fatalf("after 2 failures: attempts = %d, locked until %v", stored.FailedLoginAttempts, stored.LockedUntil)
+	}
+
+	login("wrong password")
+	if stored := findTestUser(t, user.Email); stored.FailedLoginAttempts != 0 || stored.LockedUntil == nil {
+		t.Fatalf("after 3 failures: attempts = %d, locked until %v", stored.FailedLoginAttempts, stored.LockedUntil)
+	}
+	if code := login(testPassword); code != http.StatusLocked {
+		t.Errorf("locked account: status = %d, want %d", code, http.StatusLocked)
+	}
+}
+
+func TestLoginCountsConcurrentFailures(t *testing.T) {
+	db := newTestDB(t)
+	t.Setenv("LOGIN_MAX_FAILED_ATTEMPTS", "3")
+	t.Setenv("LOGIN_LOCKOUT_DURATION", "15")
+	user := createPasswordUser(t, "alice@example.com")
+	// Two concurrent failures are counted after the login has read the user.
+	var once sync.Once
+	err := db.Callback().Query().After("gorm:query").Register("test:concurrent_failures", func(tx *gorm.DB) {
+		if tx.Statement.Table == model.TableNameUser {
+			once.Do(func() {
+				tx.Session(&gorm.Session{NewDB: true}).Exec("UPDATE users SET failed_login_attempts = 2 WHERE id = ?", user.ID)
+			})
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := controllers.LoginHandler(newTestGuard(t), jwtkeys.NewHMAC([]byte("secret")))
+
+	serve(t, http.MethodPost, "/auth/login", map[string]string{"email": user.Email, "password": "wrong password"}, handler)
+
+	if stored := findTestUser(t, user.Email); stored.LockedUntil == nil {
+		t.Errorf("account is not locked after the third failure, attempts = %d", stored.FailedLoginAttempts)
+	}
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"dbo-test/internal/loginguard"
+	"testing"
+	"time"
+)
+
+func TestGuardDelayGrowsWithFailures(t *testing.T) {
+	guard := loginguard.NewGuard(time.Second, time.Minute, time.Hour)
+	defer guard.Close()
+
+	if wait := guard.Wait("jane@example.com", "10.0.0.1"); wait != 0 {
+		t.Fatalf("got wait %v before any failure", wait)
+	}
+
+	var previous time.Duration
+	for i := 0; i < 4; i++ {
+		guard.Failure("jane@example.com", "10.0.0.1")
+		wait := guard.Wait("jane@example.com", "10.0.0.1")
+		if wait <= previous {
+			t.Fatalf("wait did not grow after failure %d: %v <= %v", i+1, wait, previous)
+		}
+		previous = wait
+	}
+
+	// 4 failures: 1s, 2s, 4s, 8s.
+	if previous > 8*time.Second {
+		t.Errorf("got wait %v, want at most 8s", previous)
+	}
+}
+
+func TestGuardTracksEmailAndIPSeparately(t *testing.T) {
+	guard := loginguard.NewGuard(time.Minute, time.Hour, time.Hour)
+	defer guard.Close()
+
+	guard.Failure("Jane@Example.com", "10.0.0.1")
+
+	if wait := guard.Wait("jane@example.com", "10.0.0.2"); wait == 0 {
+		t.Error("email was not throttled from another IP")
+	}
+	if wait := guard.Wait("john@example.com", "10.0.0.1"); wait == 0 {
+		t.Error("IP was not throttled for another email")
+	}
+	if wait := guard.Wait("john@example.com", "10.0.0.2"); wait != 0 {
+		t.Errorf("unrelated email and IP got wait %v", wait)
+	}
+
+	guard.Reset("jane@example.com")
+	if wait := guard.Wait("jane@example.com", "10.0.0.2"); wait != 0 {
+		t.Errorf("email still throttled after reset: %v", wait)
+	}
+	if wait := guard.Wait("john@example.com", "10.0.0.1"); wait == 0 {
+		t.Error("reset of the email also cleared the IP")
+	}
+}
+
+func TestGuardDelayIsCapped(t *testing.T) {
+	guard := loginguard.NewGuard(time.Second, 5*time.Second, time.Hour)
+	defer guard.Close()
+
+	for i := 0; i < 20; i++ {
+		guard.Failure("jane@example.com", "10.0.0.1")
+	}
+	if wait := guard.Wait("jane@example.com", "10.0.0.1"); wait > 5*time.Second {
+		t.Errorf("got wait %v, want at most 5s", wait)
+	}
+}
+
+func TestGuardFromEnvUsesPrefix(t *testing.T) {
+	t.Setenv("REGISTER_BACKOFF_BASE", "30")
+	t.Setenv("REGISTER_BACKOFF_MAX", "60")
+
+	guard := loginguard.FromEnv("REGISTER")
+	defer guard.Close()
+
+	guard.Failure("new@example.com", "10.0.0.3")
+	if wait := guard.Wait("new@example.com", "10.0.0.3"); wait <= 29*time.Second || wait > 30*time.Second {
+		t.Fatalf("got wait %v after one attempt, want 30s", wait)
+	}
+	guard.Failure("new@example.com", "10.0.0.3")
+	guard.Failure("new@example.com", "10.0.0.3")
+	if wait := guard.Wait("new@example.com", "10.0.0.3"); wait > 60*time.Second {
+		t.Fatalf("got wait %v, want at most the 60s maximum", wait)
+	}
+}
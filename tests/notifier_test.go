@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"dbo-test/internal/notifier"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSMTPServer accepts mail over plain SMTP on localhost and keeps the received messages.
+type fakeSMTPServer struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	messages []receivedMail
+}
+
+type receivedMail struct {
+	From string
+	To   []string
+	Data string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTPServer{listener: listener}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *fakeSMTPServer) hostPort() (string, string) {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return host, port
+}
+
+func (s *fakeSMTPServer) received() []receivedMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]receivedMail(nil), s.messages...)
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost fake SMTP")
+	var mail receivedMail
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimSpace(line)
+		upper := strings.ToUpper(cmd)
+
+		switch {
+		case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			mail = receivedMail{From: strings.Trim(cmd[len("MAIL FROM:"):], "<> ")}
+			reply("250 OK")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			mail.To = append(mail.To, strings.Trim(cmd[len("RCPT TO:"):], "<> "))
+			reply("250 OK")
+		case upper == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				dataLine, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			mail.Data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, mail)
+			s.mu.Unlock()
+			reply("250 OK")
+		case upper == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	host, port := server.hostPort()
+
+	n := notifier.NewSMTPNotifier(notifier.SMTPConfig{
+		Host: host,
+		Port: port,
+		From: "no-reply@dbo.local",
+	})
+
+	err := n.Notify(context.Background(), notifier.Message{
+		To:      "jane@example.com",
+		Subject: "Reset your password",
+		Body:    "first line\nsecond line",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := server.received()
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	mail := messages[0]
+	if mail.From != "no-reply@dbo.local" {
+		t.Errorf("got sender %q", mail.From)
+	}
+	if len(mail.To) != 1 || mail.To[0] != "jane@example.com" {
+		t.Errorf("got recipients %v", mail.To)
+	}
+	if !strings.Contains(mail.Data, "Subject: Reset your password\r\n") {
+		t.Errorf("subject header missing in %q", mail.Data)
+	}
+	if !strings.Contains(mail.Data, "first line\r\nsecond line") {
+		t.Errorf("body missing in %q", mail.Data)
+	}
+}
+
+func TestSMTPNotifierRejectsHeaderInjection(t *testing.T) {
+	n := notifier.NewSMTPNotifier(notifier.SMTPConfig{Host: "127.0.0.1", Port: "1"})
+
+	err := n.Notify(context.Background(), notifier.Message{
+		To:      "jane@example.com\r\nBcc: someone@example.com",
+		Subject: "hello",
+	})
+	if err == nil {
+		t.Error("expected an error for a recipient containing a line break")
+	}
+}
@@ -0,0 +1,231 @@
+package tests
+
+import (
+	"context"
+	"dbo-test/internal/controllers"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/jwtkeys"
+	"dbo-test/internal/oidc"
+	"dbo-test/internal/problem"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	mockClientID     = "dbo-api"
+	mockClientSecret = "s3cret"
+	mockCode         = "valid-code"
+)
+
+// mockIssuer is a local OpenID Connect provider that issues an ID token with the
+// configured claims for mockCode.
+type mockIssuer struct {
+	*httptest.Server
+	keys   *jwtkeys.KeySet
+	claims jwt.MapClaims
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	dir := t.TempDir()
+	writeRSAKey(t, dir, "idp-key")
+	keys, err := jwtkeys.NewDir(dir, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &mockIssuer{keys: keys}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.URL,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(m.keys.JWKS())
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		if r.Method != http.MethodPost || user != mockClientID || password != mockClientSecret ||
+			r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("code") != mockCode {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		idToken, err := m.keys.Sign(m.claims)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idToken, "token_type": "Bearer"})
+	})
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+
+	m.claims = jwt.MapClaims{
+		"iss":            m.URL,
+		"aud":            mockClientID,
+		"sub":            "staff-42",
+		"email":          "staff@example.com",
+		"email_verified": true,
+		"nonce":          "n-0S6",
+		"exp":            time.Now().Add(time.Minute).Unix(),
+	}
+	return m
+}
+
+func (m *mockIssuer) provider() *oidc.Provider {
+	return oidc.NewProvider(oidc.Config{
+		Issuer:       m.URL,
+		ClientID:     mockClientID,
+		ClientSecret: mockClientSecret,
+		RedirectURL:  "http://localhost/auth/oidc/callback",
+		Scopes:       []string{"openid", "email"},
+	}, m.Client())
+}
+
+func TestOIDCAuthCodeURL(t *testing.T) {
+	issuer := newMockIssuer(t)
+
+	authURL, err := issuer.provider().AuthCodeURL(context.Background(), "state-1", "nonce-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	if !strings.HasPrefix(authURL, issuer.URL+"/authorize?") || query.Get("client_id") != mockClientID ||
+		query.Get("response_type") != "code" || query.Get("state") != "state-1" ||
+		query.Get("nonce") != "nonce-1" || query.Get("scope") != "openid email" {
+		t.Fatalf("unexpected authorization URL %s", authURL)
+	}
+}
+
+func TestOIDCExchange(t *testing.T) {
+	issuer := newMockIssuer(t)
+
+	identity, err := issuer.provider().Exchange(context.Background(), mockCode, "n-0S6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := oidc.Identity{Issuer: issuer.URL, Subject: "staff-42", Email: "staff@example.com", EmailVerified: true}
+	if *identity != want {
+		t.Fatalf("identity = %+v, want %+v", *identity, want)
+	}
+}
+
+func TestOIDCExchangeRejectsInvalidTokens(t *testing.T) {
+	tests := map[string]struct {
+		claim string
+		value interface{}
+		code  string
+		nonce string
+	}{
+		"wrong code":     {code: "stolen-code", nonce: "n-0S6"},
+		"wrong nonce":    {code: mockCode, nonce: "replayed"},
+		"other audience": {claim: "aud", value: "other-client", code: mockCode, nonce: "n-0S6"},
+		"other issuer":   {claim: "iss", value: "https://evil.example.com", code: mockCode, nonce: "n-0S6"},
+		"expired":        {claim: "exp", value: time.Now().Add(-time.Minute).Unix(), code: mockCode, nonce: "n-0S6"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			issuer := newMockIssuer(t)
+			if tt.claim != "" {
+				issuer.claims[tt.claim] = tt.value
+			}
+			if _, err := issuer.provider().Exchange(context.Background(), tt.code, tt.nonce); err == nil {
+				t.Fatal("Exchange succeeded")
+			}
+		})
+	}
+}
+
+// oidcCallback calls the callback handler as the browser would after logging in at the issuer.
+func oidcCallback(t *testing.T, issuer *mockIssuer, keys *jwtkeys.KeySet, query url.Values) *httptest.ResponseRecorder {
+	t.Helper()
+	state, err := keys.Sign(jwt.MapClaims{"typ": "oidc_state", "nonce": "n-0S6", "exp": time.Now().Add(time.Minute).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	query.Set("state", state)
+
+	r := gin.New()
+	r.GET("/auth/oidc/callback", controllers.OIDCCallbackHandler(issuer.provider(), newTestGuard(t), keys))
+	req := httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?"+query.Encode(), nil)
+	req.AddCookie(&http.Cookie{Name: "oidc_state", Value: state})
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestOIDCCallbackAsksForSecondFactor(t *testing.T) {
+	newTestDB(t)
+	setTokenEnv(t)
+	keys := jwtkeys.NewHMAC([]byte("secret"))
+	createTwoFactorUser(t, "staff@example.com")
+
+	rr := oidcCallback(t, newMockIssuer(t), keys, url.Values{"code": {mockCode}})
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+	var resp struct {
+		TwoFactorRequired bool   `json:"two_factor_required"`
+		ChallengeToken    string `json:"challenge_token"`
+		AccessToken       string `json:"access_token"`
+	}
+	decodeData(t, rr, &resp)
+	if !resp.TwoFactorRequired || resp.ChallengeToken == "" || resp.AccessToken != "" {
+		t.Errorf("response = %+v, want only a challenge token", resp)
+	}
+}
+
+func TestOIDCCallbackRejectsLockedAccount(t *testing.T) {
+	newTestDB(t)
+	setTokenEnv(t)
+	keys := jwtkeys.NewHMAC([]byte("secret"))
+	user := createPasswordUser(t, "staff@example.com")
+	if _, err := dal.User.Where(dal.User.ID.Eq(user.ID)).Update(dal.User.LockedUntil, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	rr := oidcCallback(t, newMockIssuer(t), keys, url.Values{"code": {mockCode}})
+
+	if rr.Code != http.StatusLocked || decodeProblem(t, rr).Code != problem.CodeAccountLocked {
+		t.Errorf("status = %d, body %s", rr.Code, rr.Body)
+	}
+}
+
+func TestOIDCCallbackHidesProviderErrors(t *testing.T) {
+	newTestDB(t)
+	keys := jwtkeys.NewHMAC([]byte("secret"))
+	issuer := newMockIssuer(t)
+
+	for name, query := range map[string]url.Values{
+		"provider error": {"error": {"access_denied <b>details</b>"}},
+		"invalid code":   {"code": {"stolen-code"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr := oidcCallback(t, issuer, keys, query)
+			if rr.Code != http.StatusUnauthorized {
+				t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+			}
+			if got := decodeProblem(t, rr).Detail; got != "cannot verify identity" {
+				t.Errorf("detail = %q", got)
+			}
+		})
+	}
+}
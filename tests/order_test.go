@@ -0,0 +1,40 @@
+package tests
+
+import (
+	"dbo-test/internal/controllers"
+	"dbo-test/internal/model"
+	"dbo-test/internal/problem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func deleteTestOrder(t *testing.T, id int32) *httptest.ResponseRecorder {
+	t.Helper()
+	return serveRoute(t, http.MethodDelete, "/order/:id", fmt.Sprintf("/order/%d", id), nil, controllers.DeleteOrder)
+}
+
+func TestDeleteOrder(t *testing.T) {
+	db := newTestDB(t)
+	customer := createTestCustomer(t, "alice")
+	order := createTestOrder(t, map[string]any{"order_date": time.Now(), "amount": 10, "customer_id": customer.ID})
+
+	if rr := deleteTestOrder(t, order.ID); rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+	rr := deleteTestOrder(t, order.ID)
+	if rr.Code != http.StatusNotFound || decodeProblem(t, rr).Code != problem.CodeNotFound {
+		t.Errorf("deleted order: status = %d, body %s", rr.Code, rr.Body)
+	}
+
+	// A failing delete is an error, not a missing order.
+	if err := db.Migrator().DropTable(&model.Order{}); err != nil {
+		t.Fatal(err)
+	}
+	rr = deleteTestOrder(t, order.ID)
+	if rr.Code != http.StatusInternalServerError || decodeProblem(t, rr).Code != problem.CodeInternal {
+		t.Errorf("failed delete: status = %d, body %s", rr.Code, rr.Body)
+	}
+}
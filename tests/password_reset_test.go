@@ -0,0 +1,164 @@
+package tests
+
+import (
+	"dbo-test/internal/controllers"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/jwtkeys"
+	"dbo-test/internal/loginguard"
+	"dbo-test/internal/notifier"
+	"dbo-test/internal/password"
+	"dbo-test/internal/problem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const resetPassword = "a different long passphrase"
+
+// requestPasswordReset asks for a reset link for the email and returns the token it carries.
+func requestPasswordReset(t *testing.T, email string) string {
+	t.Helper()
+	t.Setenv("PASSWORD_RESET_EXPIRE", "30")
+	t.Setenv("PASSWORD_RESET_URL", "https://example.com/reset")
+	n := &recordingNotifier{}
+
+	rr := serve(t, http.MethodPost, "/auth/password/forgot", map[string]string{"email": email},
+		controllers.ForgotPasswordHandler(n, newTestGuard(t)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("forgot status = %d, body %s", rr.Code, rr.Body)
+	}
+	sent := waitForMessages(t, n, 1)
+	if len(sent) != 1 || sent[0].To != email {
+		t.Fatalf("sent = %+v, want one reset link to %s", sent, email)
+	}
+	return verificationToken(t, sent[0])
+}
+
+// waitForMessages waits until the notifier has received count messages, which handlers may
+// send after responding, and returns what it received.
+func waitForMessages(t *testing.T, n *recordingNotifier, count int) []notifier.Message {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(n.sent()) < count && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return n.sent()
+}
+
+func resetTestPassword(t *testing.T, token string) *httptest.ResponseRecorder {
+	t.Helper()
+	return serve(t, http.MethodPost, "/auth/password/reset", map[string]string{"token": token, "new_password": resetPassword},
+		controllers.ResetPasswordHandler)
+}
+
+func expectInvalidResetToken(t *testing.T, rr *httptest.ResponseRecorder) {
+	t.Helper()
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d, body %s", rr.Code, http.StatusBadRequest, rr.Body)
+	}
+	if got := decodeProblem(t, rr).Code; got != problem.CodeInvalidToken {
+		t.Errorf("code = %q, want %q", got, problem.CodeInvalidToken)
+	}
+}
+
+func TestForgotPasswordUnknownEmail(t *testing.T) {
+	newTestDB(t)
+	t.Setenv("PASSWORD_RESET_EXPIRE", "30")
+	user := createPasswordUser(t, "alice@example.com")
+	n := &recordingNotifier{}
+	handler := controllers.ForgotPasswordHandler(n, newTestGuard(t))
+
+	known := serve(t, http.MethodPost, "/auth/password/forgot", map[string]string{"email": user.Email}, handler)
+	unknown := serve(t, http.MethodPost, "/auth/password/forgot", map[string]string{"email": "nobody@example.com"}, handler)
+	if known.Code != http.StatusOK || unknown.Code != known.Code || unknown.Body.String() != known.Body.String() {
+		t.Errorf("response = %d %s for a known email and %d %s for an unknown one",
+			known.Code, known.Body, unknown.Code, unknown.Body)
+	}
+	if sent := waitForMessages(t, n, 1); len(sent) != 1 || sent[0].To != user.Email {
+		t.Errorf("sent = %+v, want one reset link to %s", sent, user.Email)
+	}
+}
+
+func TestForgotPasswordIsThrottled(t *testing.T) {
+	newTestDB(t)
+	t.Setenv("PASSWORD_RESET_EXPIRE", "30")
+	user := createPasswordUser(t, "alice@example.com")
+	guard := loginguard.NewGuard(time.Minute, time.Hour, time.Hour)
+	t.Cleanup(func() { guard.Close() })
+	n := &recordingNotifier{}
+	handler := controllers.ForgotPasswordHandler(n, guard)
+
+	first := serve(t, http.MethodPost, "/auth/password/forgot", map[string]string{"email": user.Email}, handler)
+	second := serve(t, http.MethodPost, "/auth/password/forgot", map[string]string{"email": user.Email}, handler)
+
+	if first.Code != http.StatusOK || second.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d then %d, want %d then %d", first.Code, second.Code, http.StatusOK, http.StatusTooManyRequests)
+	}
+	if second.Header().Get("Retry-After") == "" {
+		t.Error("throttled response has no Retry-After")
+	}
+	if sent := waitForMessages(t, n, 1); len(sent) != 1 {
+		t.Errorf("sent %d reset links, want 1", len(sent))
+	}
+}
+
+func TestResetPasswordTokenWorksOnce(t *testing.T) {
+	newTestDB(t)
+	t.Setenv("PASSWORD_BCRYPT_COST", "4")
+	user := createPasswordUser(t, "alice@example.com")
+	token := requestPasswordReset(t, user.Email)
+
+	if rr := resetTestPassword(t, token); rr.Code != http.StatusOK {
+		t.Fatalf("reset status = %d, body %s", rr.Code, rr.Body)
+	}
+	if !password.Verify(findTestUser(t, user.Email).Password, resetPassword) {
+		t.Error("new password does not match the stored hash")
+	}
+
+	expectInvalidResetToken(t, resetTestPassword(t, token))
+}
+
+func TestResetPasswordTokenIsReplaced(t *testing.T) {
+	newTestDB(t)
+	user := createPasswordUser(t, "alice@example.com")
+	first := requestPasswordReset(t, user.Email)
+	requestPasswordReset(t, user.Email)
+
+	expectInvalidResetToken(t, resetTestPassword(t, first))
+}
+
+func TestResetPasswordTokenExpires(t *testing.T) {
+	newTestDB(t)
+	user := createPasswordUser(t, "alice@example.com")
+	token := requestPasswordReset(t, user.Email)
+	if _, err := dal.PasswordReset.Where(dal.PasswordReset.UserID.Eq(user.ID)).
+		Update(dal.PasswordReset.ExpiresAt, time.Now().Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	expectInvalidResetToken(t, resetTestPassword(t, token))
+	if !password.Verify(findTestUser(t, user.Email).Password, testPassword) {
+		t.Error("password was changed with an expired token")
+	}
+}
+
+func TestResetPasswordRevokesSessions(t *testing.T) {
+	newTestDB(t)
+	setTokenEnv(t)
+	t.Setenv("PASSWORD_BCRYPT_COST", "4")
+	keys := jwtkeys.NewHMAC([]byte("secret"))
+	user := createPasswordUser(t, "alice@example.com")
+	accessToken, refreshToken := loginTokens(t, keys, user.Email)
+	token := requestPasswordReset(t, user.Email)
+
+	if rr := resetTestPassword(t, token); rr.Code != http.StatusOK {
+		t.Fatalf("reset status = %d, body %s", rr.Code, rr.Body)
+	}
+
+	expectInvalidRefreshToken(t, refreshTokens(t, keys, refreshToken))
+	rr := serveWithToken(t, keys, http.MethodGet, "/auth/sessions", "/auth/sessions", accessToken, controllers.GetMySessions)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("access token issued before the reset: status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"crypto/sha1"
+	"dbo-test/internal/password"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHasherRoundTrip(t *testing.T) {
+	hashers := []password.Hasher{
+		{Algorithm: password.AlgorithmBcrypt, BcryptCost: bcrypt.MinCost},
+		{Algorithm: password.AlgorithmArgon2id, Argon2: password.Argon2Params{Memory: 1024, Time: 1, Threads: 1, KeyLen: 32, SaltLen: 16}},
+	}
+	for _, hasher := range hashers {
+		hash, err := hasher.Hash("correct horse")
+		if err != nil {
+			t.Fatalf("%s: %v", hasher.Algorithm, err)
+		}
+		if !password.Verify(hash, "correct horse") {
+			t.Errorf("%s: password does not verify", hasher.Algorithm)
+		}
+		if password.Verify(hash, "wrong horse") {
+			t.Errorf("%s: wrong password verifies", hasher.Algorithm)
+		}
+		if hasher.NeedsRehash(hash) {
+			t.Errorf("%s: fresh hash needs rehash", hasher.Algorithm)
+		}
+	}
+}
+
+func TestHasherNeedsRehash(t *testing.T) {
+	weak := password.Hasher{Algorithm: password.AlgorithmBcrypt, BcryptCost: bcrypt.MinCost}
+	hash, err := weak.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stronger := password.Hasher{Algorithm: password.AlgorithmBcrypt, BcryptCost: bcrypt.MinCost + 1}
+	if !stronger.NeedsRehash(hash) {
+		t.Error("hash with lower bcrypt cost does not need rehash")
+	}
+
+	argon := password.Hasher{Algorithm: password.AlgorithmArgon2id, Argon2: password.Argon2Params{Memory: 1024, Time: 1, Threads: 1, KeyLen: 32, SaltLen: 16}}
+	if !argon.NeedsRehash(hash) {
+		t.Error("bcrypt hash does not need rehash when argon2id is configured")
+	}
+}
+
+func TestPolicyCheck(t *testing.T) {
+	policy := password.Policy{
+		MinLength:       10,
+		RequiredClasses: []string{password.ClassLower, password.ClassUpper, password.ClassDigit},
+	}
+
+	if err := policy.Check("Tr0ub4dor&3x", "jane@example.com"); err != nil {
+		t.Errorf("strong password rejected: %v", err)
+	}
+
+	err := policy.Check("short", "jane@example.com")
+	var policyErr *password.PolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("got %v, want a policy error", err)
+	}
+	// Too short, no upper case letter and no digit.
+	if len(policyErr.Violations) != 3 {
+		t.Errorf("got violations %q, want 3", policyErr.Violations)
+	}
+
+	if err := policy.Check("Jane@Example.com1", "jane@example.com1"); err == nil {
+		t.Error("email accepted as password")
+	}
+}
+
+func TestPolicyRejectsBreachedPasswords(t *testing.T) {
+	policy := password.Policy{MinLength: 8}
+	if err := policy.Check("password123", ""); err == nil {
+		t.Error("built-in breached password accepted")
+	}
+
+	sum := sha1.Sum([]byte("Sup3r-unique-phrase"))
+	list := filepath.Join(t.TempDir(), "breached.txt")
+	content := "another-leaked-one\n" + strings.ToUpper(hex.EncodeToString(sum[:])) + ":42\n"
+	if err := os.WriteFile(list, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	policy.BreachedListPath = list
+
+	if err := policy.Check("Sup3r-unique-phrase", ""); err == nil {
+		t.Error("password listed by its SHA-1 hash accepted")
+	}
+	if err := policy.Check("another-leaked-one", ""); err == nil {
+		t.Error("password listed in plain text accepted")
+	}
+	if err := policy.Check("nobody-has-leaked-this-1", ""); err != nil {
+		t.Errorf("unlisted password rejected: %v", err)
+	}
+}
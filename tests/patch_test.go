@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"dbo-test/internal/patch"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func assertJSON(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	cases := []struct{ doc, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"amount":10}`, `{"amount":0}`, `{"amount":0}`},
+	}
+	for _, tc := range cases {
+		got, err := patch.MergePatch([]byte(tc.doc), []byte(tc.patch))
+		if err != nil {
+			t.Fatalf("%s + %s: %v", tc.doc, tc.patch, err)
+		}
+		assertJSON(t, got, tc.want)
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	doc := `{"name":"Jane","phone":"0812","tags":["a","c"],"attributes":{"region":"west"}}`
+	ops := `[
+		{"op":"test","path":"/name","value":"Jane"},
+		{"op":"replace","path":"/phone","value":""},
+		{"op":"add","path":"/tags/1","value":"b"},
+		{"op":"add","path":"/tags/-","value":"d"},
+		{"op":"remove","path":"/tags/0"},
+		{"op":"copy","from":"/attributes/region","path":"/attributes/zone"},
+		{"op":"move","from":"/attributes/region","path":"/region"}
+	]`
+	got, err := patch.JSONPatch([]byte(doc), []byte(ops))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, got, `{"name":"Jane","phone":"","tags":["b","c","d"],"attributes":{"zone":"west"},"region":"west"}`)
+
+	failing := []string{
+		`[{"op":"test","path":"/name","value":"Joe"}]`,
+		`[{"op":"remove","path":"/missing"}]`,
+		`[{"op":"replace","path":"/tags/5","value":1}]`,
+		`[{"op":"add","path":"/name"}]`,
+		`[{"op":"move","from":"/attributes","path":"/attributes/inner"}]`,
+		`[{"op":"frobnicate","path":"/name"}]`,
+	}
+	for _, ops := range failing {
+		if _, err := patch.JSONPatch([]byte(doc), []byte(ops)); err == nil {
+			t.Errorf("%s applied", ops)
+		}
+	}
+}
+
+func TestApplyContentType(t *testing.T) {
+	doc := []byte(`{"a":1}`)
+	got, err := patch.Apply("application/merge-patch+json; charset=utf-8", doc, []byte(`{"a":null}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, got, `{}`)
+
+	got, err = patch.Apply(patch.ContentTypeJSONPatch, doc, []byte(`[{"op":"add","path":"/a","value":null}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, got, `{"a":null}`)
+
+	if _, err := patch.Apply("text/plain", doc, []byte(`{}`)); !errors.Is(err, patch.ErrUnsupportedMediaType) {
+		t.Errorf("got %v, want ErrUnsupportedMediaType", err)
+	}
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"dbo-test/internal/middlewares"
+	"dbo-test/internal/server"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// servePolicy sends a request as a user with the role through RBACMiddleware with the
+// route policy of the server and returns the status.
+func servePolicy(t *testing.T, role, method, path string) int {
+	t.Helper()
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		middlewares.SetPrincipal(c, &middlewares.Principal{UserID: 1, Roles: []string{role}})
+	}, middlewares.RBACMiddleware(server.RoutePolicy))
+	for route := range server.RoutePolicy {
+		method, path, _ := strings.Cut(route, " ")
+		r.Handle(method, path, func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+	r.GET("/unlisted", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(method, path, nil))
+	return rr.Code
+}
+
+func TestRoutePolicy(t *testing.T) {
+	const (
+		admin    = middlewares.RoleAdmin
+		sales    = middlewares.RoleSales
+		readonly = middlewares.RoleReadOnly
+	)
+	tests := []struct {
+		method, path string
+		allowed      []string
+		denied       []string
+	}{
+		{http.MethodGet, "/customer/", []string{admin, sales, readonly}, nil},
+		{http.MethodGet, "/customer/7", []string{admin, sales, readonly}, nil},
+		{http.MethodPost, "/customer/", []string{admin, sales}, []string{readonly}},
+		{http.MethodPatch, "/customer/7", []string{admin, sales}, []string{readonly}},
+		{http.MethodDelete, "/customer/7", []string{admin}, []string{sales, readonly}},
+		{http.MethodPost, "/customer/7/restore", []string{admin}, []string{sales, readonly}},
+		{http.MethodPost, "/customer/7/merge", []string{admin}, []string{sales, readonly}},
+		{http.MethodPost, "/customer/tags/bulk", []string{admin, sales}, []string{readonly}},
+		{http.MethodGet, "/order/", []string{admin, sales, readonly}, nil},
+		{http.MethodPut, "/order/7", []string{admin, sales}, []string{readonly}},
+		{http.MethodDelete, "/order/7", []string{admin}, []string{sales, readonly}},
+		{http.MethodPut, "/user/me/password", []string{admin, sales, readonly}, nil},
+		{http.MethodGet, "/user/", []string{admin}, []string{sales, readonly}},
+		{http.MethodPost, "/user/7/disable", []string{admin}, []string{sales, readonly}},
+		{http.MethodPost, "/api-key/", []string{admin}, []string{sales, readonly}},
+		{http.MethodGet, "/login-data", []string{admin}, []string{sales, readonly}},
+		{http.MethodGet, "/unlisted", nil, []string{admin, sales, readonly}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			for _, role := range tt.allowed {
+				if got := servePolicy(t, role, tt.method, tt.path); got != http.StatusOK {
+					t.Errorf("%s: status = %d, want %d", role, got, http.StatusOK)
+				}
+			}
+			for _, role := range tt.denied {
+				if got := servePolicy(t, role, tt.method, tt.path); got != http.StatusForbidden {
+					t.Errorf("%s: status = %d, want %d", role, got, http.StatusForbidden)
+				}
+			}
+		})
+	}
+}
+
+func TestRoutePolicyRoles(t *testing.T) {
+	for route, roles := range server.RoutePolicy {
+		if !slices.Contains(roles, middlewares.RoleAdmin) {
+			t.Errorf("%s: admins are not allowed", route)
+		}
+		// Read-only users may only read, apart from changing their own password.
+		if slices.Contains(roles, middlewares.RoleReadOnly) && !strings.HasPrefix(route, "GET ") && route != "PUT /user/me/password" {
+			t.Errorf("%s: read-only users are allowed", route)
+		}
+	}
+}
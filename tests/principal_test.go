@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"dbo-test/internal/jwtkeys"
+	"dbo-test/internal/middlewares"
+	"dbo-test/internal/revocation"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+func TestJWTAuthMiddlewareSetsPrincipal(t *testing.T) {
+	keys := jwtkeys.NewHMAC([]byte("secret"))
+	revoked := revocation.NewMemoryStore(time.Hour)
+	defer revoked.Close()
+
+	exp := time.Now().Add(time.Minute).Unix()
+	token, err := keys.Sign(jwt.MapClaims{
+		"jti":    "token-1",
+		"typ":    middlewares.TokenTypeAccess,
+		"exp":    exp,
+		"sub":    "7",
+		"email":  "sales@example.com",
+		"roles":  []string{middlewares.RoleSales},
+		"tenant": "acme",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got *middlewares.Principal
+	r := gin.New()
+	r.Use(middlewares.JWTAuthMiddleware(keys, revoked), middlewares.RBACMiddleware(middlewares.Policy{
+		"GET /customer": {middlewares.RoleSales},
+		"GET /user":     {middlewares.RoleAdmin},
+	}))
+	handler := func(c *gin.Context) {
+		got, _ = middlewares.GetPrincipal(c)
+	}
+	r.GET("/customer", handler)
+	r.GET("/user", handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/customer", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rr.Code, http.StatusOK, rr.Body)
+	}
+	if got == nil || got.UserID != 7 || got.Email != "sales@example.com" || got.Tenant != "acme" ||
+		got.TokenID != "token-1" || got.ExpiresAt.Unix() != exp || !got.HasRole(middlewares.RoleSales) {
+		t.Fatalf("principal = %+v", got)
+	}
+	if actor := got.ActorID(); actor == nil || *actor != 7 {
+		t.Fatalf("ActorID = %v, want 7", actor)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/user", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusForbidden)
+	}
+}
+
+func TestPrincipalOfService(t *testing.T) {
+	p := &middlewares.Principal{APIKeyID: 3, Roles: []string{middlewares.RoleReadOnly}}
+	if p.IsUser() || p.ActorID() != nil {
+		t.Fatalf("API key principal %+v is treated as a user", p)
+	}
+	if p.HasRole(middlewares.RoleAdmin, middlewares.RoleSales) || !p.HasRole(middlewares.RoleReadOnly) {
+		t.Fatalf("HasRole does not match roles %v", p.Roles)
+	}
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"dbo-test/internal/problem"
+	"dbo-test/internal/trace"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+func serveProblem(t *testing.T, requestID string, handler gin.HandlerFunc) (*httptest.ResponseRecorder, problem.Details) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(trace.Middleware())
+	r.GET("/customer/:id", handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/customer/7", nil)
+	if requestID != "" {
+		req.Header.Set(trace.Header, requestID)
+	}
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	var details problem.Details
+	if err := json.Unmarshal(rr.Body.Bytes(), &details); err != nil {
+		t.Fatal(err)
+	}
+	return rr, details
+}
+
+func TestProblemWrite(t *testing.T) {
+	rr, details := serveProblem(t, "req-12345678", func(c *gin.Context) {
+		problem.Write(c, http.StatusNotFound, problem.CodeNotFound, "customer not found")
+	})
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("status = %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != problem.ContentType {
+		t.Errorf("content type = %q", ct)
+	}
+	want := problem.Details{
+		Type:     "about:blank",
+		Title:    "Not Found",
+		Status:   http.StatusNotFound,
+		Detail:   "customer not found",
+		Instance: "/customer/7",
+		Code:     problem.CodeNotFound,
+		TraceID:  "req-12345678",
+	}
+	if !reflect.DeepEqual(details, want) {
+		t.Errorf("got %+v, want %+v", details, want)
+	}
+	if got := rr.Header().Get(trace.Header); got != "req-12345678" {
+		t.Errorf("trace header = %q", got)
+	}
+}
+
+func TestProblemTraceIDGenerated(t *testing.T) {
+	rr, details := serveProblem(t, "bad id!", func(c *gin.Context) {
+		problem.Write(c, http.StatusBadRequest, problem.CodeInvalidParameter, "invalid id")
+	})
+	if details.TraceID == "" || details.TraceID == "bad id!" {
+		t.Errorf("trace id = %q", details.TraceID)
+	}
+	if rr.Header().Get(trace.Header) != details.TraceID {
+		t.Error("trace header and body differ")
+	}
+}
+
+func TestProblemError(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{fmt.Errorf("load: %w", gorm.ErrRecordNotFound), http.StatusNotFound, problem.CodeNotFound},
+		{&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'a@b.c' for key 'email'"}, http.StatusConflict, problem.CodeConflict},
+		{&mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row"}, http.StatusConflict, problem.CodeConflict},
+		{fmt.Errorf("create: %w", gorm.ErrDuplicatedKey), http.StatusConflict, problem.CodeConflict},
+		{mysql.ErrInvalidConn, http.StatusServiceUnavailable, problem.CodeUnavailable},
+		{errors.New("Error 1054: Unknown column 'secret' in 'field list'"), http.StatusInternalServerError, problem.CodeInternal},
+	}
+	for _, tc := range cases {
+		rr, details := serveProblem(t, "", func(c *gin.Context) {
+			problem.Error(c, tc.err)
+		})
+		if rr.Code != tc.status || details.Code != tc.code {
+			t.Errorf("%v: got %d %s, want %d %s", tc.err, rr.Code, details.Code, tc.status, tc.code)
+		}
+		if details.Detail == tc.err.Error() {
+			t.Errorf("%v: error text leaked", tc.err)
+		}
+	}
+}
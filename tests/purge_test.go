@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"dbo-test/internal/dal"
+	"dbo-test/internal/model"
+	"dbo-test/internal/purge"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const testRetention = 30 * 24 * time.Hour
+
+func newTestPurger(t *testing.T) *purge.Purger {
+	t.Helper()
+	p := purge.NewPurger(testRetention, time.Hour)
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+// trashTestCustomer moves the customer to the trash at the time.
+func trashTestCustomer(t *testing.T, customer *model.Customer, at time.Time) {
+	t.Helper()
+	_, err := dal.Customer.Where(dal.Customer.ID.Eq(customer.ID)).
+		Update(dal.Customer.DeletedAt, gorm.DeletedAt{Time: at, Valid: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPurgeDeletesTagsOfPurgedCustomers(t *testing.T) {
+	newTestDB(t)
+	purged, kept := createTestCustomer(t, "purged"), createTestCustomer(t, "kept")
+	tagTestCustomer(t, purged.ID, "vip")
+	tagTestCustomer(t, kept.ID, "vip")
+	trashTestCustomer(t, purged, time.Now().Add(-testRetention-time.Hour))
+
+	if _, err := newTestPurger(t).Purge(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	links, err := dal.CustomerTag.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 1 || links[0].CustomerID != kept.ID {
+		t.Errorf("customer tags = %v, want only the one of the kept customer", links)
+	}
+	if count, err := dal.Tag.Count(); err != nil || count != 1 {
+		t.Errorf("tags = %d %v, want the tag kept", count, err)
+	}
+}
+
+// customerExists reports whether the customer row is still there, in the trash or not.
+func customerExists(t *testing.T, id int32) bool {
+	t.Helper()
+	count, err := dal.Customer.Unscoped().Where(dal.Customer.ID.Eq(id)).Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count == 1
+}
+
+func TestPurgeRetention(t *testing.T) {
+	newTestDB(t)
+	now := time.Now()
+	expired, recent, active := createTestCustomer(t, "expired"), createTestCustomer(t, "recent"), createTestCustomer(t, "active")
+	trashTestCustomer(t, expired, now.Add(-testRetention-time.Minute))
+	trashTestCustomer(t, recent, now.Add(-testRetention+time.Minute))
+	createTestAddress(t, expired.ID, model.AddressTypeShipping, "Jakarta", true)
+	createTestAddress(t, recent.ID, model.AddressTypeShipping, "Bandung", true)
+
+	purged, err := newTestPurger(t).Purge(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if purged != 1 || customerExists(t, expired.ID) {
+		t.Errorf("purged %d, want only the customer trashed before the retention period", purged)
+	}
+	if !customerExists(t, recent.ID) || !customerExists(t, active.ID) {
+		t.Error("a customer within the retention period or not in the trash was purged")
+	}
+	addresses, err := dal.Address.Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addresses) != 1 || addresses[0].CustomerID != recent.ID {
+		t.Errorf("addresses = %v, want only the one of the kept customer", addresses)
+	}
+}
+
+func TestPurgeKeepsReferencedCustomers(t *testing.T) {
+	newTestDB(t)
+	now := time.Now()
+	customer := createTestCustomer(t, "ordered")
+	if err := dal.Order.Create(&model.Order{OrderDate: now, Amount: 10, CustomerID: customer.ID}); err != nil {
+		t.Fatal(err)
+	}
+	trashTestCustomer(t, customer, now.Add(-2*testRetention))
+
+	purged, err := newTestPurger(t).Purge(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if purged != 0 || !customerExists(t, customer.ID) {
+		t.Errorf("purged %d, want the customer orders refer to kept", purged)
+	}
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"dbo-test/internal/controllers"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/jwtkeys"
+	"dbo-test/internal/problem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func refreshTokens(t *testing.T, keys *jwtkeys.KeySet, refreshToken string) *httptest.ResponseRecorder {
+	t.Helper()
+	return serve(t, http.MethodPost, "/auth/refresh", map[string]string{"refresh_token": refreshToken}, controllers.RefreshTokenHandler(keys))
+}
+
+// rotateRefreshToken refreshes the tokens and returns the new refresh token.
+func rotateRefreshToken(t *testing.T, keys *jwtkeys.KeySet, refreshToken string) string {
+	t.Helper()
+	rr := refreshTokens(t, keys, refreshToken)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("refresh status = %d, body %s", rr.Code, rr.Body)
+	}
+	var tokens struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	decodeData(t, rr, &tokens)
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" || tokens.RefreshToken == refreshToken {
+		t.Fatalf("refresh returned %+v", tokens)
+	}
+	return tokens.RefreshToken
+}
+
+func expectInvalidRefreshToken(t *testing.T, rr *httptest.ResponseRecorder) {
+	t.Helper()
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d, body %s", rr.Code, http.StatusUnauthorized, rr.Body)
+	}
+	if got := decodeProblem(t, rr).Code; got != problem.CodeInvalidToken {
+		t.Errorf("code = %q, want %q", got, problem.CodeInvalidToken)
+	}
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	newTestDB(t)
+	setTokenEnv(t)
+	keys := jwtkeys.NewHMAC([]byte("secret"))
+	user := createPasswordUser(t, "alice@example.com")
+	first := loginRefreshToken(t, keys, user.Email)
+
+	second := rotateRefreshToken(t, keys, first)
+	rotateRefreshToken(t, keys, second)
+
+	tokens, err := dal.RefreshToken.Order(dal.RefreshToken.ID).Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 3 || tokens[0].RevokedAt == nil || tokens[1].RevokedAt == nil || tokens[2].RevokedAt != nil {
+		t.Fatalf("stored tokens = %+v, want the two rotated ones revoked", tokens)
+	}
+	if tokens[0].ReplacedBy == nil || *tokens[0].ReplacedBy != tokens[1].ID || tokens[1].FamilyID != tokens[0].FamilyID {
+		t.Errorf("first token %+v is not replaced by the second %+v of its family", tokens[0], tokens[1])
+	}
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	newTestDB(t)
+	setTokenEnv(t)
+	keys := jwtkeys.NewHMAC([]byte("secret"))
+	user := createPasswordUser(t, "alice@example.com")
+	stolen := loginRefreshToken(t, keys, user.Email)
+	other := loginRefreshToken(t, keys, user.Email)
+	current := rotateRefreshToken(t, keys, stolen)
+
+	expectInvalidRefreshToken(t, refreshTokens(t, keys, stolen))
+
+	// The whole family of the replayed token is revoked, other logins are not.
+	expectInvalidRefreshToken(t, refreshTokens(t, keys, current))
+	rotateRefreshToken(t, keys, other)
+}
+
+func TestRefreshTokenRejectsInvalidTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(t *testing.T, userID int32)
+	}{
+		{"expired", func(t *testing.T, userID int32) {
+			if _, err := dal.RefreshToken.Where(dal.RefreshToken.UserID.Eq(userID)).
+				Update(dal.RefreshToken.ExpiresAt, time.Now().Add(-time.Minute)); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"revoked", func(t *testing.T, userID int32) {
+			if _, err := dal.RefreshToken.Where(dal.RefreshToken.UserID.Eq(userID)).
+				Update(dal.RefreshToken.RevokedAt, time.Now()); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		{"session ended", func(t *testing.T, userID int32) {
+			if _, err := dal.Session.Where(dal.Session.UserID.Eq(userID)).
+				Update(dal.Session.RevokedAt, time.Now()); err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestDB(t)
+			setTokenEnv(t)
+			keys := jwtkeys.NewHMAC([]byte("secret"))
+			user := createPasswordUser(t, "alice@example.com")
+			refreshToken := loginRefreshToken(t, keys, user.Email)
+			tt.modify(t, user.ID)
+
+			expectInvalidRefreshToken(t, refreshTokens(t, keys, refreshToken))
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		newTestDB(t)
+		expectInvalidRefreshToken(t, refreshTokens(t, jwtkeys.NewHMAC([]byte("secret")), "not a token"))
+	})
+}
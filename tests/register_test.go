@@ -0,0 +1,275 @@
+package tests
+
+import (
+	"context"
+	"dbo-test/internal/controllers"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/jwtkeys"
+	"dbo-test/internal/loginguard"
+	"dbo-test/internal/middlewares"
+	"dbo-test/internal/model"
+	"dbo-test/internal/notifier"
+	"dbo-test/internal/problem"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// recordingNotifier keeps the messages instead of delivering them.
+type recordingNotifier struct {
+	mu       sync.Mutex
+	messages []notifier.Message
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, msg notifier.Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = append(n.messages, msg)
+	return nil
+}
+
+func (n *recordingNotifier) sent() []notifier.Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]notifier.Message(nil), n.messages...)
+}
+
+func createTestUser(t *testing.T, email string, verified bool) *model.User {
+	t.Helper()
+	user := &model.User{Email: email, Password: "not a hash", CreatedAt: time.Now()}
+	if verified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	if err := dal.User.Create(user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+// newTestGuard returns a guard that never makes clients wait.
+func newTestGuard(t *testing.T) *loginguard.Guard {
+	t.Helper()
+	guard := loginguard.NewGuard(0, 0, time.Minute)
+	t.Cleanup(func() { guard.Close() })
+	return guard
+}
+
+func TestRegisterWeakPasswordDoesNotRevealEmail(t *testing.T) {
+	newTestDB(t)
+	createTestUser(t, "taken@example.com", true)
+	n := &recordingNotifier{}
+	handler := controllers.RegisterHandler(n, newTestGuard(t), jwtkeys.NewHMAC([]byte("secret")))
+
+	taken := serve(t, http.MethodPost, "/auth/register", map[string]string{"email": "taken@example.com", "password": "short"}, handler)
+	free := serve(t, http.MethodPost, "/auth/register", map[string]string{"email": "free@example.com", "password": "short"}, handler)
+
+	if taken.Code != http.StatusBadRequest || free.Code != taken.Code {
+		t.Fatalf("status = %d for a registered email and %d for a free one, want both %d", taken.Code, free.Code, http.StatusBadRequest)
+	}
+	takenProblem, freeProblem := decodeProblem(t, taken), decodeProblem(t, free)
+	if takenProblem.Code != freeProblem.Code || takenProblem.Detail != freeProblem.Detail {
+		t.Errorf("problem = %q %q for a registered email and %q %q for a free one",
+			takenProblem.Code, takenProblem.Detail, freeProblem.Code, freeProblem.Detail)
+	}
+	if len(n.sent()) != 0 {
+		t.Errorf("sent %d messages for rejected registrations", len(n.sent()))
+	}
+}
+
+func TestRegisterRaceAnswersAsExistingEmail(t *testing.T) {
+	db := newTestDB(t)
+	// Another registration of the same email commits between the lookup and the insert.
+	var once sync.Once
+	err := db.Callback().Query().After("gorm:query").Register("test:concurrent_registration", func(tx *gorm.DB) {
+		if tx.Statement.Table == model.TableNameUser {
+			once.Do(func() {
+				tx.Session(&gorm.Session{NewDB: true}).Exec(
+					"INSERT INTO users (email, password, created_at) VALUES (?, ?, ?)", "race@example.com", "not a hash", time.Now())
+			})
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := &recordingNotifier{}
+	handler := controllers.RegisterHandler(n, newTestGuard(t), jwtkeys.NewHMAC([]byte("secret")))
+
+	rr := serve(t, http.MethodPost, "/auth/register", map[string]string{"email": "race@example.com", "password": testPassword}, handler)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+	if sent := n.sent(); len(sent) != 1 || sent[0].To != "race@example.com" {
+		t.Errorf("sent %v, want a verification link for the existing account", sent)
+	}
+}
+
+var verificationTokenPattern = regexp.MustCompile(`token=(\S+)`)
+
+// verificationToken returns the token of the verification link in a message.
+func verificationToken(t *testing.T, msg notifier.Message) string {
+	t.Helper()
+	match := verificationTokenPattern.FindStringSubmatch(msg.Body)
+	if match == nil {
+		t.Fatalf("no verification link in %q", msg.Body)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func findTestUser(t *testing.T, email string) *model.User {
+	t.Helper()
+	user, err := dal.User.Where(dal.User.Email.Eq(email)).First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func TestRegisterCreatesUnverifiedUser(t *testing.T) {
+	newTestDB(t)
+	n := &recordingNotifier{}
+	handler := controllers.RegisterHandler(n, newTestGuard(t), jwtkeys.NewHMAC([]byte("secret")))
+
+	rr := serve(t, http.MethodPost, "/auth/register", map[string]string{"email": "new@example.com", "password": testPassword}, handler)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+
+	user := findTestUser(t, "new@example.com")
+	if user.EmailVerifiedAt != nil {
+		t.Error("new user is verified")
+	}
+	if user.Password == testPassword {
+		t.Error("password is stored in plain text")
+	}
+	roles, err := dal.Role.Join(dal.UserRole, dal.UserRole.RoleID.EqCol(dal.Role.ID)).Where(dal.UserRole.UserID.Eq(user.ID)).Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 1 || roles[0].Name != middlewares.RoleReadOnly {
+		t.Errorf("roles = %v, want only %s", roles, middlewares.RoleReadOnly)
+	}
+	if sent := n.sent(); len(sent) != 1 || sent[0].To != user.Email {
+		t.Errorf("sent %v, want one verification link", sent)
+	}
+}
+
+func TestRegisterExistingEmailAnswersLikeNewEmail(t *testing.T) {
+	newTestDB(t)
+	createTestUser(t, "taken@example.com", true)
+	n := &recordingNotifier{}
+	handler := controllers.RegisterHandler(n, newTestGuard(t), jwtkeys.NewHMAC([]byte("secret")))
+
+	taken := serve(t, http.MethodPost, "/auth/register", map[string]string{"email": "taken@example.com", "password": testPassword}, handler)
+	free := serve(t, http.MethodPost, "/auth/register", map[string]string{"email": "free@example.com", "password": testPassword}, handler)
+
+	if taken.Code != http.StatusOK || free.Code != http.StatusOK || taken.Body.String() != free.Body.String() {
+		t.Errorf("registered email got %d %s, free email %d %s", taken.Code, taken.Body, free.Code, free.Body)
+	}
+	sent := n.sent()
+	if len(sent) != 2 || sent[0].To != "taken@example.com" || sent[0].Subject != "You already have an account" {
+		t.Errorf("sent %v, want a notice to the owner of the registered email first", sent)
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	newTestDB(t)
+	keys := jwtkeys.NewHMAC([]byte("secret"))
+	n := &recordingNotifier{}
+	rr := serve(t, http.MethodPost, "/auth/register", map[string]string{"email": "new@example.com", "password": testPassword},
+		controllers.RegisterHandler(n, newTestGuard(t), keys))
+	if rr.Code != http.StatusOK || len(n.sent()) != 1 {
+		t.Fatalf("status = %d, sent %d messages", rr.Code, len(n.sent()))
+	}
+	token := verificationToken(t, n.sent()[0])
+	verify := controllers.VerifyEmailHandler(keys)
+
+	rr = serve(t, http.MethodPost, "/auth/register/verify", map[string]string{"token": "not a token"}, verify)
+	if rr.Code != http.StatusBadRequest || decodeProblem(t, rr).Code != problem.CodeInvalidToken {
+		t.Errorf("invalid token got %d %s", rr.Code, rr.Body)
+	}
+
+	rr = serve(t, http.MethodPost, "/auth/register/verify", map[string]string{"token": token}, verify)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+	if findTestUser(t, "new@example.com").EmailVerifiedAt == nil {
+		t.Error("email is not verified")
+	}
+}
+
+func TestVerifyEmailRejectsTokenOfOldEmail(t *testing.T) {
+	newTestDB(t)
+	keys := jwtkeys.NewHMAC([]byte("secret"))
+	n := &recordingNotifier{}
+	user := createTestUser(t, "old@example.com", false)
+	rr := serve(t, http.MethodPost, "/auth/register/resend", map[string]string{"email": user.Email},
+		controllers.ResendVerificationHandler(n, newTestGuard(t), keys))
+	if rr.Code != http.StatusOK || len(n.sent()) != 1 {
+		t.Fatalf("status = %d, sent %d messages", rr.Code, len(n.sent()))
+	}
+	if _, err := dal.User.Where(dal.User.ID.Eq(user.ID)).Update(dal.User.Email, "new@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	rr = serve(t, http.MethodPost, "/auth/register/verify", map[string]string{"token": verificationToken(t, n.sent()[0])},
+		controllers.VerifyEmailHandler(keys))
+
+	if rr.Code != http.StatusBadRequest || decodeProblem(t, rr).Code != problem.CodeInvalidToken {
+		t.Errorf("token of the old email got %d %s", rr.Code, rr.Body)
+	}
+	if findTestUser(t, "new@example.com").EmailVerifiedAt != nil {
+		t.Error("new email was verified with the token of the old one")
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	newTestDB(t)
+	createTestUser(t, "unverified@example.com", false)
+	createTestUser(t, "verified@example.com", true)
+	n := &recordingNotifier{}
+	handler := controllers.ResendVerificationHandler(n, newTestGuard(t), jwtkeys.NewHMAC([]byte("secret")))
+
+	var bodies []string
+	for _, email := range []string{"unverified@example.com", "verified@example.com", "unknown@example.com"} {
+		rr := serve(t, http.MethodPost, "/auth/register/resend", map[string]string{"email": email}, handler)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, body %s", email, rr.Code, rr.Body)
+		}
+		bodies = append(bodies, rr.Body.String())
+	}
+
+	if bodies[0] != bodies[1] || bodies[1] != bodies[2] {
+		t.Errorf("responses differ: %v", bodies)
+	}
+	if sent := n.sent(); len(sent) != 1 || sent[0].To != "unverified@example.com" {
+		t.Errorf("sent %v, want one verification link to the unverified account", sent)
+	}
+}
+
+func TestResendVerificationIsThrottled(t *testing.T) {
+	newTestDB(t)
+	guard := loginguard.NewGuard(time.Minute, time.Hour, time.Hour)
+	t.Cleanup(func() { guard.Close() })
+	handler := controllers.ResendVerificationHandler(&recordingNotifier{}, guard, jwtkeys.NewHMAC([]byte("secret")))
+
+	first := serve(t, http.MethodPost, "/auth/register/resend", map[string]string{"email": "a@example.com"}, handler)
+	second := serve(t, http.MethodPost, "/auth/register/resend", map[string]string{"email": "a@example.com"}, handler)
+
+	if first.Code != http.StatusOK || second.Code != http.StatusTooManyRequests {
+		t.Errorf("status = %d then %d, want %d then %d", first.Code, second.Code, http.StatusOK, http.StatusTooManyRequests)
+	}
+	if second.Header().Get("Retry-After") == "" {
+		t.Error("throttled response has no Retry-After")
+	}
+}
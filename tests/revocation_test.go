@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"dbo-test/internal/revocation"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreRevoke(t *testing.T) {
+	store := revocation.NewMemoryStore(time.Hour)
+	defer store.Close()
+
+	if err := store.Revoke("active", time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Revoke("expired", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]bool{
+		"active":  true,
+		"expired": false,
+		"unknown": false,
+	}
+	for jti, want := range tests {
+		got, err := store.IsRevoked(jti)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("IsRevoked(%q) = %v, want %v", jti, got, want)
+		}
+	}
+}
+
+func TestMemoryStoreCleanup(t *testing.T) {
+	store := revocation.NewMemoryStore(10 * time.Millisecond)
+	defer store.Close()
+
+	if err := store.Revoke("short-lived", time.Now().Add(20*time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for store.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expired entry was not cleaned up, %d entries left", store.Len())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func testConsume(t *testing.T, store revocation.Store) {
+	t.Helper()
+	expiresAt := time.Now().Add(time.Minute)
+
+	first, err := store.Consume("challenge", expiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := store.Consume("challenge", expiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first || again {
+		t.Errorf("Consume = %v then %v, want true then false", first, again)
+	}
+	if revoked, err := store.IsRevoked("challenge"); err != nil || !revoked {
+		t.Errorf("IsRevoked after Consume = %v %v, want true", revoked, err)
+	}
+}
+
+func TestMemoryStoreConsume(t *testing.T) {
+	store := revocation.NewMemoryStore(time.Hour)
+	defer store.Close()
+	testConsume(t, store)
+}
+
+func TestDatabaseStoreConsume(t *testing.T) {
+	newTestDB(t)
+	store := revocation.NewDatabaseStore(time.Hour)
+	defer store.Close()
+	testConsume(t, store)
+}
@@ -0,0 +1,224 @@
+package tests
+
+import (
+	"dbo-test/internal/controllers"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/jwtkeys"
+	"dbo-test/internal/middlewares"
+	"dbo-test/internal/model"
+	"dbo-test/internal/problem"
+	"dbo-test/internal/session"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSessionIsActive(t *testing.T) {
+	now := time.Now()
+	revokedAt := now.Add(-time.Minute)
+
+	tests := []struct {
+		name    string
+		session model.Session
+		want    bool
+	}{
+		{"active", model.Session{ExpiresAt: now.Add(time.Hour)}, true},
+		{"expired", model.Session{ExpiresAt: now.Add(-time.Second)}, false},
+		{"expires now", model.Session{ExpiresAt: now}, false},
+		{"revoked", model.Session{ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt}, false},
+	}
+	for _, tt := range tests {
+		if got := session.IsActive(&tt.session, now); got != tt.want {
+			t.Errorf("%s: IsActive = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// serveWithToken serves a request authenticated by JWTAuthMiddleware with the access token.
+func serveWithToken(t *testing.T, keys *jwtkeys.KeySet, method, route, path, accessToken string, handler gin.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+	r := gin.New()
+	r.Handle(method, route, middlewares.JWTAuthMiddleware(keys, newTestRevocationStore(t)), handler)
+
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+// userSessions returns the sessions of the user in the order they were created.
+func userSessions(t *testing.T, userID int32) []*model.Session {
+	t.Helper()
+	sessions, err := dal.Session.Where(dal.Session.UserID.Eq(userID)).Order(dal.Session.ID).Find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sessions
+}
+
+type listedSession struct {
+	ID      int32 `json:"id"`
+	UserID  int32 `json:"user_id"`
+	Current bool  `json:"current"`
+}
+
+func TestMySessions(t *testing.T) {
+	newTestDB(t)
+	setTokenEnv(t)
+	keys := jwtkeys.NewHMAC([]byte("secret"))
+	user := createPasswordUser(t, "alice@example.com")
+	firstAccess, _ := loginTokens(t, keys, user.Email)
+	secondAccess, secondRefresh := loginTokens(t, keys, user.Email)
+	sessions := userSessions(t, user.ID)
+	if len(sessions) != 2 {
+		t.Fatalf("sessions = %d, want 2", len(sessions))
+	}
+
+	rr := serveWithToken(t, keys, http.MethodGet, "/auth/sessions", "/auth/sessions", firstAccess, controllers.GetMySessions)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("list status = %d, body %s", rr.Code, rr.Body)
+	}
+	var listed []listedSession
+	decodeData(t, rr, &listed)
+	if len(listed) != 2 {
+		t.Fatalf("listed sessions = %d, want 2", len(listed))
+	}
+	for _, s := range listed {
+		if want := s.ID == sessions[0].ID; s.Current != want {
+			t.Errorf("session %d current = %t, want %t", s.ID, s.Current, want)
+		}
+	}
+
+	path := fmt.Sprintf("/auth/sessions/%d", sessions[1].ID)
+	rr = serveWithToken(t, keys, http.MethodDelete, "/auth/sessions/:id", path, firstAccess, controllers.RevokeMySession)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("revoke status = %d, body %s", rr.Code, rr.Body)
+	}
+
+	rr = serveWithToken(t, keys, http.MethodGet, "/auth/sessions", "/auth/sessions", secondAccess, controllers.GetMySessions)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("access token of ended session: status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+	if got := decodeProblem(t, rr).Code; got != problem.CodeInvalidToken {
+		t.Errorf("code = %q, want %q", got, problem.CodeInvalidToken)
+	}
+	expectInvalidRefreshToken(t, refreshTokens(t, keys, secondRefresh))
+
+	rr = serveWithToken(t, keys, http.MethodGet, "/auth/sessions", "/auth/sessions", firstAccess, controllers.GetMySessions)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("list status = %d, body %s", rr.Code, rr.Body)
+	}
+	decodeData(t, rr, &listed)
+	if len(listed) != 1 || listed[0].ID != sessions[0].ID {
+		t.Errorf("listed sessions = %+v, want only session %d", listed, sessions[0].ID)
+	}
+}
+
+func TestRevokeMySessionOfOtherUser(t *testing.T) {
+	newTestDB(t)
+	setTokenEnv(t)
+	keys := jwtkeys.NewHMAC([]byte("secret"))
+	alice := createPasswordUser(t, "alice@example.com")
+	bob := createPasswordUser(t, "bob@example.com")
+	aliceAccess, _ := loginTokens(t, keys, alice.Email)
+	bobAccess, _ := loginTokens(t, keys, bob.Email)
+	bobSession := userSessions(t, bob.ID)[0]
+
+	path := fmt.Sprintf("/auth/sessions/%d", bobSession.ID)
+	rr := serveWithToken(t, keys, http.MethodDelete, "/auth/sessions/:id", path, aliceAccess, controllers.RevokeMySession)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d, body %s", rr.Code, http.StatusNotFound, rr.Body)
+	}
+
+	rr = serveWithToken(t, keys, http.MethodGet, "/auth/sessions", "/auth/sessions", bobAccess, controllers.GetMySessions)
+	if rr.Code != http.StatusOK {
+		t.Errorf("session of other user was ended: status = %d, body %s", rr.Code, rr.Body)
+	}
+}
+
+func TestUserSessions(t *testing.T) {
+	newTestDB(t)
+	setTokenEnv(t)
+	keys := jwtkeys.NewHMAC([]byte("secret"))
+	admin := createPasswordUser(t, "admin@example.com")
+	alice := createPasswordUser(t, "alice@example.com")
+	bob := createPasswordUser(t, "bob@example.com")
+	asAdmin := asUser(admin, middlewares.RoleAdmin)
+	firstAccess, _ := loginTokens(t, keys, alice.Email)
+	secondAccess, _ := loginTokens(t, keys, alice.Email)
+	bobAccess, _ := loginTokens(t, keys, bob.Email)
+	sessions := userSessions(t, alice.ID)
+	bobSession := userSessions(t, bob.ID)[0]
+	listUserSessions := func(query string) []listedSession {
+		t.Helper()
+		rr := serveRoute(t, http.MethodGet, "/user/:id/sessions", fmt.Sprintf("/user/%d/sessions%s", alice.ID, query), nil,
+			asAdmin, controllers.GetUserSessions)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("list status = %d, body %s", rr.Code, rr.Body)
+		}
+		var listed []listedSession
+		decodeData(t, rr, &listed)
+		return listed
+	}
+	authenticates := func(accessToken string) bool {
+		t.Helper()
+		return serveWithToken(t, keys, http.MethodGet, "/auth/sessions", "/auth/sessions", accessToken, controllers.GetMySessions).Code == http.StatusOK
+	}
+
+	listed := listUserSessions("")
+	if len(listed) != 2 {
+		t.Fatalf("listed sessions = %d, want 2", len(listed))
+	}
+	for _, s := range listed {
+		if s.UserID != alice.ID {
+			t.Errorf("session %d belongs to user %d, want %d", s.ID, s.UserID, alice.ID)
+		}
+	}
+
+	rr := serveRoute(t, http.MethodDelete, "/user/:id/sessions/:sessionId", fmt.Sprintf("/user/%d/sessions/%d", alice.ID, bobSession.ID), nil,
+		asAdmin, controllers.RevokeUserSession)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("revoke session of other user: status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+	if !authenticates(bobAccess) {
+		t.Error("session of other user was ended")
+	}
+
+	rr = serveRoute(t, http.MethodDelete, "/user/:id/sessions/:sessionId", fmt.Sprintf("/user/%d/sessions/%d", alice.ID, sessions[0].ID), nil,
+		asAdmin, controllers.RevokeUserSession)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("revoke status = %d, body %s", rr.Code, rr.Body)
+	}
+	if authenticates(firstAccess) {
+		t.Error("access token of ended session was accepted")
+	}
+	if !authenticates(secondAccess) {
+		t.Error("access token of other session was rejected")
+	}
+	if listed := listUserSessions(""); len(listed) != 1 || listed[0].ID != sessions[1].ID {
+		t.Errorf("listed sessions = %+v, want only session %d", listed, sessions[1].ID)
+	}
+	if listed := listUserSessions("?revoked=true"); len(listed) != 2 {
+		t.Errorf("listed sessions with revoked = %d, want 2", len(listed))
+	}
+
+	rr = serveRoute(t, http.MethodDelete, "/user/:id/sessions", fmt.Sprintf("/user/%d/sessions", alice.ID), nil,
+		asAdmin, controllers.RevokeUserSessions)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("revoke all status = %d, body %s", rr.Code, rr.Body)
+	}
+	if authenticates(secondAccess) {
+		t.Error("access token was accepted after all sessions ended")
+	}
+	if listed := listUserSessions(""); len(listed) != 0 {
+		t.Errorf("listed sessions = %d, want 0", len(listed))
+	}
+	if !authenticates(bobAccess) {
+		t.Error("session of other user was ended")
+	}
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"bytes"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/model"
+	"dbo-test/internal/problem"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// testIndexes are the unique keys of the migrations the handlers rely on.
+var testIndexes = []string{
+	"CREATE UNIQUE INDEX uq_users_email ON users (email)",
+	"CREATE UNIQUE INDEX uq_roles_name ON roles (name)",
+	"CREATE UNIQUE INDEX uq_tags_name ON tags (name)",
+	"CREATE UNIQUE INDEX uq_api_keys_key_hash ON api_keys (key_hash)",
+}
+
+// newTestDB points the query layer at a fresh in-memory SQLite database with the tables
+// of the models and the roles of the migrations.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+url.PathEscape(t.Name())+"?mode=memory&cache=shared"), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// One connection keeps the in-memory database alive and avoids locking errors.
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	err = db.AutoMigrate(
+		&model.Address{}, &model.APIKey{}, &model.CustomerAttribute{}, &model.CustomerMerge{},
+		&model.CustomerTag{}, &model.Customer{}, &model.LoginLog{}, &model.Order{},
+		&model.PasswordHistory{}, &model.PasswordReset{}, &model.RecoveryCode{}, &model.RefreshToken{},
+		&model.RevokedToken{}, &model.Role{}, &model.Session{}, &model.Tag{}, &model.UserRole{}, &model.User{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, index := range testIndexes {
+		if err := db.Exec(index).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Exec("INSERT INTO roles (name) VALUES ('admin'), ('sales'), ('readonly')").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	dal.SetDefault(db)
+	return db
+}
+
+// serve sends a JSON request to the handler and returns the response.
+func serve(t *testing.T, method, path string, body any, handlers ...gin.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+	return serveRoute(t, method, path, path, body, handlers...)
+}
+
+// serveRoute is serve for a handler registered under a route with parameters.
+func serveRoute(t *testing.T, method, route, path string, body any, handlers ...gin.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+	r := gin.New()
+	r.Handle(method, route, handlers...)
+
+	var reader *bytes.Reader
+	if s, ok := body.(string); ok {
+		reader = bytes.NewReader([]byte(s))
+	} else {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+	return rr
+}
+
+// decodeProblem decodes the problem of an error response.
+func decodeProblem(t *testing.T, rr *httptest.ResponseRecorder) problem.Details {
+	t.Helper()
+	if !strings.HasPrefix(rr.Header().Get("Content-Type"), problem.ContentType) {
+		t.Fatalf("Content-Type = %q, body %s", rr.Header().Get("Content-Type"), rr.Body)
+	}
+	var d problem.Details
+	if err := json.Unmarshal(rr.Body.Bytes(), &d); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// decodeData decodes the data of a success response into v.
+func decodeData(t *testing.T, rr *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	var resp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(resp.Data, v); err != nil {
+		t.Fatal(err)
+	}
+}
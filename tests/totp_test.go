@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"dbo-test/internal/totp"
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 test key of RFC 6238 appendix B, "12345678901234567890".
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCodeMatchesRFC6238(t *testing.T) {
+	// The RFC lists 8 digit codes, the 6 digit codes are their last six digits.
+	tests := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for unix, want := range tests {
+		got, err := totp.Code(rfc6238Secret, time.Unix(unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Code at %d = %s, want %s", unix, got, want)
+		}
+	}
+}
+
+func TestTOTPValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, err := totp.Code(rfc6238Secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !totp.Validate(rfc6238Secret, code, now) {
+		t.Error("current code was rejected")
+	}
+	if !totp.Validate(rfc6238Secret, code, now.Add(30*time.Second)) {
+		t.Error("code of the previous period was rejected")
+	}
+	if totp.Validate(rfc6238Secret, code, now.Add(2*time.Minute)) {
+		t.Error("stale code was accepted")
+	}
+	if totp.Validate(rfc6238Secret, "12345", now) {
+		t.Error("short code was accepted")
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uri := totp.URI("DBO", "jane@example.com", secret)
+	if !strings.HasPrefix(uri, "otpauth://totp/DBO:jane@example.com?") {
+		t.Errorf("unexpected uri %q", uri)
+	}
+	if !strings.Contains(uri, "secret="+secret) {
+		t.Errorf("uri %q does not contain the secret", uri)
+	}
+}
+
+func TestTOTPStep(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, err := totp.Code(rfc6238Secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step, ok := totp.Step(rfc6238Secret, code, now)
+	if !ok || step != 1111111111/30 {
+		t.Errorf("Step = %d %v, want %d", step, ok, 1111111111/30)
+	}
+	// The code keeps its step when it is checked a period later.
+	if later, ok := totp.Step(rfc6238Secret, code, now.Add(30*time.Second)); !ok || later != step {
+		t.Errorf("Step a period later = %d %v, want %d", later, ok, step)
+	}
+}
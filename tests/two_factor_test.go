@@ -0,0 +1,314 @@
+package tests
+
+import (
+	"dbo-test/internal/controllers"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/jwtkeys"
+	"dbo-test/internal/loginguard"
+	"dbo-test/internal/middlewares"
+	"dbo-test/internal/model"
+	"dbo-test/internal/problem"
+	"dbo-test/internal/revocation"
+	"dbo-test/internal/totp"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testPassword = "correct horse battery staple"
+
+// asUser authenticates the request as the user, as JWTAuthMiddleware would.
+func asUser(user *model.User, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		middlewares.SetPrincipal(c, &middlewares.Principal{UserID: user.ID, Email: user.Email, Roles: roles})
+	}
+}
+
+// createTwoFactorUser creates a user with the password testPassword and enables two-factor
+// authentication through the handlers. It returns the TOTP secret and the recovery codes.
+func createTwoFactorUser(t *testing.T, email string) (*model.User, string, []string) {
+	t.Helper()
+	user := createPasswordUser(t, email)
+
+	rr := serve(t, http.MethodPost, "/auth/2fa/enroll", nil, asUser(user), controllers.EnrollTwoFactor)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("enroll status = %d, body %s", rr.Code, rr.Body)
+	}
+	var enrolled struct {
+		Secret string `json:"secret"`
+	}
+	decodeData(t, rr, &enrolled)
+
+	// The code of the previous period keeps the current one unused for the test.
+	code, err := totp.Code(enrolled.Secret, time.Now().Add(-30*time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr = serve(t, http.MethodPost, "/auth/2fa/confirm", map[string]string{"code": code}, asUser(user), controllers.ConfirmTwoFactor)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("confirm status = %d, body %s", rr.Code, rr.Body)
+	}
+	var confirmed struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	decodeData(t, rr, &confirmed)
+	return user, enrolled.Secret, confirmed.RecoveryCodes
+}
+
+func twoFactorEnabled(t *testing.T, userID int32) bool {
+	t.Helper()
+	user, err := dal.User.Where(dal.User.ID.Eq(userID)).First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return user.TotpEnabledAt != nil
+}
+
+func TestDisableTwoFactor(t *testing.T) {
+	t.Run("recovery code", func(t *testing.T) {
+		newTestDB(t)
+		user, _, recoveryCodes := createTwoFactorUser(t, "alice@example.com")
+
+		rr := serve(t, http.MethodPost, "/auth/2fa/disable", map[string]string{
+			"password":      testPassword,
+			"recovery_code": recoveryCodes[0],
+		}, asUser(user), controllers.DisableTwoFactorHandler(newTestGuard(t)))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+		}
+		if twoFactorEnabled(t, user.ID) {
+			t.Error("two-factor authentication is still enabled")
+		}
+	})
+
+	t.Run("authenticator code", func(t *testing.T) {
+		newTestDB(t)
+		user, secret, _ := createTwoFactorUser(t, "alice@example.com")
+		code, err := totp.Code(secret, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := serve(t, http.MethodPost, "/auth/2fa/disable", map[string]string{
+			"password": testPassword,
+			"code":     code,
+		}, asUser(user), controllers.DisableTwoFactorHandler(newTestGuard(t)))
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+		}
+		if twoFactorEnabled(t, user.ID) {
+			t.Error("two-factor authentication is still enabled")
+		}
+	})
+
+	t.Run("recovery code is not a code", func(t *testing.T) {
+		newTestDB(t)
+		user, _, recoveryCodes := createTwoFactorUser(t, "alice@example.com")
+
+		rr := serve(t, http.MethodPost, "/auth/2fa/disable", map[string]string{
+			"password": testPassword,
+			"code":     recoveryCodes[0],
+		}, asUser(user), controllers.DisableTwoFactorHandler(newTestGuard(t)))
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("status = %d, want %d", rr.Code, http.StatusBadRequest)
+		}
+		if !twoFactorEnabled(t, user.ID) {
+			t.Error("two-factor authentication was disabled")
+		}
+	})
+}
+
+// loginChallenge logs in with the password and returns the two-factor challenge token.
+func loginChallenge(t *testing.T, keys *jwtkeys.KeySet, guard *loginguard.Guard, email string) string {
+	t.Helper()
+	rr := serve(t, http.MethodPost, "/auth/login", map[string]string{"email": email, "password": testPassword},
+		controllers.LoginHandler(guard, keys))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("login status = %d, body %s", rr.Code, rr.Body)
+	}
+	var challenge struct {
+		ChallengeToken string `json:"challenge_token"`
+	}
+	decodeData(t, rr, &challenge)
+	return challenge.ChallengeToken
+}
+
+// setTokenEnv configures the lifetime of the tokens a login issues.
+func setTokenEnv(t *testing.T) {
+	t.Setenv("JWT_EXPIRE", "15")
+	t.Setenv("JWT_REFRESH_EXPIRE", "60")
+}
+
+func TestLoginTwoFactorRejectsReusedCode(t *testing.T) {
+	newTestDB(t)
+	setTokenEnv(t)
+	keys, guard := jwtkeys.NewHMAC([]byte("secret")), newTestGuard(t)
+	user, secret, _ := createTwoFactorUser(t, "alice@example.com")
+	code, err := totp.Code(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := controllers.LoginTwoFactorHandler(guard, keys, newTestRevocationStore(t))
+
+	rr := serve(t, http.MethodPost, "/auth/login/2fa", map[string]string{
+		"challenge_token": loginChallenge(t, keys, guard, user.Email),
+		"code":            code,
+	}, handler)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("first use status = %d, body %s", rr.Code, rr.Body)
+	}
+
+	rr = serve(t, http.MethodPost, "/auth/login/2fa", map[string]string{
+		"challenge_token": loginChallenge(t, keys, guard, user.Email),
+		"code":            code,
+	}, handler)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("second use status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+	if got := decodeProblem(t, rr).Code; got != problem.CodeInvalidCode {
+		t.Errorf("code = %q, want %q", got, problem.CodeInvalidCode)
+	}
+}
+
+func newTestRevocationStore(t *testing.T) revocation.Store {
+	t.Helper()
+	store := revocation.NewMemoryStore(time.Hour)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestLoginTwoFactorRejectsReusedChallenge(t *testing.T) {
+	newTestDB(t)
+	setTokenEnv(t)
+	keys, guard := jwtkeys.NewHMAC([]byte("secret")), newTestGuard(t)
+	user, _, recoveryCodes := createTwoFactorUser(t, "alice@example.com")
+	handler := controllers.LoginTwoFactorHandler(guard, keys, newTestRevocationStore(t))
+	challenge := loginChallenge(t, keys, guard, user.Email)
+
+	rr := serve(t, http.MethodPost, "/auth/login/2fa", map[string]string{
+		"challenge_token": challenge,
+		"recovery_code":   recoveryCodes[0],
+	}, handler)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("first use status = %d, body %s", rr.Code, rr.Body)
+	}
+
+	rr = serve(t, http.MethodPost, "/auth/login/2fa", map[string]string{
+		"challenge_token": challenge,
+		"recovery_code":   recoveryCodes[1],
+	}, handler)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("second use status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+	if got := decodeProblem(t, rr).Code; got != problem.CodeInvalidToken {
+		t.Errorf("code = %q, want %q", got, problem.CodeInvalidToken)
+	}
+}
+
+// usedChallengeStore answers like a store whose challenge another request consumed between
+// the check and the consumption.
+type usedChallengeStore struct {
+	revocation.Store
+}
+
+func (usedChallengeStore) IsRevoked(string) (bool, error) { return false, nil }
+
+func (usedChallengeStore) Consume(string, time.Time) (bool, error) { return false, nil }
+
+func TestLoginTwoFactorKeepsRecoveryCodeOfUsedChallenge(t *testing.T) {
+	newTestDB(t)
+	setTokenEnv(t)
+	keys, guard := jwtkeys.NewHMAC([]byte("secret")), newTestGuard(t)
+	user, _, recoveryCodes := createTwoFactorUser(t, "alice@example.com")
+	handler := controllers.LoginTwoFactorHandler(guard, keys, usedChallengeStore{newTestRevocationStore(t)})
+
+	rr := serve(t, http.MethodPost, "/auth/login/2fa", map[string]string{
+		"challenge_token": loginChallenge(t, keys, guard, user.Email),
+		"recovery_code":   recoveryCodes[0],
+	}, handler)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rr.Code, http.StatusUnauthorized)
+	}
+	if got := decodeProblem(t, rr).Code; got != problem.CodeInvalidToken {
+		t.Errorf("code = %q, want %q", got, problem.CodeInvalidToken)
+	}
+	used, err := dal.RecoveryCode.Where(dal.RecoveryCode.UserID.Eq(user.ID), dal.RecoveryCode.UsedAt.IsNotNull()).Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used != 0 {
+		t.Errorf("used recovery codes = %d, want 0", used)
+	}
+}
+
+func TestEnrollTwoFactorForgetsLastStep(t *testing.T) {
+	newTestDB(t)
+	user, _, recoveryCodes := createTwoFactorUser(t, "alice@example.com")
+	rr := serve(t, http.MethodPost, "/auth/2fa/disable", map[string]string{
+		"password":      testPassword,
+		"recovery_code": recoveryCodes[0],
+	}, asUser(user), controllers.DisableTwoFactorHandler(newTestGuard(t)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("disable status = %d, body %s", rr.Code, rr.Body)
+	}
+	if findTestUser(t, user.Email).TotpLastStep == nil {
+		t.Fatal("last step was not stored")
+	}
+
+	rr = serve(t, http.MethodPost, "/auth/2fa/enroll", nil, asUser(user), controllers.EnrollTwoFactor)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("enroll status = %d, body %s", rr.Code, rr.Body)
+	}
+	if step := findTestUser(t, user.Email).TotpLastStep; step != nil {
+		t.Errorf("last step = %d after enrollment, want none", *step)
+	}
+}
+
+func TestDisableTwoFactorIsThrottled(t *testing.T) {
+	t.Run("lockout", func(t *testing.T) {
+		newTestDB(t)
+		t.Setenv("LOGIN_MAX_FAILED_ATTEMPTS", "2")
+		t.Setenv("LOGIN_LOCKOUT_DURATION", "15")
+		user, _, recoveryCodes := createTwoFactorUser(t, "alice@example.com")
+		handler := controllers.DisableTwoFactorHandler(newTestGuard(t))
+		disable := func(pass string) int {
+			return serve(t, http.MethodPost, "/auth/2fa/disable", map[string]string{
+				"password":      pass,
+				"recovery_code": recoveryCodes[0],
+			}, asUser(user), handler).Code
+		}
+
+		for i := 0; i < 2; i++ {
+			if code := disable("wrong password"); code != http.StatusBadRequest {
+				t.Fatalf("failure %d: status = %d, want %d", i+1, code, http.StatusBadRequest)
+			}
+		}
+		if code := disable(testPassword); code != http.StatusLocked {
+			t.Errorf("locked account: status = %d, want %d", code, http.StatusLocked)
+		}
+		if !twoFactorEnabled(t, user.ID) {
+			t.Error("two-factor authentication was disabled")
+		}
+	})
+
+	t.Run("backoff", func(t *testing.T) {
+		newTestDB(t)
+		user, _, _ := createTwoFactorUser(t, "alice@example.com")
+		guard := loginguard.NewGuard(time.Minute, time.Hour, time.Hour)
+		t.Cleanup(func() { guard.Close() })
+		handler := controllers.DisableTwoFactorHandler(guard)
+		body := map[string]string{"password": testPassword, "code": "000000"}
+
+		first := serve(t, http.MethodPost, "/auth/2fa/disable", body, asUser(user), handler)
+		second := serve(t, http.MethodPost, "/auth/2fa/disable", body, asUser(user), handler)
+
+		if first.Code != http.StatusBadRequest || second.Code != http.StatusTooManyRequests {
+			t.Errorf("status = %d then %d, want %d then %d", first.Code, second.Code, http.StatusBadRequest, http.StatusTooManyRequests)
+		}
+	})
+}
@@ -0,0 +1,356 @@
+package tests
+
+import (
+	"dbo-test/internal/controllers"
+	"dbo-test/internal/dal"
+	"dbo-test/internal/jwtkeys"
+	"dbo-test/internal/middlewares"
+	"dbo-test/internal/model"
+	"dbo-test/internal/password"
+	"dbo-test/internal/problem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// loginTokens logs in with the password testPassword and returns the access and refresh tokens.
+func loginTokens(t *testing.T, keys *jwtkeys.KeySet, email string) (string, string) {
+	t.Helper()
+	rr := serve(t, http.MethodPost, "/auth/login", map[string]string{"email": email, "password": testPassword},
+		controllers.LoginHandler(newTestGuard(t), keys))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("login status = %d, body %s", rr.Code, rr.Body)
+	}
+	var tokens struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	decodeData(t, rr, &tokens)
+	return tokens.AccessToken, tokens.RefreshToken
+}
+
+func loginRefreshToken(t *testing.T, keys *jwtkeys.KeySet, email string) string {
+	t.Helper()
+	_, refreshToken := loginTokens(t, keys, email)
+	return refreshToken
+}
+
+type userResponse struct {
+	ID    int32    `json:"id"`
+	Email string   `json:"email"`
+	Roles []string `json:"roles"`
+}
+
+func TestCreateUser(t *testing.T) {
+	newTestDB(t)
+	setTokenEnv(t)
+	t.Setenv("PASSWORD_BCRYPT_COST", "4")
+	keys := jwtkeys.NewHMAC([]byte("secret"))
+	admin := createPasswordUser(t, "admin@example.com")
+	asAdmin := asUser(admin, middlewares.RoleAdmin)
+	createUser := func(body map[string]any) *httptest.ResponseRecorder {
+		return serve(t, http.MethodPost, "/user", body, asAdmin, controllers.CreateUser)
+	}
+
+	tests := []struct {
+		name   string
+		body   map[string]any
+		status int
+	}{
+		{"weak password", map[string]any{"email": "alice@example.com", "password": "short"}, http.StatusBadRequest},
+		{"unknown role", map[string]any{"email": "alice@example.com", "password": testPassword, "roles": []string{"owner"}}, http.StatusBadRequest},
+		{"taken email", map[string]any{"email": admin.Email, "password": testPassword}, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		if rr := createUser(tt.body); rr.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, rr.Code, tt.status)
+		}
+	}
+
+	rr := createUser(map[string]any{"email": "alice@example.com", "password": testPassword, "roles": []string{middlewares.RoleSales}})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+	var created userResponse
+	decodeData(t, rr, &created)
+	if len(created.Roles) != 1 || created.Roles[0] != middlewares.RoleSales {
+		t.Errorf("roles = %v, want only %s", created.Roles, middlewares.RoleSales)
+	}
+	stored := findTestUser(t, created.Email).Password
+	if stored == testPassword || !password.Verify(stored, testPassword) {
+		t.Error("password is not stored as a matching hash")
+	}
+	loginTokens(t, keys, created.Email)
+
+	rr = createUser(map[string]any{"email": "bob@example.com", "password": testPassword})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+	decodeData(t, rr, &created)
+	if len(created.Roles) != 1 || created.Roles[0] != middlewares.RoleReadOnly {
+		t.Errorf("roles without roles given = %v, want only %s", created.Roles, middlewares.RoleReadOnly)
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	newTestDB(t)
+	setTokenEnv(t)
+	t.Setenv("PASSWORD_BCRYPT_COST", "4")
+	keys := jwtkeys.NewHMAC([]byte("secret"))
+	admin := createPasswordUser(t, "admin@example.com")
+	asAdmin := asUser(admin, middlewares.RoleAdmin)
+	user := createPasswordUser(t, "alice@example.com")
+	refreshToken := loginRefreshToken(t, keys, user.Email)
+	updateUser := func(body map[string]any) *httptest.ResponseRecorder {
+		return serveRoute(t, http.MethodPut, "/user/:id", fmt.Sprintf("/user/%d", user.ID), body, asAdmin, controllers.UpdateUser)
+	}
+	const newPassword = "a different long passphrase"
+
+	if rr := updateUser(map[string]any{"email": admin.Email}); rr.Code != http.StatusConflict {
+		t.Errorf("taken email: status = %d, want %d", rr.Code, http.StatusConflict)
+	}
+	if rr := updateUser(map[string]any{"password": "short"}); rr.Code != http.StatusBadRequest {
+		t.Errorf("weak password: status = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+	if !password.Verify(findTestUser(t, user.Email).Password, testPassword) {
+		t.Fatal("password was changed by a rejected update")
+	}
+
+	rr := updateUser(map[string]any{"email": "alice@example.org", "password": newPassword, "roles": []string{middlewares.RoleSales}})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+	var updated userResponse
+	decodeData(t, rr, &updated)
+	if updated.Email != "alice@example.org" || len(updated.Roles) != 1 || updated.Roles[0] != middlewares.RoleSales {
+		t.Errorf("updated user = %+v, want alice@example.org with only %s", updated, middlewares.RoleSales)
+	}
+	if !password.Verify(findTestUser(t, updated.Email).Password, newPassword) {
+		t.Error("new password does not match the stored hash")
+	}
+	expectInvalidRefreshToken(t, refreshTokens(t, keys, refreshToken))
+	rr = serve(t, http.MethodPost, "/auth/login", map[string]string{"email": updated.Email, "password": newPassword},
+		controllers.LoginHandler(newTestGuard(t), keys))
+	if rr.Code != http.StatusOK {
+		t.Errorf("login with the new password: status = %d, body %s", rr.Code, rr.Body)
+	}
+
+	// An update without roles keeps them.
+	rr = updateUser(map[string]any{"email": user.Email})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+	decodeData(t, rr, &updated)
+	if len(updated.Roles) != 1 || updated.Roles[0] != middlewares.RoleSales {
+		t.Errorf("roles = %v, want only %s", updated.Roles, middlewares.RoleSales)
+	}
+}
+
+func TestDisableAndEnableUser(t *testing.T) {
+	newTestDB(t)
+	setTokenEnv(t)
+	keys := jwtkeys.NewHMAC([]byte("secret"))
+	admin := createPasswordUser(t, "admin@example.com")
+	user := createPasswordUser(t, "alice@example.com")
+	refreshToken := loginRefreshToken(t, keys, user.Email)
+	asAdmin := asUser(admin, middlewares.RoleAdmin)
+	login := func() int {
+		return serve(t, http.MethodPost, "/auth/login", map[string]string{"email": user.Email, "password": testPassword},
+			controllers.LoginHandler(newTestGuard(t), keys)).Code
+	}
+
+	rr := serveRoute(t, http.MethodPost, "/user/:id/disable", fmt.Sprintf("/user/%d/disable", user.ID), nil, asAdmin, controllers.DisableUser)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("disable status = %d, body %s", rr.Code, rr.Body)
+	}
+	if findTestUser(t, user.Email).DisabledAt == nil {
+		t.Error("user is not disabled")
+	}
+	if code := login(); code != http.StatusForbidden {
+		t.Errorf("login of a disabled user: status = %d, want %d", code, http.StatusForbidden)
+	}
+	rr = serve(t, http.MethodPost, "/auth/refresh", map[string]string{"refresh_token": refreshToken}, controllers.RefreshTokenHandler(keys))
+	if rr.Code == http.StatusOK {
+		t.Error("refresh token of a disabled user still works")
+	}
+
+	rr = serveRoute(t, http.MethodPost, "/user/:id/enable", fmt.Sprintf("/user/%d/enable", user.ID), nil, asAdmin, controllers.EnableUser)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("enable status = %d, body %s", rr.Code, rr.Body)
+	}
+	if findTestUser(t, user.Email).DisabledAt != nil {
+		t.Error("user is still disabled")
+	}
+	if code := login(); code != http.StatusOK {
+		t.Errorf("login of an enabled user: status = %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestDisableUserErrors(t *testing.T) {
+	newTestDB(t)
+	admin := createPasswordUser(t, "admin@example.com")
+	asAdmin := asUser(admin, middlewares.RoleAdmin)
+
+	tests := []struct {
+		name, path string
+		status     int
+		code       string
+	}{
+		{"yourself", fmt.Sprintf("/user/%d/disable", admin.ID), http.StatusBadRequest, problem.CodeBadRequest},
+		{"unknown user", "/user/99/disable", http.StatusNotFound, problem.CodeNotFound},
+		{"invalid id", "/user/abc/disable", http.StatusBadRequest, problem.CodeInvalidParameter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := serveRoute(t, http.MethodPost, "/user/:id/disable", tt.path, nil, asAdmin, controllers.DisableUser)
+			if rr.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rr.Code, tt.status)
+			}
+			if got := decodeProblem(t, rr).Code; got != tt.code {
+				t.Errorf("code = %q, want %q", got, tt.code)
+			}
+		})
+	}
+	if findTestUser(t, admin.Email).DisabledAt != nil {
+		t.Error("admin disabled themselves")
+	}
+}
+
+func changeOwnPassword(t *testing.T, handler gin.HandlerFunc, user *model.User, current, next string) *httptest.ResponseRecorder {
+	t.Helper()
+	return serve(t, http.MethodPut, "/user/me/password", map[string]string{
+		"current_password": current,
+		"new_password":     next,
+	}, asUser(user, middlewares.RoleReadOnly), handler)
+}
+
+func TestChangeOwnPassword(t *testing.T) {
+	newTestDB(t)
+	setTokenEnv(t)
+	t.Setenv("PASSWORD_BCRYPT_COST", "4")
+	keys := jwtkeys.NewHMAC([]byte("secret"))
+	user := createPasswordUser(t, "alice@example.com")
+	refreshToken := loginRefreshToken(t, keys, user.Email)
+	handler := controllers.ChangeOwnPasswordHandler(newTestGuard(t))
+	const newPassword = "a different long passphrase"
+
+	rr := changeOwnPassword(t, handler, user, "wrong password", newPassword)
+	if rr.Code != http.StatusBadRequest || decodeProblem(t, rr).Code != problem.CodeInvalidCredentials {
+		t.Fatalf("wrong current password: status = %d, body %s", rr.Code, rr.Body)
+	}
+
+	rr = changeOwnPassword(t, handler, user, testPassword, newPassword)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+	}
+	if !password.Verify(findTestUser(t, user.Email).Password, newPassword) {
+		t.Error("new password does not match the stored hash")
+	}
+	rr = serve(t, http.MethodPost, "/auth/refresh", map[string]string{"refresh_token": refreshToken}, controllers.RefreshTokenHandler(keys))
+	if rr.Code == http.StatusOK {
+		t.Error("refresh token issued before the change still works")
+	}
+}
+
+func TestChangeOwnPasswordIsThrottled(t *testing.T) {
+	newTestDB(t)
+	t.Setenv("LOGIN_MAX_FAILED_ATTEMPTS", "2")
+	t.Setenv("LOGIN_LOCKOUT_DURATION", "15")
+	user := createPasswordUser(t, "alice@example.com")
+	handler := controllers.ChangeOwnPasswordHandler(newTestGuard(t))
+	const newPassword = "a different long passphrase"
+
+	for i := 0; i < 2; i++ {
+		if rr := changeOwnPassword(t, handler, user, "wrong password", newPassword); rr.Code != http.StatusBadRequest {
+			t.Fatalf("failure %d: status = %d, want %d", i+1, rr.Code, http.StatusBadRequest)
+		}
+	}
+	if rr := changeOwnPassword(t, handler, user, testPassword, newPassword); rr.Code != http.StatusLocked {
+		t.Errorf("locked account: status = %d, want %d", rr.Code, http.StatusLocked)
+	}
+	if !password.Verify(findTestUser(t, user.Email).Password, testPassword) {
+		t.Error("password was changed")
+	}
+}
+
+func TestGetMultipleUserOrder(t *testing.T) {
+	newTestDB(t)
+	for i, email := range []string{"bob@example.com", "alice@example.com", "carol@example.com"} {
+		user := createPasswordUser(t, email)
+		if _, err := dal.User.Where(dal.User.ID.Eq(user.ID)).Update(dal.User.Password, fmt.Sprintf("hash %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	emails := func(order string) string {
+		t.Helper()
+		rr := serveRoute(t, http.MethodGet, "/user", "/user?order="+url.QueryEscape(order), nil, controllers.GetMultipleUser)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("status = %d, body %s", rr.Code, rr.Body)
+		}
+		var page struct {
+			Data []struct {
+				Email string `json:"email"`
+			} `json:"data"`
+		}
+		decodeData(t, rr, &page)
+		var emails []string
+		for _, user := range page.Data {
+			emails = append(emails, user.Email)
+		}
+		return strings.Join(emails, " ")
+	}
+
+	if got, want := emails("email desc"), "carol@example.com bob@example.com alice@example.com"; got != want {
+		t.Errorf("ordered by email: %s, want %s", got, want)
+	}
+	// Columns that are not listed, such as the password hash, are ignored.
+	if got, want := emails("password desc"), "bob@example.com alice@example.com carol@example.com"; got != want {
+		t.Errorf("ordered by password: %s, want %s", got, want)
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	db := newTestDB(t)
+	admin := createPasswordUser(t, "admin@example.com")
+	asAdmin := asUser(admin, middlewares.RoleAdmin)
+	user, _, _ := createTwoFactorUser(t, "alice@example.com")
+	requestPasswordReset(t, user.Email)
+	role, err := dal.Role.Where(dal.Role.Name.Eq(middlewares.RoleReadOnly)).First()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dal.UserRole.Create(&model.UserRole{UserID: user.ID, RoleID: role.ID}); err != nil {
+		t.Fatal(err)
+	}
+	deleteUser := func(id int32) *httptest.ResponseRecorder {
+		return serveRoute(t, http.MethodDelete, "/user/:id", fmt.Sprintf("/user/%d", id), nil, asAdmin, controllers.DeleteUser)
+	}
+
+	if rr := deleteUser(admin.ID); rr.Code != http.StatusBadRequest {
+		t.Errorf("delete yourself: status = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+	if rr := deleteUser(user.ID); rr.Code != http.StatusOK {
+		t.Fatalf("delete status = %d, body %s", rr.Code, rr.Body)
+	}
+	for _, table := range []string{"users", "user_roles", "recovery_codes", "password_resets"} {
+		column := "user_id"
+		if table == "users" {
+			column = "id"
+		}
+		var count int64
+		if err := db.Table(table).Where(column+" = ?", user.ID).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Errorf("%s rows of the deleted user = %d, want 0", table, count)
+		}
+	}
+	if rr := deleteUser(user.ID); rr.Code != http.StatusNotFound {
+		t.Errorf("delete again: status = %d, want %d", rr.Code, http.StatusNotFound)
+	}
+}
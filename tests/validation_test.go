@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"dbo-test/internal/validation"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type validationAddress struct {
+	Country string `json:"country" binding:"required,iso3166_1_alpha2"`
+}
+
+type validationReq struct {
+	Name      string              `json:"name" binding:"required"`
+	Email     string              `json:"email" binding:"required,email"`
+	Phone     string              `json:"phone" binding:"omitempty,e164"`
+	Amount    float64             `json:"amount" binding:"gt=0"`
+	OrderDate time.Time           `json:"order_date" binding:"required,notfarfuture"`
+	Tags      []string            `json:"tags" binding:"required,min=1"`
+	Addresses []validationAddress `json:"addresses" binding:"dive"`
+}
+
+func TestValidationStruct(t *testing.T) {
+	valid := validationReq{
+		Name:      "Jane",
+		Email:     "jane@example.com",
+		Phone:     "+6281234567890",
+		Amount:    10,
+		OrderDate: time.Now(),
+		Tags:      []string{"vip"},
+		Addresses: []validationAddress{{Country: "ID"}},
+	}
+	if err := validation.Struct(valid); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := validationReq{
+		Email:     "jane",
+		Phone:     "0812-3456",
+		Amount:    -5,
+		OrderDate: time.Now().Add(validation.MaxFuture + time.Hour),
+		Tags:      []string{},
+		Addresses: []validationAddress{{Country: "XX"}},
+	}
+	var errs validation.Errors
+	if err := validation.Struct(invalid); !errors.As(err, &errs) {
+		t.Fatalf("got %v, want validation errors", err)
+	}
+	var got [][2]string
+	for _, fe := range errs {
+		if fe.Message == "" {
+			t.Errorf("%s has no message", fe.Field)
+		}
+		got = append(got, [2]string{fe.Field, fe.Code})
+	}
+	want := [][2]string{
+		{"name", validation.CodeRequired},
+		{"email", validation.CodeFormat},
+		{"phone", validation.CodeFormat},
+		{"amount", validation.CodeMin},
+		{"order_date", validation.CodeFuture},
+		{"tags", validation.CodeMin},
+		{"addresses[0].country", validation.CodeFormat},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestValidationStructPartial(t *testing.T) {
+	legacy := validationReq{
+		Name:      "Jane",
+		Email:     "jane",
+		Phone:     "0812-3456",
+		Amount:    10,
+		OrderDate: time.Now(),
+		Tags:      []string{"vip"},
+	}
+	if err := validation.StructPartial(&legacy, "name", "amount"); err != nil {
+		t.Errorf("unchanged fields were checked: %v", err)
+	}
+	if err := validation.StructPartial(&legacy); err != nil {
+		t.Errorf("no fields named, got %v", err)
+	}
+
+	var errs validation.Errors
+	if err := validation.StructPartial(&legacy, "name", "phone"); !errors.As(err, &errs) {
+		t.Fatalf("got %v, want validation errors", err)
+	}
+	if len(errs) != 1 || errs[0].Field != "phone" || errs[0].Code != validation.CodeFormat {
+		t.Errorf("got %v, want a format problem with phone", errs)
+	}
+}